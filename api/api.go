@@ -40,6 +40,9 @@ func InitApi() {
 	InitWebSocket(r)
 	InitFile(r)
 	InitCommand(r)
+	InitScheduledPost(r)
+	InitDraft(r)
+	InitGroup(r)
 
 	templatesDir := utils.FindDir("api/templates")
 	l4g.Debug("Parsing server templates at %v", templatesDir)
@@ -13,12 +13,18 @@ import (
 	"strings"
 )
 
+const (
+	MENTION_RECOUNT_PAGE_SIZE = 1000
+	MENTION_RECOUNT_MAX_PAGES = 5
+)
+
 func InitChannel(r *mux.Router) {
 	l4g.Debug("Initializing channel api routes")
 
 	sr := r.PathPrefix("/channels").Subrouter()
 	sr.Handle("/", ApiUserRequiredActivity(getChannels, false)).Methods("GET")
 	sr.Handle("/more", ApiUserRequired(getMoreChannels)).Methods("GET")
+	sr.Handle("/unreads", ApiUserRequired(getChannelUnreads)).Methods("GET")
 	sr.Handle("/create", ApiUserRequired(createChannel)).Methods("POST")
 	sr.Handle("/create_direct", ApiUserRequired(createDirectChannel)).Methods("POST")
 	sr.Handle("/update", ApiUserRequired(updateChannel)).Methods("POST")
@@ -31,7 +37,9 @@ func InitChannel(r *mux.Router) {
 	sr.Handle("/{id:[A-Za-z0-9]+}/add", ApiUserRequired(addChannelMember)).Methods("POST")
 	sr.Handle("/{id:[A-Za-z0-9]+}/remove", ApiUserRequired(removeChannelMember)).Methods("POST")
 	sr.Handle("/{id:[A-Za-z0-9]+}/update_last_viewed_at", ApiUserRequired(updateLastViewedAt)).Methods("POST")
+	sr.Handle("/{id:[A-Za-z0-9]+}/mark_unread", ApiUserRequired(markChannelUnread)).Methods("POST")
 
+	HandleWebSocket(model.WEBSOCKET_UPDATE_LAST_VIEWED, updateLastViewedAtWebSocket)
 }
 
 func createChannel(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -146,6 +154,21 @@ func CreateDirectChannel(c *Context, otherUserId string) (*model.Channel, *model
 	}
 }
 
+// GetDirectChannel returns the direct channel between the session user and
+// the other user, creating it the first time they talk.
+func GetDirectChannel(c *Context, otherUserId string) (*model.Channel, *model.AppError) {
+	name := otherUserId + "__" + c.Session.UserId
+	if otherUserId > c.Session.UserId {
+		name = c.Session.UserId + "__" + otherUserId
+	}
+
+	if result := <-Srv.Store.Channel().GetByName(c.Session.TeamId, name); result.Err == nil {
+		return result.Data.(*model.Channel), nil
+	}
+
+	return CreateDirectChannel(c, otherUserId)
+}
+
 func CreateDefaultChannels(c *Context, teamId string) ([]*model.Channel, *model.AppError) {
 	townSquare := &model.Channel{DisplayName: "Town Square", Name: "town-square", Type: model.CHANNEL_OPEN, TeamId: teamId}
 
@@ -174,6 +197,7 @@ func updateChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	sc := Srv.Store.Channel().Get(channel.Id)
 	cmc := Srv.Store.Channel().GetMember(channel.Id, c.Session.UserId)
+	uc := Srv.Store.User().Get(c.Session.UserId)
 
 	if cresult := <-sc; cresult.Err != nil {
 		c.Err = cresult.Err
@@ -181,9 +205,13 @@ func updateChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 	} else if cmcresult := <-cmc; cmcresult.Err != nil {
 		c.Err = cmcresult.Err
 		return
+	} else if uresult := <-uc; uresult.Err != nil {
+		c.Err = uresult.Err
+		return
 	} else {
 		oldChannel := cresult.Data.(*model.Channel)
 		channelMember := cmcresult.Data.(model.ChannelMember)
+		user := uresult.Data.(*model.User)
 		if !c.HasPermissionsToTeam(oldChannel.TeamId, "updateChannel") {
 			return
 		}
@@ -206,6 +234,9 @@ func updateChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		oldDescription := oldChannel.Description
+		oldDisplayName := oldChannel.DisplayName
+
 		oldChannel.Description = channel.Description
 
 		if len(channel.DisplayName) > 0 {
@@ -225,6 +256,15 @@ func updateChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		} else {
 			c.LogAudit("name=" + channel.Name)
+
+			if oldDescription != oldChannel.Description {
+				postHeaderChangeMessage(c, oldChannel.Id, user, oldDescription, oldChannel.Description)
+			}
+
+			if oldDisplayName != oldChannel.DisplayName {
+				postDisplayNameChangeMessage(c, oldChannel.Id, user, oldDisplayName, oldChannel.DisplayName)
+			}
+
 			w.Write([]byte(oldChannel.ToJson()))
 		}
 	}
@@ -247,6 +287,7 @@ func updateChannelDesc(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	sc := Srv.Store.Channel().Get(channelId)
 	cmc := Srv.Store.Channel().GetMember(channelId, c.Session.UserId)
+	uc := Srv.Store.User().Get(c.Session.UserId)
 
 	if cresult := <-sc; cresult.Err != nil {
 		c.Err = cresult.Err
@@ -254,14 +295,19 @@ func updateChannelDesc(c *Context, w http.ResponseWriter, r *http.Request) {
 	} else if cmcresult := <-cmc; cmcresult.Err != nil {
 		c.Err = cmcresult.Err
 		return
+	} else if uresult := <-uc; uresult.Err != nil {
+		c.Err = uresult.Err
+		return
 	} else {
 		channel := cresult.Data.(*model.Channel)
 		// Don't need to do anything channel member, just wanted to confirm it exists
+		user := uresult.Data.(*model.User)
 
 		if !c.HasPermissionsToTeam(channel.TeamId, "updateChannelDesc") {
 			return
 		}
 
+		oldDescription := channel.Description
 		channel.Description = channelDesc
 
 		if ucresult := <-Srv.Store.Channel().Update(channel); ucresult.Err != nil {
@@ -269,6 +315,11 @@ func updateChannelDesc(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		} else {
 			c.LogAudit("name=" + channel.Name)
+
+			if oldDescription != channel.Description {
+				postHeaderChangeMessage(c, channel.Id, user, oldDescription, channel.Description)
+			}
+
 			w.Write([]byte(channel.ToJson()))
 		}
 	}
@@ -364,9 +415,12 @@ func JoinChannel(c *Context, channelId string, role string) {
 				return
 			}
 
-			post := &model.Post{ChannelId: channel.Id, Message: fmt.Sprintf(
+			publishMemberChange(channel.TeamId, channel.Id, c.Session.UserId, model.ACTION_USER_ADDED)
+
+			post := &model.Post{ChannelId: channel.Id, Type: model.POST_JOIN_CHANNEL, Message: fmt.Sprintf(
 				`User %v has joined this channel.`,
 				user.Username)}
+			post.AddProp("username", user.Username)
 			if _, err := CreatePost(c, post, false); err != nil {
 				l4g.Error("Failed to post join message %v", err)
 				c.Err = model.NewAppError("joinChannel", "Failed to send join request", "")
@@ -451,9 +505,12 @@ func leaveChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		post := &model.Post{ChannelId: channel.Id, Message: fmt.Sprintf(
+		publishMemberChange(channel.TeamId, channel.Id, c.Session.UserId, model.ACTION_USER_REMOVED)
+
+		post := &model.Post{ChannelId: channel.Id, Type: model.POST_LEAVE_CHANNEL, Message: fmt.Sprintf(
 			`%v has left the channel.`,
 			user.Username)}
+		post.AddProp("username", user.Username)
 		if _, err := CreatePost(c, post, false); err != nil {
 			l4g.Error("Failed to post leave message %v", err)
 			c.Err = model.NewAppError("leaveChannel", "Failed to send leave message", "")
@@ -518,9 +575,10 @@ func deleteChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 
 		c.LogAudit("name=" + channel.Name)
 
-		post := &model.Post{ChannelId: channel.Id, Message: fmt.Sprintf(
+		post := &model.Post{ChannelId: channel.Id, Type: model.POST_CHANNEL_DELETED, Message: fmt.Sprintf(
 			`%v has archived the channel.`,
 			user.Username)}
+		post.AddProp("username", user.Username)
 		if _, err := CreatePost(c, post, false); err != nil {
 			l4g.Error("Failed to post archive message %v", err)
 			c.Err = model.NewAppError("deleteChannel", "Failed to send archive message", "")
@@ -537,18 +595,169 @@ func updateLastViewedAt(c *Context, w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
 
-	Srv.Store.Channel().UpdateLastViewedAt(id, c.Session.UserId)
-
-	message := model.NewMessage(c.Session.TeamId, id, c.Session.UserId, model.ACTION_VIEWED)
-	message.Add("channel_id", id)
-
-	store.PublishAndForget(message)
+	markChannelViewed(c, id)
 
 	result := make(map[string]string)
 	result["id"] = id
 	w.Write([]byte(model.MapToJson(result)))
 }
 
+func updateLastViewedAtWebSocket(c *Context, data map[string]string) map[string]string {
+	id := data["channel_id"]
+	if len(id) != 26 {
+		c.SetInvalidParam("updateLastViewedAt", "channel_id")
+		return nil
+	}
+
+	markChannelViewed(c, id)
+
+	return map[string]string{"id": id}
+}
+
+func markChannelViewed(c *Context, channelId string) {
+	Srv.Store.Channel().UpdateLastViewedAt(channelId, c.Session.UserId)
+
+	message := model.NewMessage(c.Session.TeamId, channelId, c.Session.UserId, model.ACTION_VIEWED)
+	message.Add("channel_id", channelId)
+
+	store.PublishAndForget(message)
+}
+
+func getChannelUnreads(c *Context, w http.ResponseWriter, r *http.Request) {
+	if result := <-Srv.Store.Channel().GetUnreads(c.Session.TeamId, c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Header().Set("Expires", "-1")
+		w.Write([]byte(model.ChannelUnreadsToJson(result.Data.([]*model.ChannelUnread))))
+	}
+}
+
+func markChannelUnread(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	data := model.MapFromJson(r.Body)
+	postId := data["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("markChannelUnread", "post_id")
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.Session.TeamId, id, c.Session.UserId)
+	pchan := Srv.Store.Post().Get(postId)
+	uchan := Srv.Store.User().Get(c.Session.UserId)
+
+	if !c.HasPermissionsToChannel(cchan, "markChannelUnread") {
+		return
+	}
+
+	var post *model.Post
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else if post = result.Data.(*model.PostList).Posts[postId]; post == nil || post.ChannelId != id || post.DeleteAt != 0 {
+		c.SetInvalidParam("markChannelUnread", "post_id")
+		return
+	}
+
+	var user *model.User
+	if result := <-uchan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	lastViewedAt := post.CreateAt - 1
+
+	mentionCount, err := countMentionsSince(user, c.Session.TeamId, id, lastViewedAt)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if result := <-Srv.Store.Channel().MarkUnread(id, c.Session.UserId, lastViewedAt, mentionCount); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	if result := <-Srv.Store.Channel().GetUnread(id, c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		unread := result.Data.(*model.ChannelUnread)
+
+		message := model.NewMessage(c.Session.TeamId, id, c.Session.UserId, model.ACTION_CHANNEL_UNREAD)
+		message.Add("channel_id", id)
+		message.Add("unread", unread.ToJson())
+
+		store.PublishAndForget(message)
+
+		w.Write([]byte(unread.ToJson()))
+	}
+}
+
+// countMentionsSince recounts how many posts after since would have bumped the
+// user's mention count in the channel, the way fireAndForgetNotifications does.
+// Replies to threads the user follows and system messages never count. It
+// runs while the request waits so it stops after MENTION_RECOUNT_MAX_PAGES
+// pages, marking an old post unread in a busy channel gives a capped count.
+func countMentionsSince(user *model.User, teamId string, channelId string, since int64) (int64, *model.AppError) {
+	parser := model.NewMentionParser()
+	parser.AddUser(user)
+
+	if result := <-Srv.Store.Group().GetMentionMap(teamId); result.Err != nil {
+		return 0, result.Err
+	} else {
+		for name, userIds := range result.Data.(map[string][]string) {
+			for _, userId := range userIds {
+				if userId == user.Id {
+					parser.AddGroup(name, []string{user.Id})
+				}
+			}
+		}
+	}
+
+	var count int64
+	counted := make(map[string]bool)
+	for page := 0; page < MENTION_RECOUNT_MAX_PAGES; page++ {
+		var list *model.PostList
+		if result := <-Srv.Store.Post().GetPostsSince(channelId, since, MENTION_RECOUNT_PAGE_SIZE); result.Err != nil {
+			return 0, result.Err
+		} else {
+			list = result.Data.(*model.PostList)
+		}
+
+		for _, id := range list.Order {
+			post := list.Posts[id]
+			if counted[id] || post.UserId == user.Id || post.IsSystemMessage() {
+				continue
+			}
+
+			// @here isn't counted since there's no telling who was online when the post was made
+			if parser.Parse(post.Message).IsMentioned(user, false) {
+				count++
+				counted[id] = true
+			}
+		}
+
+		if len(list.Order) < MENTION_RECOUNT_PAGE_SIZE {
+			break
+		}
+
+		// Start the next page one millisecond back so posts sharing the last
+		// post's CreateAt aren't skipped, counted stops them counting twice
+		last := list.Posts[list.Order[len(list.Order)-1]].CreateAt
+		if last-1 == since {
+			break
+		}
+		since = last - 1
+	}
+
+	return count, nil
+}
+
 func getChannelExtraInfo(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	params := mux.Vars(r)
@@ -557,6 +766,7 @@ func getChannelExtraInfo(c *Context, w http.ResponseWriter, r *http.Request) {
 	sc := Srv.Store.Channel().Get(id)
 	scm := Srv.Store.Channel().GetMember(id, c.Session.UserId)
 	ecm := Srv.Store.Channel().GetExtraMembers(id, 20)
+	ppc := Srv.Store.Post().GetPinnedPostCount(id)
 
 	if cresult := <-sc; cresult.Err != nil {
 		c.Err = cresult.Err
@@ -567,10 +777,14 @@ func getChannelExtraInfo(c *Context, w http.ResponseWriter, r *http.Request) {
 	} else if ecmresult := <-ecm; ecmresult.Err != nil {
 		c.Err = ecmresult.Err
 		return
+	} else if ppcresult := <-ppc; ppcresult.Err != nil {
+		c.Err = ppcresult.Err
+		return
 	} else {
 		channel := cresult.Data.(*model.Channel)
 		member := cmresult.Data.(model.ChannelMember)
 		extraMembers := ecmresult.Data.([]model.ExtraMember)
+		pinnedPostCount := ppcresult.Data.(int64)
 
 		if !c.HasPermissionsToTeam(channel.TeamId, "getChannelExtraInfo") {
 			return
@@ -586,7 +800,7 @@ func getChannelExtraInfo(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		data := model.ChannelExtra{Id: channel.Id, Members: extraMembers}
+		data := model.ChannelExtra{Id: channel.Id, Members: extraMembers, PinnedPostCount: pinnedPostCount}
 		w.Header().Set("Expires", "-1")
 		w.Write([]byte(data.ToJson()))
 	}
@@ -644,9 +858,14 @@ func addChannelMember(c *Context, w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			post := &model.Post{ChannelId: id, Message: fmt.Sprintf(
+			publishMemberChange(channel.TeamId, channel.Id, userId, model.ACTION_USER_ADDED)
+
+			post := &model.Post{ChannelId: id, Type: model.POST_ADD_TO_CHANNEL, Message: fmt.Sprintf(
 				`%v added to the channel by %v`,
 				nUser.Username, oUser.Username)}
+			post.AddProp("username", oUser.Username)
+			post.AddProp("added_user_id", nUser.Id)
+			post.AddProp("added_username", nUser.Username)
 			if _, err := CreatePost(c, post, false); err != nil {
 				l4g.Error("Failed to post add message %v", err)
 				c.Err = model.NewAppError("addChannelMember", "Failed to add member to channel", "")
@@ -675,6 +894,8 @@ func removeChannelMember(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	sc := Srv.Store.Channel().Get(id)
 	cmc := Srv.Store.Channel().GetMember(id, c.Session.UserId)
+	ouc := Srv.Store.User().Get(c.Session.UserId)
+	ruc := Srv.Store.User().Get(userId)
 
 	if cresult := <-sc; cresult.Err != nil {
 		c.Err = cresult.Err
@@ -682,9 +903,17 @@ func removeChannelMember(c *Context, w http.ResponseWriter, r *http.Request) {
 	} else if cmcresult := <-cmc; cmcresult.Err != nil {
 		c.Err = cmcresult.Err
 		return
+	} else if oresult := <-ouc; oresult.Err != nil {
+		c.Err = oresult.Err
+		return
+	} else if rresult := <-ruc; rresult.Err != nil {
+		c.Err = model.NewAppError("removeChannelMember", "Failed to find user to be removed", "")
+		return
 	} else {
 		channel := cresult.Data.(*model.Channel)
 		channelMember := cmcresult.Data.(model.ChannelMember)
+		oUser := oresult.Data.(*model.User)
+		rUser := rresult.Data.(*model.User)
 
 		if !c.HasPermissionsToTeam(channel.TeamId, "removeChannelMember") {
 			return
@@ -707,6 +936,18 @@ func removeChannelMember(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		publishMemberChange(channel.TeamId, channel.Id, userId, model.ACTION_USER_REMOVED)
+
+		post := &model.Post{ChannelId: channel.Id, Type: model.POST_REMOVE_FROM_CHANNEL, Message: fmt.Sprintf(
+			`%v removed from the channel by %v`,
+			rUser.Username, oUser.Username)}
+		post.AddProp("username", oUser.Username)
+		post.AddProp("removed_user_id", rUser.Id)
+		post.AddProp("removed_username", rUser.Username)
+		if _, err := CreatePost(c, post, false); err != nil {
+			l4g.Error("Failed to post remove message %v", err)
+		}
+
 		c.LogAudit("name=" + channel.Name + " user_id=" + userId)
 
 		result := make(map[string]string)
@@ -717,6 +958,50 @@ func removeChannelMember(c *Context, w http.ResponseWriter, r *http.Request) {
 
 }
 
+func postHeaderChangeMessage(c *Context, channelId string, user *model.User, oldHeader, newHeader string) {
+	var message string
+	if len(newHeader) == 0 {
+		message = fmt.Sprintf(`%v removed the channel header (was: %v)`, user.Username, oldHeader)
+	} else if len(oldHeader) == 0 {
+		message = fmt.Sprintf(`%v updated the channel header to: %v`, user.Username, newHeader)
+	} else {
+		message = fmt.Sprintf(`%v updated the channel header from: %v to: %v`, user.Username, oldHeader, newHeader)
+	}
+
+	post := &model.Post{ChannelId: channelId, Type: model.POST_HEADER_CHANGE, Message: message}
+	post.AddProp("username", user.Username)
+	post.AddProp("old_header", oldHeader)
+	post.AddProp("new_header", newHeader)
+	if _, err := CreatePost(c, post, false); err != nil {
+		l4g.Error("Failed to post header change message %v", err)
+	}
+}
+
+func postDisplayNameChangeMessage(c *Context, channelId string, user *model.User, oldDisplayName, newDisplayName string) {
+	post := &model.Post{ChannelId: channelId, Type: model.POST_DISPLAYNAME_CHANGE, Message: fmt.Sprintf(
+		`%v updated the channel display name from: %v to: %v`,
+		user.Username, oldDisplayName, newDisplayName)}
+	post.AddProp("username", user.Username)
+	post.AddProp("old_displayname", oldDisplayName)
+	post.AddProp("new_displayname", newDisplayName)
+	if _, err := CreatePost(c, post, false); err != nil {
+		l4g.Error("Failed to post display name change message %v", err)
+	}
+}
+
+// publishMemberChange tells every node that userId joined or left the channel so
+// open websockets drop their cached channel permissions. It is published
+// synchronously so it is ordered ahead of any posts that follow the change.
+func publishMemberChange(teamId, channelId, userId, action string) {
+	message := model.NewMessage(teamId, channelId, userId, action)
+	message.Add("channel_id", channelId)
+	message.Add("user_id", userId)
+
+	if err := store.Publish(message); err != nil {
+		l4g.Error("Failed to publish membership change channel_id=%v user_id=%v err=%v", channelId, userId, err)
+	}
+}
+
 func updateNotifyLevel(c *Context, w http.ResponseWriter, r *http.Request) {
 	data := model.MapFromJson(r.Body)
 	userId := data["user_id"]
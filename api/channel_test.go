@@ -7,6 +7,7 @@ import (
 	"github.com/mattermost/platform/model"
 	"net/http"
 	"testing"
+	"time"
 )
 
 func TestCreateChannel(t *testing.T) {
@@ -666,6 +667,167 @@ func TestRemoveChannelMember(t *testing.T) {
 
 }
 
+func TestChannelSystemMessages(t *testing.T) {
+	Setup()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "A Test API Name", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	lastPost := func() *model.Post {
+		list := Client.Must(Client.GetPosts(channel1.Id, 0, 1, "")).Data.(*model.PostList)
+		return list.Posts[list.Order[0]]
+	}
+
+	Client.Must(Client.AddChannelMember(channel1.Id, user2.Id))
+	if post := lastPost(); post.Type != model.POST_ADD_TO_CHANNEL || post.Props["added_user_id"] != user2.Id || post.Props["added_username"] != user2.Username || post.Props["username"] != user1.Username {
+		t.Fatal("add to channel message didn't match")
+	}
+
+	Client.Must(Client.RemoveChannelMember(channel1.Id, user2.Id))
+	if post := lastPost(); post.Type != model.POST_REMOVE_FROM_CHANNEL || post.Props["removed_user_id"] != user2.Id || post.Props["removed_username"] != user2.Username {
+		t.Fatal("remove from channel message didn't match")
+	}
+
+	Client.Must(Client.UpdateChannelDesc(map[string]string{"channel_id": channel1.Id, "channel_description": "new desc"}))
+	if post := lastPost(); post.Type != model.POST_HEADER_CHANGE || post.Props["old_header"] != "" || post.Props["new_header"] != "new desc" {
+		t.Fatal("header change message didn't match")
+	}
+
+	Client.Must(Client.UpdateChannelDesc(map[string]string{"channel_id": channel1.Id, "channel_description": "new desc"}))
+	if post := lastPost(); post.Type != model.POST_HEADER_CHANGE || post.Props["old_header"] != "" {
+		t.Fatal("setting the same header shouldn't post again")
+	}
+
+	channel1.DisplayName = "A New Test API Name"
+	channel1.Description = "new desc"
+	Client.Must(Client.UpdateChannel(channel1))
+	if post := lastPost(); post.Type != model.POST_DISPLAYNAME_CHANGE || post.Props["old_displayname"] != "A Test API Name" || post.Props["new_displayname"] != "A New Test API Name" {
+		t.Fatal("display name change message didn't match")
+	}
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	Client.Must(Client.JoinChannel(channel1.Id))
+	if post := lastPost(); post.Type != model.POST_JOIN_CHANNEL || post.UserId != user2.Id || post.Props["username"] != user2.Username {
+		t.Fatal("join message didn't match")
+	}
+
+	if _, err := Client.UpdatePost(&model.Post{Id: lastPost().Id, ChannelId: channel1.Id, Message: "edited"}); err == nil {
+		t.Fatal("should not be able to edit a system message")
+	}
+
+	if _, err := Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "fake", Type: model.POST_JOIN_CHANNEL}); err == nil {
+		t.Fatal("should not be able to post a system message")
+	}
+
+	Client.Must(Client.LeaveChannel(channel1.Id))
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+	if post := lastPost(); post.Type != model.POST_LEAVE_CHANNEL || post.Props["username"] != user2.Username {
+		t.Fatal("leave message didn't match")
+	}
+
+	// An @channel in the header doesn't alert anyone when the change is posted
+	Client.Must(Client.AddChannelMember(channel1.Id, user2.Id))
+	Client.Must(Client.UpdateChannelDesc(map[string]string{"channel_id": channel1.Id, "channel_description": "@channel read this"}))
+
+	time.Sleep(500 * time.Millisecond)
+
+	if member := (<-Srv.Store.Channel().GetMember(channel1.Id, user2.Id)).Data.(model.ChannelMember); member.MentionCount != 0 {
+		t.Fatal("system messages should not count as mentions")
+	}
+}
+
+func TestMarkChannelUnread(t *testing.T) {
+	Setup()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "A Test API Name", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+	Client.Must(Client.AddChannelMember(channel1.Id, user2.Id))
+
+	channel2 := &model.Channel{DisplayName: "A Test API Name", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel2 = Client.Must(Client.CreateChannel(channel2)).Data.(*model.Channel)
+
+	post1 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "hey @" + user2.Username}))
+	post3 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel2.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+
+	// Replies to a thread user2 follows are unread but aren't mentions
+	<-Srv.Store.Thread().AutoFollow(post1.Id, []string{user2.Id})
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, RootId: post1.Id, ParentId: post1.Id, Message: "a" + model.NewId() + "a"}))
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+	Client.Must(Client.UpdateLastViewedAt(channel1.Id))
+
+	unreads := Client.Must(Client.GetChannelUnreads()).Data.([]*model.ChannelUnread)
+	for _, unread := range unreads {
+		if unread.ChannelId == channel1.Id && (unread.MsgCount != 0 || unread.MentionCount != 0) {
+			t.Fatal("channel should have been read")
+		}
+	}
+
+	if unread := Client.Must(Client.MarkChannelUnread(channel1.Id, post1.Id)).Data.(*model.ChannelUnread); unread.ChannelId != channel1.Id {
+		t.Fatal("wrong channel")
+	} else if unread.MsgCount != 3 || unread.MentionCount != 1 {
+		t.Fatal("should have had three unread posts and one mention", unread.MsgCount, unread.MentionCount)
+	} else if unread.LastViewedAt != post1.CreateAt-1 {
+		t.Fatal("last viewed at should be just before the post")
+	}
+
+	found := false
+	unreads = Client.Must(Client.GetChannelUnreads()).Data.([]*model.ChannelUnread)
+	for _, unread := range unreads {
+		if unread.ChannelId == channel1.Id {
+			found = true
+			if unread.MsgCount != 3 || unread.MentionCount != 1 {
+				t.Fatal("unreads didn't match")
+			}
+		}
+	}
+
+	if !found {
+		t.Fatal("should have listed the channel")
+	}
+
+	if _, err := Client.MarkChannelUnread(channel1.Id, post3.Id); err == nil {
+		t.Fatal("Should have errored, post in another channel")
+	}
+
+	if _, err := Client.MarkChannelUnread(channel2.Id, post3.Id); err == nil {
+		t.Fatal("Should have errored, not a member of the channel")
+	}
+
+	if _, err := Client.MarkChannelUnread(channel1.Id, "junk"); err == nil {
+		t.Fatal("Should have errored, bad post id")
+	}
+}
+
 func TestUpdateNotifyLevel(t *testing.T) {
 	Setup()
 
@@ -0,0 +1,106 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	l4g "code.google.com/p/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"strings"
+	"time"
+)
+
+// Every app server keeps its own caches, so changes behind them are
+// announced on a redis channel all of them listen to. Team hubs can't carry
+// these since a server only runs a hub while the team has connections there.
+
+const (
+	CLOSE_REASON_SESSION_REVOKED = "session revoked"
+	CLOSE_REASON_SESSION_UPDATED = "session updated"
+)
+
+func StartClusterListener() {
+	go func() {
+		delay := time.Duration(0)
+		lost := false
+
+		for {
+			pubsub, err := store.Subscribe(store.CLUSTER_CHANNEL, REDIS_WAIT)
+			if err != nil {
+				if !lost {
+					l4g.Error("Lost the cluster subscription err=%v", err)
+					lost = true
+				}
+
+				delay = nextRedisReconnectDelay(delay)
+				time.Sleep(delay)
+				continue
+			}
+
+			if lost {
+				// Anything could have been revoked while we weren't listening
+				l4g.Info("Cluster subscription restored, dropping cached sessions")
+				sessionCache.Purge()
+				lost = false
+			}
+
+			delay = 0
+
+			for {
+				if payload, err := pubsub.ReceiveTimeout(REDIS_WAIT); err != nil {
+					if !strings.Contains(err.Error(), "i/o timeout") {
+						l4g.Error("Lost the cluster subscription err=%v", err)
+						lost = true
+						pubsub.Close()
+						break
+					}
+				} else if msg := store.GetClusterMessageFromPayload(payload); msg != nil {
+					handleClusterMessage(msg)
+				}
+			}
+		}
+	}()
+}
+
+func handleClusterMessage(msg *model.ClusterMessage) {
+	switch msg.Event {
+	case model.CLUSTER_EVENT_INVALIDATE_SESSIONS:
+		invalidateLocalSessions(msg.Props["team_id"], msg.Props["user_id"], msg.Props["alt_id"], msg.Props["reason"])
+	default:
+		l4g.Debug("Ignoring unknown cluster event %v", msg.Event)
+	}
+}
+
+// InvalidateSessions makes every app server forget a user's cached session
+// and close its connections. An empty altId means all the user's sessions.
+// Only ids that are safe to publish go out, never the session token.
+func InvalidateSessions(teamId string, userId string, altId string, reason string) {
+	invalidateLocalSessions(teamId, userId, altId, reason)
+
+	msg := model.NewClusterMessage(model.CLUSTER_EVENT_INVALIDATE_SESSIONS)
+	msg.Add("team_id", teamId)
+	msg.Add("user_id", userId)
+	msg.Add("alt_id", altId)
+	msg.Add("reason", reason)
+
+	if err := store.PublishCluster(msg); err != nil {
+		l4g.Error("Failed to invalidate sessions for user_id=%v on the other servers err=%v", userId, err)
+	}
+}
+
+func invalidateLocalSessions(teamId string, userId string, altId string, reason string) {
+	for _, key := range sessionCache.Keys() {
+		if ts, ok := sessionCache.Get(key); ok {
+			if session := ts.(*model.Session); matchesSession(session, userId, altId) {
+				sessionCache.Remove(key)
+			}
+		}
+	}
+
+	hub.CloseSessions(teamId, userId, altId, reason)
+}
+
+func matchesSession(session *model.Session, userId string, altId string) bool {
+	return session.UserId == userId && (len(altId) == 0 || session.AltId == altId)
+}
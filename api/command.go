@@ -13,6 +13,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type commandHandler func(c *Context, command *model.Command) bool
@@ -22,6 +23,7 @@ var commands = []commandHandler{
 	joinCommand,
 	loadTestCommand,
 	echoCommand,
+	remindCommand,
 }
 
 func InitCommand(r *mux.Router) {
@@ -29,6 +31,7 @@ func InitCommand(r *mux.Router) {
 	r.Handle("/command", ApiUserRequired(command)).Methods("POST")
 
 	hub.Start()
+	startReminderScheduler()
 }
 
 func command(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -42,6 +45,12 @@ func command(c *Context, w http.ResponseWriter, r *http.Request) {
 		Suggestions: make([]*model.SuggestCommand, 0, 128),
 	}
 
+	// Minutes east of UTC, so commands that deal in times can use the user's
+	// time zone
+	if offset, err := strconv.Atoi(props["utcOffset"]); err == nil && offset >= -14*60 && offset <= 14*60 {
+		command.Location = time.FixedZone("", offset*60)
+	}
+
 	checkCommand(c, command)
 
 	if c.Err != nil {
@@ -168,6 +177,173 @@ func echoCommand(c *Context, command *model.Command) bool {
 	return false
 }
 
+func remindCommand(c *Context, command *model.Command) bool {
+
+	// looks for "/remind me in 2 hours to ...", "/remind list" or "/remind delete id"
+	cmd := "/remind"
+	usage := "Set a reminder, e.g. /remind me in 2 hours to review the PR or /remind @alice tomorrow at 9am to ..."
+
+	if strings.Index(command.Command, cmd+" ") != 0 && command.Command != cmd {
+		if strings.Index(cmd, command.Command) == 0 {
+			command.AddSuggestion(&model.SuggestCommand{Suggestion: cmd, Description: usage})
+		}
+		return false
+	}
+
+	text := strings.TrimSpace(strings.TrimPrefix(command.Command, cmd))
+	parts := strings.Fields(text)
+
+	// Times are in the user's time zone when their client sent it
+	location := command.Location
+	if location == nil {
+		location = time.Local
+	}
+
+	// Still typing the first word
+	if len(parts) == 0 || (command.Suggest && len(parts) == 1 && parts[0] != "list" && parts[0] != "delete") {
+		partial := ""
+		if len(parts) == 1 {
+			partial = parts[0]
+		}
+
+		subcommands := []*model.SuggestCommand{
+			{Suggestion: cmd + " me", Description: usage},
+			{Suggestion: cmd + " list", Description: "List your reminders"},
+			{Suggestion: cmd + " delete", Description: "Delete one of your reminders"},
+		}
+
+		for _, sub := range subcommands {
+			if strings.Index(sub.Suggestion, cmd+" "+partial) == 0 {
+				command.AddSuggestion(sub)
+			}
+		}
+		return false
+	}
+
+	if parts[0] == "list" || parts[0] == "delete" {
+		var reminders []*model.Reminder
+		if result := <-Srv.Store.Reminder().GetForUser(c.Session.TeamId, c.Session.UserId); result.Err != nil {
+			c.Err = result.Err
+			return false
+		} else {
+			reminders = result.Data.([]*model.Reminder)
+		}
+
+		if command.Suggest {
+			for _, reminder := range reminders {
+				if parts[0] == "delete" && (len(parts) < 2 || strings.Index(reminder.Id, parts[1]) == 0) {
+					command.AddSuggestion(&model.SuggestCommand{Suggestion: cmd + " delete " + reminder.Id, Description: reminder.Message})
+				}
+			}
+			return false
+		}
+
+		if parts[0] == "delete" {
+			if len(parts) != 2 {
+				c.Err = model.NewAppError("remindCommand", "Use /remind delete followed by the id of the reminder", "")
+				c.Err.StatusCode = http.StatusBadRequest
+				return false
+			}
+
+			if result := <-Srv.Store.Reminder().Delete(parts[1], c.Session.UserId); result.Err != nil {
+				c.Err = result.Err
+				c.Err.StatusCode = http.StatusBadRequest
+				return false
+			}
+
+			command.Response = model.RESP_EXECUTED
+			return true
+		}
+
+		message := "You don't have any reminders."
+		if len(reminders) > 0 {
+			lines := make([]string, 0, len(reminders)+1)
+			lines = append(lines, "Your reminders:")
+			for _, reminder := range reminders {
+				lines = append(lines, reminder.Id+" "+formatReminderTime(reminder.RemindAt, location)+": "+reminder.Message)
+			}
+			message = strings.Join(lines, "\n")
+		}
+
+		if err := replyToCommand(c, command, message); err != nil {
+			c.Err = err
+			return false
+		}
+
+		command.Response = model.RESP_EXECUTED
+		return true
+	}
+
+	if command.Suggest {
+		command.AddSuggestion(&model.SuggestCommand{Suggestion: cmd + " " + text, Description: usage})
+		return false
+	}
+
+	now := time.Now().In(location)
+	who, message, when, ok := model.ParseReminder(text, now)
+	if !ok || !when.After(now) {
+		c.Err = model.NewAppError("remindCommand", "Couldn't work out the reminder. "+usage, "")
+		c.Err.StatusCode = http.StatusBadRequest
+		return false
+	}
+
+	userId := c.Session.UserId
+	if who != "me" {
+		if result := <-Srv.Store.User().GetByUsername(c.Session.TeamId, strings.TrimPrefix(who, "@")); result.Err != nil {
+			c.Err = model.NewAppError("remindCommand", "Couldn't find "+who+" on this team", "")
+			c.Err.StatusCode = http.StatusBadRequest
+			return false
+		} else {
+			userId = result.Data.(*model.User).Id
+		}
+	}
+
+	reminder := &model.Reminder{
+		CreatorId: c.Session.UserId,
+		UserId:    userId,
+		TeamId:    c.Session.TeamId,
+		Message:   message,
+		RemindAt:  when.UnixNano() / int64(time.Millisecond),
+		TeamUrl:   c.TeamUrl,
+	}
+
+	if result := <-Srv.Store.Reminder().Save(reminder); result.Err != nil {
+		c.Err = result.Err
+		return false
+	}
+
+	if who == "me" {
+		who = "you"
+	}
+
+	confirmation := "I will remind " + who + " " + formatReminderTime(reminder.RemindAt, location) + ": " + message
+	if command.Location == nil {
+		confirmation += " (times are in the server's time zone since your client didn't send one)"
+	}
+
+	if err := replyToCommand(c, command, confirmation); err != nil {
+		l4g.Error("Failed to confirm the reminder id=%v err=%v", reminder.Id, err)
+	}
+
+	command.Response = model.RESP_EXECUTED
+	return true
+}
+
+// replyToCommand answers only the user who ran the command, right in the
+// channel they ran it from when there is one.
+func replyToCommand(c *Context, command *model.Command, message string) *model.AppError {
+	if len(command.ChannelId) == 0 {
+		return sendValetMessage(c, c.Session.UserId, message)
+	}
+
+	SendEphemeralPost(c.Session.TeamId, c.Session.UserId, &model.Post{ChannelId: command.ChannelId, Message: message})
+	return nil
+}
+
+func formatReminderTime(millis int64, location *time.Location) string {
+	return time.Unix(0, millis*int64(time.Millisecond)).In(location).Format("Mon Jan 2 at 3:04 PM MST")
+}
+
 func joinCommand(c *Context, command *model.Command) bool {
 
 	// looks for "/join channel-name"
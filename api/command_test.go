@@ -6,6 +6,7 @@ package api
 import (
 	"github.com/mattermost/platform/model"
 	"testing"
+	"time"
 )
 
 func TestSuggestRootCommands(t *testing.T) {
@@ -144,3 +145,111 @@ func TestJoinCommands(t *testing.T) {
 		t.Fatal("didn't join channel")
 	}
 }
+
+func TestRemindCommands(t *testing.T) {
+	Setup()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	rs1 := Client.Must(Client.Command("", "/rem", true)).Data.(*model.Command)
+	if len(rs1.Suggestions) != 1 || rs1.Suggestions[0].Suggestion != "/remind" {
+		t.Fatal("should have remind cmd")
+	}
+
+	rs2 := Client.Must(Client.Command("", "/remind me in 2 hours to review the PR", false)).Data.(*model.Command)
+	if rs2.Response != model.RESP_EXECUTED {
+		t.Fatal("should have set the reminder")
+	}
+
+	Client.Must(Client.Command("", "/remind @"+user2.Username+" tomorrow at 9am to send the report", false))
+
+	if _, err := Client.Command("", "/remind me sometime to relax", false); err == nil {
+		t.Fatal("should not understand the time")
+	}
+
+	if _, err := Client.Command("", "/remind @nobody"+model.NewId()+" in 2 hours to relax", false); err == nil {
+		t.Fatal("should not find the user")
+	}
+
+	reminders := (<-Srv.Store.Reminder().GetForUser(team.Id, user1.Id)).Data.([]*model.Reminder)
+	if len(reminders) != 2 || reminders[0].Message != "review the PR" || reminders[0].UserId != user1.Id || reminders[1].UserId != user2.Id {
+		t.Fatal("should have saved both reminders")
+	}
+
+	rs3 := Client.Must(Client.Command("", "/remind delete", true)).Data.(*model.Command)
+	if len(rs3.Suggestions) != 2 {
+		t.Fatal("should suggest both reminders to delete")
+	}
+
+	Client.Must(Client.Command("", "/remind list", false))
+	Client.Must(Client.Command("", "/remind delete "+reminders[1].Id, false))
+
+	if reminders = (<-Srv.Store.Reminder().GetForUser(team.Id, user1.Id)).Data.([]*model.Reminder); len(reminders) != 1 {
+		t.Fatal("should have deleted the reminder")
+	}
+
+	// Times are read in the time zone the client sent
+	if _, err := Client.DoPost("/command", model.MapToJson(map[string]string{"command": "/remind me tomorrow at 9am to stretch", "channelId": "", "suggest": "false", "utcOffset": "-300"})); err != nil {
+		t.Fatal(err)
+	}
+
+	zone := time.FixedZone("", -300*60)
+	for _, reminder := range (<-Srv.Store.Reminder().GetForUser(team.Id, user1.Id)).Data.([]*model.Reminder) {
+		if reminder.Message == "stretch" {
+			if when := time.Unix(0, reminder.RemindAt*int64(time.Millisecond)).In(zone); when.Hour() != 9 || when.Minute() != 0 {
+				t.Fatal("should have used the client's time zone")
+			}
+			<-Srv.Store.Reminder().Delete(reminder.Id, user1.Id)
+		}
+	}
+
+	// Make the remaining reminder due and check it lands in a direct message
+	reminders[0].Id = ""
+	reminders[0].RemindAt = model.GetMillis() - 1000
+	<-Srv.Store.Reminder().Save(reminders[0])
+
+	sendDueReminders()
+
+	valet := (<-Srv.Store.User().GetByUsername(team.Id, model.BOT_USERNAME)).Data.(*model.User)
+
+	name := user1.Id + "__" + valet.Id
+	if valet.Id < user1.Id {
+		name = valet.Id + "__" + user1.Id
+	}
+
+	channel := (<-Srv.Store.Channel().GetByName(team.Id, name)).Data.(*model.Channel)
+	list := Client.Must(Client.GetPosts(channel.Id, 0, 10, "")).Data.(*model.PostList)
+
+	found := false
+	for _, post := range list.Posts {
+		if post.Message == "Reminder: review the PR" && post.UserId == valet.Id {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("should have sent the reminder")
+	}
+
+	// A reminder for someone who doesn't exist is dropped instead of retried
+	<-Srv.Store.Reminder().Save(&model.Reminder{CreatorId: user1.Id, UserId: model.NewId(), TeamId: team.Id, Message: "nobody", RemindAt: model.GetMillis() - 1000})
+
+	sendDueReminders()
+
+	for _, reminder := range (<-Srv.Store.Reminder().GetForUser(team.Id, user1.Id)).Data.([]*model.Reminder) {
+		if reminder.Message == "nobody" {
+			t.Fatal("should have given up on the reminder")
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	l4g "code.google.com/p/log4go"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"net/http"
+)
+
+func InitDraft(r *mux.Router) {
+	l4g.Debug("Initializing draft api routes")
+
+	sr := r.PathPrefix("/drafts").Subrouter()
+	sr.Handle("/", ApiUserRequired(getDrafts)).Methods("GET")
+	sr.Handle("/upsert", ApiUserRequired(upsertDraft)).Methods("POST")
+	sr.Handle("/delete", ApiUserRequired(deleteDraft)).Methods("POST")
+}
+
+func getDrafts(c *Context, w http.ResponseWriter, r *http.Request) {
+	if result := <-Srv.Store.Draft().GetForUser(c.Session.TeamId, c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Header().Set("Expires", "-1")
+		w.Write([]byte(model.DraftsToJson(result.Data.([]*model.Draft))))
+	}
+}
+
+func upsertDraft(c *Context, w http.ResponseWriter, r *http.Request) {
+	draft := model.DraftFromJson(r.Body)
+	if draft == nil {
+		c.SetInvalidParam("upsertDraft", "draft")
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.Session.TeamId, draft.ChannelId, c.Session.UserId)
+	if !c.HasPermissionsToChannel(cchan, "upsertDraft") {
+		return
+	}
+
+	draft.UserId = c.Session.UserId
+	draft.CreateAt = 0
+
+	if result := <-Srv.Store.Draft().Upsert(draft); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	} else {
+		saved := result.Data.(*model.Draft)
+
+		message := model.NewMessage(c.Session.TeamId, "", c.Session.UserId, model.ACTION_DRAFT_UPDATED)
+		message.Add("draft", saved.ToJson())
+		publishDraftMessage(c, message)
+
+		w.Write([]byte(saved.ToJson()))
+	}
+}
+
+func deleteDraft(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+
+	channelId := props["channel_id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam("deleteDraft", "channel_id")
+		return
+	}
+
+	rootId := props["root_id"]
+	if !(len(rootId) == 26 || len(rootId) == 0) {
+		c.SetInvalidParam("deleteDraft", "root_id")
+		return
+	}
+
+	// Like a saved post, a user can always clear out their own draft even
+	// once they've left the channel
+	if result := <-Srv.Store.Draft().Delete(c.Session.UserId, channelId, rootId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		if result.Data.(int64) > 0 {
+			publishDraftDeleted(c, channelId, rootId)
+		}
+
+		w.Write([]byte(model.MapToJson(props)))
+	}
+}
+
+// deleteDraftForPost clears the draft the post was written in, run once the
+// post has been created.
+func deleteDraftForPost(c *Context, post *model.Post) {
+	if result := <-Srv.Store.Draft().Delete(post.UserId, post.ChannelId, post.RootId); result.Err != nil {
+		l4g.Error("Failed to delete draft user_id=%v channel_id=%v err=%v", post.UserId, post.ChannelId, result.Err)
+	} else if result.Data.(int64) > 0 {
+		publishDraftDeleted(c, post.ChannelId, post.RootId)
+	}
+}
+
+func publishDraftDeleted(c *Context, channelId string, rootId string) {
+	message := model.NewMessage(c.Session.TeamId, "", c.Session.UserId, model.ACTION_DRAFT_DELETED)
+	message.Add("channel_id", channelId)
+	message.Add("root_id", rootId)
+	publishDraftMessage(c, message)
+}
+
+// publishDraftMessage tells the user's other sessions about the change, the
+// session that made it already knows.
+func publishDraftMessage(c *Context, message *model.Message) {
+	message.Add("session_alt_id", c.Session.AltId)
+	store.PublishAndForget(message)
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"github.com/mattermost/platform/model"
+	"testing"
+)
+
+func TestDrafts(t *testing.T) {
+	Setup()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	channel2 := &model.Channel{DisplayName: "TestDrafts", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_PRIVATE, TeamId: team.Id}
+	channel2 = Client.Must(Client.CreateChannel(channel2)).Data.(*model.Channel)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "TestDrafts", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	post1 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+
+	if _, err := Client.UpsertDraft(&model.Draft{ChannelId: channel2.Id, Message: "nope"}); err == nil {
+		t.Fatal("should not save a draft in a channel the user isn't in")
+	}
+
+	d1 := Client.Must(Client.UpsertDraft(&model.Draft{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Draft)
+	if d1.UserId != user1.Id {
+		t.Fatal("draft should belong to the user")
+	}
+
+	d1.Message = "a" + model.NewId() + "a"
+	if rd1 := Client.Must(Client.UpsertDraft(d1)).Data.(*model.Draft); rd1.Message != d1.Message || rd1.CreateAt != d1.CreateAt {
+		t.Fatal("should have updated the draft")
+	}
+
+	d2 := Client.Must(Client.UpsertDraft(&model.Draft{ChannelId: channel1.Id, RootId: post1.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Draft)
+
+	if drafts := Client.Must(Client.GetDrafts()).Data.([]*model.Draft); len(drafts) != 2 {
+		t.Fatal("should have had two drafts")
+	}
+
+	// Posting the reply clears the thread draft but not the channel one
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, RootId: post1.Id, Message: d2.Message}))
+
+	if drafts := Client.Must(Client.GetDrafts()).Data.([]*model.Draft); len(drafts) != 1 || drafts[0].RootId != "" {
+		t.Fatal("should have deleted the thread draft")
+	}
+
+	Client.Must(Client.DeleteDraft(channel1.Id, ""))
+
+	if drafts := Client.Must(Client.GetDrafts()).Data.([]*model.Draft); len(drafts) != 0 {
+		t.Fatal("should have deleted the draft")
+	}
+
+	if _, err := Client.DeleteDraft("junk", ""); err == nil {
+		t.Fatal("should have errored on a bad channel id")
+	}
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	if drafts := Client.Must(Client.GetDrafts()).Data.([]*model.Draft); len(drafts) != 0 {
+		t.Fatal("should not see another user's drafts")
+	}
+}
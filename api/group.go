@@ -0,0 +1,244 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	l4g "code.google.com/p/log4go"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/model"
+	"net/http"
+	"strings"
+)
+
+func InitGroup(r *mux.Router) {
+	l4g.Debug("Initializing group api routes")
+
+	sr := r.PathPrefix("/groups").Subrouter()
+	sr.Handle("/", ApiUserRequired(getGroups)).Methods("GET")
+	sr.Handle("/create", ApiUserRequired(createGroup)).Methods("POST")
+	sr.Handle("/update", ApiUserRequired(updateGroup)).Methods("POST")
+	sr.Handle("/{id:[A-Za-z0-9]+}/delete", ApiUserRequired(deleteGroup)).Methods("POST")
+	sr.Handle("/{id:[A-Za-z0-9]+}/members", ApiUserRequired(getGroupMembers)).Methods("GET")
+	sr.Handle("/{id:[A-Za-z0-9]+}/add", ApiUserRequired(addGroupMember)).Methods("POST")
+	sr.Handle("/{id:[A-Za-z0-9]+}/remove", ApiUserRequired(removeGroupMember)).Methods("POST")
+}
+
+func getGroups(c *Context, w http.ResponseWriter, r *http.Request) {
+	if result := <-Srv.Store.Group().GetForTeam(c.Session.TeamId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(model.GroupsToJson(result.Data.([]*model.Group))))
+	}
+}
+
+func createGroup(c *Context, w http.ResponseWriter, r *http.Request) {
+	group := model.GroupFromJson(r.Body)
+	if group == nil {
+		c.SetInvalidParam("createGroup", "group")
+		return
+	}
+
+	if !isTeamAdmin(c, "createGroup") {
+		return
+	}
+
+	group.Id = ""
+	group.TeamId = c.Session.TeamId
+
+	if !isGroupNameFree(c, group.Name, "createGroup") {
+		return
+	}
+
+	if result := <-Srv.Store.Group().Save(group); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	} else {
+		c.LogAudit("name=" + group.Name)
+		w.Write([]byte(result.Data.(*model.Group).ToJson()))
+	}
+}
+
+func updateGroup(c *Context, w http.ResponseWriter, r *http.Request) {
+	group := model.GroupFromJson(r.Body)
+	if group == nil {
+		c.SetInvalidParam("updateGroup", "group")
+		return
+	}
+
+	if !isTeamAdmin(c, "updateGroup") {
+		return
+	}
+
+	oldGroup := getTeamGroup(c, group.Id, "updateGroup")
+	if oldGroup == nil {
+		return
+	}
+
+	if group.Name != oldGroup.Name && !isGroupNameFree(c, group.Name, "updateGroup") {
+		return
+	}
+
+	oldGroup.Name = group.Name
+	oldGroup.DisplayName = group.DisplayName
+	oldGroup.Description = group.Description
+
+	if result := <-Srv.Store.Group().Update(oldGroup); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	} else {
+		c.LogAudit("name=" + oldGroup.Name)
+		w.Write([]byte(result.Data.(*model.Group).ToJson()))
+	}
+}
+
+func deleteGroup(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	if !isTeamAdmin(c, "deleteGroup") {
+		return
+	}
+
+	group := getTeamGroup(c, id, "deleteGroup")
+	if group == nil {
+		return
+	}
+
+	if result := <-Srv.Store.Group().Delete(group.Id); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		c.LogAudit("name=" + group.Name)
+		data := map[string]string{"id": group.Id}
+		w.Write([]byte(model.MapToJson(data)))
+	}
+}
+
+func getGroupMembers(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	group := getTeamGroup(c, id, "getGroupMembers")
+	if group == nil {
+		return
+	}
+
+	if result := <-Srv.Store.Group().GetMembers(group.Id); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(model.ArrayToJson(result.Data.([]string))))
+	}
+}
+
+func addGroupMember(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	data := model.MapFromJson(r.Body)
+	userId := data["user_id"]
+	if len(userId) != 26 {
+		c.SetInvalidParam("addGroupMember", "user_id")
+		return
+	}
+
+	if !isTeamAdmin(c, "addGroupMember") {
+		return
+	}
+
+	group := getTeamGroup(c, id, "addGroupMember")
+	if group == nil {
+		return
+	}
+
+	if result := <-Srv.Store.User().Get(userId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else if user := result.Data.(*model.User); user.TeamId != c.Session.TeamId {
+		c.SetInvalidParam("addGroupMember", "user_id")
+		return
+	}
+
+	if result := <-Srv.Store.Group().SaveMember(&model.GroupMember{GroupId: group.Id, UserId: userId}); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	} else {
+		c.LogAudit("name=" + group.Name + " user_id=" + userId)
+		w.Write([]byte(model.MapToJson(data)))
+	}
+}
+
+func removeGroupMember(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	data := model.MapFromJson(r.Body)
+	userId := data["user_id"]
+	if len(userId) != 26 {
+		c.SetInvalidParam("removeGroupMember", "user_id")
+		return
+	}
+
+	if !isTeamAdmin(c, "removeGroupMember") {
+		return
+	}
+
+	group := getTeamGroup(c, id, "removeGroupMember")
+	if group == nil {
+		return
+	}
+
+	if result := <-Srv.Store.Group().RemoveMember(group.Id, userId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		c.LogAudit("name=" + group.Name + " user_id=" + userId)
+		w.Write([]byte(model.MapToJson(data)))
+	}
+}
+
+func isTeamAdmin(c *Context, where string) bool {
+	if !strings.Contains(c.Session.Roles, model.ROLE_ADMIN) && !c.IsSystemAdmin() {
+		c.Err = model.NewAppError(where, "You do not have the appropriate permissions", "userId="+c.Session.UserId)
+		c.Err.StatusCode = http.StatusForbidden
+		return false
+	}
+
+	return true
+}
+
+// getTeamGroup loads the group, treating a group on another team as if it
+// didn't exist.
+func getTeamGroup(c *Context, id string, where string) *model.Group {
+	if len(id) != 26 {
+		c.SetInvalidParam(where, "id")
+		return nil
+	}
+
+	if result := <-Srv.Store.Group().Get(id); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusBadRequest
+		return nil
+	} else if group := result.Data.(*model.Group); group.TeamId != c.Session.TeamId {
+		c.SetInvalidParam(where, "id")
+		return nil
+	} else {
+		return group
+	}
+}
+
+// isGroupNameFree makes sure @name doesn't already mention a user on the team.
+func isGroupNameFree(c *Context, name string, where string) bool {
+	if result := <-Srv.Store.User().GetByUsername(c.Session.TeamId, name); result.Err == nil {
+		c.Err = model.NewAppError(where, "A user with that username already exists", "name="+name)
+		c.Err.StatusCode = http.StatusBadRequest
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"github.com/mattermost/platform/model"
+	"testing"
+	"time"
+)
+
+func TestGroups(t *testing.T) {
+	Setup()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: model.NewId() + "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	userTeamAdmin := &model.User{TeamId: team.Id, Email: team.Email, FullName: "Corey Hulen", Password: "pwd"}
+	userTeamAdmin = Client.Must(Client.CreateUser(userTeamAdmin, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(userTeamAdmin.Id)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	if _, err := Client.CreateGroup(&model.Group{Name: "backend"}); err == nil {
+		t.Fatal("only team admins can create groups")
+	}
+
+	Client.LoginByEmail(team.Domain, userTeamAdmin.Email, "pwd")
+
+	group := Client.Must(Client.CreateGroup(&model.Group{Name: "backend", DisplayName: "Backend"})).Data.(*model.Group)
+	if group.TeamId != team.Id {
+		t.Fatal("group should be on the admin's team")
+	}
+
+	if _, err := Client.CreateGroup(&model.Group{Name: "backend"}); err == nil {
+		t.Fatal("should not create a second group with the same name")
+	}
+
+	if _, err := Client.CreateGroup(&model.Group{Name: user1.Username}); err == nil {
+		t.Fatal("should not create a group with a user's name")
+	}
+
+	if _, err := Client.CreateGroup(&model.Group{Name: "all"}); err == nil {
+		t.Fatal("should not create a group with a reserved name")
+	}
+
+	group.DisplayName = "Back End"
+	if rgroup := Client.Must(Client.UpdateGroup(group)).Data.(*model.Group); rgroup.DisplayName != "Back End" {
+		t.Fatal("should have updated the group")
+	}
+
+	Client.Must(Client.AddGroupMember(group.Id, user2.Id))
+
+	if _, err := Client.AddGroupMember(group.Id, user2.Id); err == nil {
+		t.Fatal("should not add a member twice")
+	}
+
+	if _, err := Client.AddGroupMember(group.Id, model.NewId()); err == nil {
+		t.Fatal("should not add a user who doesn't exist")
+	}
+
+	if members := Client.Must(Client.GetGroupMembers(group.Id)).Data.([]string); len(members) != 1 || members[0] != user2.Id {
+		t.Fatal("should have had one member")
+	}
+
+	channel1 := &model.Channel{DisplayName: "TestGroups", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+	Client.Must(Client.AddChannelMember(channel1.Id, user1.Id))
+	Client.Must(Client.AddChannelMember(channel1.Id, user2.Id))
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	if groups := Client.Must(Client.GetGroups()).Data.([]*model.Group); len(groups) != 1 || groups[0].Id != group.Id {
+		t.Fatal("team members should be able to list groups")
+	}
+
+	if _, err := Client.AddGroupMember(group.Id, user1.Id); err == nil {
+		t.Fatal("only team admins can change membership")
+	}
+
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "ping @Backend, can you look?"}))
+	time.Sleep(500 * time.Millisecond)
+
+	mentionCount := func() int64 {
+		for _, unread := range Client.Must(Client.GetChannelUnreads()).Data.([]*model.ChannelUnread) {
+			if unread.ChannelId == channel1.Id {
+				return unread.MentionCount
+			}
+		}
+		return -1
+	}
+
+	if count := mentionCount(); count != 0 {
+		t.Fatal("user1 isn't in the group", count)
+	}
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	if count := mentionCount(); count != 1 {
+		t.Fatal("group members should have been mentioned", count)
+	}
+
+	Client.LoginByEmail(team.Domain, userTeamAdmin.Email, "pwd")
+
+	Client.Must(Client.RemoveGroupMember(group.Id, user2.Id))
+
+	if members := Client.Must(Client.GetGroupMembers(group.Id)).Data.([]string); len(members) != 0 {
+		t.Fatal("should have removed the member")
+	}
+
+	Client.Must(Client.DeleteGroup(group.Id))
+
+	if groups := Client.Must(Client.GetGroups()).Data.([]*model.Group); len(groups) != 0 {
+		t.Fatal("should have deleted the group")
+	}
+
+	if _, err := Client.DeleteGroup(group.Id); err == nil {
+		t.Fatal("group is already gone")
+	}
+}
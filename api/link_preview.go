@@ -0,0 +1,506 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"bytes"
+	l4g "code.google.com/p/log4go"
+	"encoding/json"
+	"errors"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+	"html"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	LINK_PREVIEW_CACHE_SIZE            = 10000
+	LINK_PREVIEW_CACHE_SECS            = 60 * 60
+	LINK_PREVIEW_MAX_REDIRECTS         = 3
+	DEFAULT_LINK_PREVIEW_TIMEOUT_SECS  = 5
+	DEFAULT_LINK_PREVIEW_MAX_BODY_SIZE = 1024 * 1024
+)
+
+// Holds a *model.LinkPreview per url, an empty one when the page had nothing
+// to show or couldn't be fetched so we don't keep hammering it
+var linkPreviewCache *utils.Cache = utils.NewLru(LINK_PREVIEW_CACHE_SIZE)
+
+var (
+	previewLinkRegex = regexp.MustCompile(`https?://[^\s<>"']+`)
+	metaTagRegex     = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	linkTagRegex     = regexp.MustCompile(`(?is)<link\s[^>]*>`)
+	titleTagRegex    = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	tagAttrRegex     = regexp.MustCompile(`(?is)([a-z][a-z0-9_:\-]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+var privateNetworks = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"255.255.255.255/32",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"fec0::/10",
+)
+
+// IPv6 ranges that carry an IPv4 address, NAT64 puts it in the last four
+// bytes and 6to4 in the four after the 2002: prefix
+var (
+	nat64Network     = parseCIDRs("64:ff9b::/96")[0]
+	sixToFourNetwork = parseCIDRs("2002::/16")[0]
+)
+
+// isBlockedIP decides which addresses the fetcher refuses to connect to, tests
+// swap it out so they can preview pages served from localhost
+var isBlockedIP = isPrivateIP
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic("Bad private network " + cidr)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsUnspecified() || ip.IsMulticast() {
+		return true
+	}
+
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	// The embedded address is the one that actually gets connected to
+	if ip.To4() == nil {
+		if nat64Network.Contains(ip) {
+			return isPrivateIP(net.IP(ip[12:16]))
+		} else if sixToFourNetwork.Contains(ip) {
+			return isPrivateIP(net.IP(ip[2:6]))
+		}
+	}
+
+	return false
+}
+
+// isLinkPreviewDomainAllowed checks host against the deny list and, when one is
+// set, the allow list. Entries match the domain and all of its subdomains.
+func isLinkPreviewDomainAllowed(host string) bool {
+	host = strings.ToLower(host)
+
+	matches := func(domains []string) bool {
+		for _, domain := range domains {
+			domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "."))
+			if len(domain) > 0 && (host == domain || strings.HasSuffix(host, "."+domain)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if matches(utils.Cfg.LinkPreviewSettings.DeniedDomains) {
+		return false
+	}
+
+	if len(utils.Cfg.LinkPreviewSettings.AllowedDomains) > 0 {
+		return matches(utils.Cfg.LinkPreviewSettings.AllowedDomains)
+	}
+
+	return true
+}
+
+func isLinkPreviewUrlAllowed(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+
+	host := u.Host
+	if h, _, err := net.SplitHostPort(u.Host); err == nil {
+		host = h
+	}
+
+	return len(host) > 0 && isLinkPreviewDomainAllowed(host)
+}
+
+// firstPreviewLink is the first http(s) link in a message, trailing
+// punctuation is treated as part of the sentence rather than the link.
+func firstPreviewLink(message string) string {
+	link := previewLinkRegex.FindString(message)
+	return strings.TrimRight(link, ".,;:!?)]}")
+}
+
+func linkPreviewTimeout() time.Duration {
+	if secs := utils.Cfg.LinkPreviewSettings.TimeoutSecs; secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+
+	return DEFAULT_LINK_PREVIEW_TIMEOUT_SECS * time.Second
+}
+
+func linkPreviewMaxBodySize() int64 {
+	if size := utils.Cfg.LinkPreviewSettings.MaxBodySize; size > 0 {
+		return size
+	}
+
+	return DEFAULT_LINK_PREVIEW_MAX_BODY_SIZE
+}
+
+// newLinkPreviewClient builds a client that resolves every host itself and
+// refuses to dial blocked addresses, so neither the link nor a redirect can
+// point it at the internal network. It never goes through a proxy.
+func newLinkPreviewClient() *http.Client {
+	timeout := linkPreviewTimeout()
+
+	dial := func(network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		ips, err := net.LookupIP(host)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(ips) == 0 {
+			return nil, errors.New("no addresses found for " + host)
+		}
+
+		for _, ip := range ips {
+			if isBlockedIP(ip) {
+				return nil, errors.New("refusing to connect to blocked address " + ip.String())
+			}
+		}
+
+		return net.DialTimeout(network, net.JoinHostPort(ips[0].String(), port), timeout)
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Dial:                  dial,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= LINK_PREVIEW_MAX_REDIRECTS {
+				return errors.New("too many redirects")
+			}
+
+			if !isLinkPreviewUrlAllowed(req.URL) {
+				return errors.New("redirected to a domain that isn't allowed")
+			}
+
+			return nil
+		},
+	}
+}
+
+// fetchLinkPage gets link and reads at most the configured number of bytes of
+// it, returning the body and the url it ended up at after redirects.
+func fetchLinkPage(client *http.Client, link string, accept string) ([]byte, *url.URL, string, error) {
+	req, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", "Mattermost-LinkPreview/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, "", errors.New("unexpected status " + resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, linkPreviewMaxBodySize()))
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	return body, resp.Request.URL, resp.Header.Get("Content-Type"), nil
+}
+
+// getLinkPreview returns the preview for link, from the cache when possible.
+// It returns nil when the page has nothing to preview.
+func getLinkPreview(link string) *model.LinkPreview {
+	if cached, ok := linkPreviewCache.Get(link); ok {
+		if preview := cached.(*model.LinkPreview); !preview.IsEmpty() {
+			return preview
+		}
+		return nil
+	}
+
+	preview, err := fetchLinkPreview(link)
+	if err != nil {
+		l4g.Debug("Failed to fetch link preview url=%v err=%v", link, err)
+		preview = &model.LinkPreview{Url: link}
+	}
+
+	linkPreviewCache.AddWithExpiresInSecs(link, preview, LINK_PREVIEW_CACHE_SECS)
+
+	if preview.IsEmpty() {
+		return nil
+	}
+
+	return preview
+}
+
+func fetchLinkPreview(link string) (*model.LinkPreview, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isLinkPreviewUrlAllowed(u) {
+		return nil, errors.New("domain isn't allowed")
+	}
+
+	client := newLinkPreviewClient()
+
+	body, finalUrl, contentType, err := fetchLinkPage(client, link, "text/html")
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.Contains(strings.ToLower(contentType), "text/html") {
+		return &model.LinkPreview{Url: link}, nil
+	}
+
+	preview, oEmbedUrl := parseOpenGraph(body)
+	preview.Url = link
+
+	if len(preview.Title) == 0 && len(oEmbedUrl) > 0 {
+		if ou, err := finalUrl.Parse(oEmbedUrl); err == nil && isLinkPreviewUrlAllowed(ou) {
+			if oEmbed, err := fetchOEmbed(client, ou.String()); err != nil {
+				l4g.Debug("Failed to fetch oEmbed url=%v err=%v", ou.String(), err)
+			} else {
+				mergeOEmbed(preview, oEmbed)
+			}
+		}
+	}
+
+	// Relative images are relative to wherever the redirects ended up
+	if len(preview.ImageUrl) > 0 {
+		if iu, err := finalUrl.Parse(preview.ImageUrl); err == nil && (iu.Scheme == "http" || iu.Scheme == "https") {
+			preview.ImageUrl = iu.String()
+		} else {
+			preview.ImageUrl = ""
+		}
+	}
+
+	preview.Truncate()
+
+	return preview, nil
+}
+
+func parseTagAttrs(tag string) map[string]string {
+	attrs := make(map[string]string)
+	for _, match := range tagAttrRegex.FindAllStringSubmatch(tag, -1) {
+		attrs[strings.ToLower(match[1])] = html.UnescapeString(match[2] + match[3] + match[4])
+	}
+
+	return attrs
+}
+
+// parseOpenGraph pulls the OpenGraph tags out of a page's head, falling back
+// on the twitter card tags and the plain title and description. It also
+// returns the page's oEmbed discovery link, if it has one.
+func parseOpenGraph(page []byte) (*model.LinkPreview, string) {
+	if end := bytes.Index(bytes.ToLower(page), []byte("</head>")); end >= 0 {
+		page = page[:end]
+	}
+	head := string(page)
+
+	meta := make(map[string]string)
+	for _, tag := range metaTagRegex.FindAllString(head, -1) {
+		attrs := parseTagAttrs(tag)
+
+		key := attrs["property"]
+		if len(key) == 0 {
+			key = attrs["name"]
+		}
+		key = strings.ToLower(key)
+
+		if _, seen := meta[key]; len(key) > 0 && !seen {
+			meta[key] = attrs["content"]
+		}
+	}
+
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if value := strings.TrimSpace(meta[key]); len(value) > 0 {
+				return value
+			}
+		}
+		return ""
+	}
+
+	preview := &model.LinkPreview{
+		Title:       first("og:title", "twitter:title"),
+		Description: first("og:description", "twitter:description", "description"),
+		ImageUrl:    first("og:image:secure_url", "og:image", "og:image:url", "twitter:image"),
+		SiteName:    first("og:site_name"),
+	}
+
+	if len(preview.Title) == 0 {
+		if match := titleTagRegex.FindStringSubmatch(head); match != nil {
+			preview.Title = strings.TrimSpace(html.UnescapeString(match[1]))
+		}
+	}
+
+	oEmbedUrl := ""
+	for _, tag := range linkTagRegex.FindAllString(head, -1) {
+		attrs := parseTagAttrs(tag)
+		if strings.ToLower(attrs["rel"]) == "alternate" && strings.ToLower(attrs["type"]) == "application/json+oembed" {
+			oEmbedUrl = attrs["href"]
+			break
+		}
+	}
+
+	return preview, oEmbedUrl
+}
+
+type oEmbedResponse struct {
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	AuthorName   string `json:"author_name"`
+	ProviderName string `json:"provider_name"`
+	Url          string `json:"url"`
+	ThumbnailUrl string `json:"thumbnail_url"`
+}
+
+func fetchOEmbed(client *http.Client, link string) (*oEmbedResponse, error) {
+	body, _, _, err := fetchLinkPage(client, link, "application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	var o oEmbedResponse
+	if err := json.Unmarshal(body, &o); err != nil {
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+func mergeOEmbed(preview *model.LinkPreview, o *oEmbedResponse) {
+	preview.Title = o.Title
+
+	if len(preview.SiteName) == 0 {
+		preview.SiteName = o.ProviderName
+	}
+
+	if len(preview.Description) == 0 && len(o.AuthorName) > 0 {
+		preview.Description = o.AuthorName
+	}
+
+	if len(preview.ImageUrl) == 0 {
+		if o.Type == "photo" && len(o.Url) > 0 {
+			preview.ImageUrl = o.Url
+		} else {
+			preview.ImageUrl = o.ThumbnailUrl
+		}
+	}
+}
+
+// fireAndForgetLinkPreview attaches a preview of the first link in the post,
+// or drops a stale one after an edit, then tells the channel about it.
+func fireAndForgetLinkPreview(teamId string, post *model.Post) {
+	go func() {
+		link := firstPreviewLink(post.Message)
+
+		var current string
+		if preview := post.LinkPreview(); preview != nil {
+			current = preview.Url
+		}
+
+		if link == current {
+			return
+		}
+
+		var preview *model.LinkPreview
+		if len(link) > 0 && utils.Cfg.LinkPreviewSettings.Enable && wantsLinkPreviews(post.UserId) {
+			preview = getLinkPreview(link)
+		}
+
+		if preview == nil && len(current) == 0 {
+			return
+		}
+
+		// The post may have been edited while we were fetching, only write
+		// the preview if it's still about the same link
+		var latest *model.Post
+		if result := <-Srv.Store.Post().Get(post.Id); result.Err != nil {
+			l4g.Error("Failed to get post for link preview post_id=%v err=%v", post.Id, result.Err)
+			return
+		} else if latest = result.Data.(*model.PostList).Posts[post.Id]; latest == nil || latest.DeleteAt != 0 || firstPreviewLink(latest.Message) != link {
+			return
+		}
+
+		props := make(model.StringMap)
+		for k, v := range latest.Props {
+			props[k] = v
+		}
+
+		if preview == nil {
+			delete(props, model.POST_PROP_LINK_PREVIEW)
+		} else {
+			props[model.POST_PROP_LINK_PREVIEW] = preview.ToJson()
+		}
+
+		if len(model.MapToJson(props)) > 4000 {
+			l4g.Debug("Link preview doesn't fit in the post's props post_id=%v", post.Id)
+			return
+		}
+
+		if result := <-Srv.Store.Post().UpdateProps(post.Id, props); result.Err != nil {
+			l4g.Error("Failed to save link preview post_id=%v err=%v", post.Id, result.Err)
+			return
+		}
+
+		message := model.NewMessage(teamId, post.ChannelId, post.UserId, model.ACTION_POST_LINK_PREVIEW)
+		message.Add("post_id", post.Id)
+		message.Add("channel_id", post.ChannelId)
+		message.Add("link_preview", props[model.POST_PROP_LINK_PREVIEW])
+
+		store.PublishAndForget(message)
+	}()
+}
+
+func wantsLinkPreviews(userId string) bool {
+	if result := <-Srv.Store.User().Get(userId); result.Err != nil {
+		l4g.Error("Failed to get user for link preview user_id=%v err=%v", userId, result.Err)
+		return false
+	} else {
+		return result.Data.(*model.User).Props[model.USER_PROP_LINK_PREVIEWS] != "false"
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"fmt"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPreviewPage = `<html><head>
+<title>Fallback title</title>
+<meta property="og:title" content="Mattermost &amp; friends">
+<meta content='Open source messaging' property='og:description'>
+<meta property="og:image" content="/logo.png">
+<meta property="og:site_name" content="Mattermost">
+</head><body><meta property="og:title" content="Not in the head"></body></html>`
+
+// allowLocalLinkPreviews lets the fetcher reach httptest servers, the
+// returned func puts the private network check back.
+func allowLocalLinkPreviews() func() {
+	isBlockedIP = func(ip net.IP) bool { return false }
+	return func() { isBlockedIP = isPrivateIP }
+}
+
+func newLinkPreviewServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, testPreviewPage)
+	})
+	mux.HandleFunc("/oembed-page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><link rel="alternate" type="application/json+oembed" href="/oembed.json"></head></html>`)
+	})
+	mux.HandleFunc("/oembed.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"type": "video", "title": "A video", "provider_name": "Tube", "thumbnail_url": "http://example.com/thumb.png"}`)
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><head>"+strings.Repeat(" ", 2048)+`<meta property="og:title" content="Too far in"></head></html>`)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Second)
+		fmt.Fprint(w, testPreviewPage)
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("not really a png"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestFirstPreviewLink(t *testing.T) {
+	if link := firstPreviewLink("have a look at https://example.com/a?b=c, it's good"); link != "https://example.com/a?b=c" {
+		t.Fatal("wrong link " + link)
+	}
+
+	if link := firstPreviewLink("(see http://example.com/page)."); link != "http://example.com/page" {
+		t.Fatal("wrong link " + link)
+	}
+
+	if link := firstPreviewLink("ftp://example.com and example.com"); link != "" {
+		t.Fatal("should not have found a link " + link)
+	}
+}
+
+func TestParseOpenGraph(t *testing.T) {
+	preview, oEmbedUrl := parseOpenGraph([]byte(testPreviewPage))
+
+	if preview.Title != "Mattermost & friends" || preview.Description != "Open source messaging" || preview.ImageUrl != "/logo.png" || preview.SiteName != "Mattermost" {
+		t.Fatal("didn't parse the open graph tags " + preview.ToJson())
+	}
+
+	if oEmbedUrl != "" {
+		t.Fatal("page doesn't have oembed")
+	}
+
+	preview, _ = parseOpenGraph([]byte(`<head><title> Just a title </title><meta name="description" content="desc"></head>`))
+	if preview.Title != "Just a title" || preview.Description != "desc" {
+		t.Fatal("should have fallen back on the title and description " + preview.ToJson())
+	}
+}
+
+func TestIsPrivateIP(t *testing.T) {
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "0.0.0.0", "::1", "fd00::1",
+		"192.0.0.8", "198.18.0.1", "198.19.255.254", "240.0.0.1", "255.255.255.255", "fec0::1",
+		"64:ff9b::127.0.0.1", "64:ff9b::a9fe:a9fe", "2002:7f00:1::", "2002:a9fe:a9fe::1"} {
+		if !isPrivateIP(net.ParseIP(ip)) {
+			t.Fatal("should be private " + ip)
+		}
+	}
+
+	for _, ip := range []string{"8.8.8.8", "172.32.0.1", "2001:4860:4860::8888", "64:ff9b::8.8.8.8", "2002:808:808::1"} {
+		if isPrivateIP(net.ParseIP(ip)) {
+			t.Fatal("should be public " + ip)
+		}
+	}
+}
+
+func TestLinkPreviewDomains(t *testing.T) {
+	settings := utils.Cfg.LinkPreviewSettings
+	defer func() { utils.Cfg.LinkPreviewSettings = settings }()
+
+	utils.Cfg.LinkPreviewSettings.AllowedDomains = nil
+	utils.Cfg.LinkPreviewSettings.DeniedDomains = []string{"blocked.com"}
+
+	if isLinkPreviewDomainAllowed("blocked.com") || isLinkPreviewDomainAllowed("www.Blocked.com") {
+		t.Fatal("should be denied")
+	}
+
+	if !isLinkPreviewDomainAllowed("notblocked.com") {
+		t.Fatal("should be allowed")
+	}
+
+	utils.Cfg.LinkPreviewSettings.AllowedDomains = []string{"example.com"}
+
+	if !isLinkPreviewDomainAllowed("docs.example.com") {
+		t.Fatal("should be allowed")
+	}
+
+	if isLinkPreviewDomainAllowed("example.org") {
+		t.Fatal("should only allow the allowed domains")
+	}
+}
+
+func TestFetchLinkPreview(t *testing.T) {
+	settings := utils.Cfg.LinkPreviewSettings
+	defer func() { utils.Cfg.LinkPreviewSettings = settings }()
+	utils.Cfg.LinkPreviewSettings = utils.LinkPreviewSettings{Enable: true, TimeoutSecs: 1, MaxBodySize: 1024}
+
+	ts := newLinkPreviewServer()
+	defer ts.Close()
+
+	if _, err := fetchLinkPreview(ts.URL + "/page"); err == nil {
+		t.Fatal("should have refused to connect to localhost")
+	}
+
+	defer allowLocalLinkPreviews()()
+
+	if preview, err := fetchLinkPreview(ts.URL + "/page"); err != nil {
+		t.Fatal(err)
+	} else if preview.Title != "Mattermost & friends" || preview.ImageUrl != ts.URL+"/logo.png" || preview.Url != ts.URL+"/page" {
+		t.Fatal("bad preview " + preview.ToJson())
+	}
+
+	if preview, err := fetchLinkPreview(ts.URL + "/oembed-page"); err != nil {
+		t.Fatal(err)
+	} else if preview.Title != "A video" || preview.SiteName != "Tube" || preview.ImageUrl != "http://example.com/thumb.png" {
+		t.Fatal("should have used the oembed data " + preview.ToJson())
+	}
+
+	if preview, err := fetchLinkPreview(ts.URL + "/big"); err != nil {
+		t.Fatal(err)
+	} else if !preview.IsEmpty() {
+		t.Fatal("should have stopped reading at the size limit")
+	}
+
+	if preview, err := fetchLinkPreview(ts.URL + "/image"); err != nil {
+		t.Fatal(err)
+	} else if !preview.IsEmpty() {
+		t.Fatal("should not preview non html")
+	}
+
+	if _, err := fetchLinkPreview(ts.URL + "/slow"); err == nil {
+		t.Fatal("should have timed out")
+	}
+
+	utils.Cfg.LinkPreviewSettings.DeniedDomains = []string{"blocked.example.com"}
+	if _, err := fetchLinkPreview(ts.URL + "/redirect?to=http://blocked.example.com/page"); err == nil {
+		t.Fatal("should not follow a redirect to a denied domain")
+	}
+
+	if _, err := fetchLinkPreview("http://blocked.example.com/page"); err == nil {
+		t.Fatal("should not fetch a denied domain")
+	}
+}
+
+func TestLinkPreviewOnCreatePost(t *testing.T) {
+	Setup()
+
+	settings := utils.Cfg.LinkPreviewSettings
+	defer func() { utils.Cfg.LinkPreviewSettings = settings }()
+	utils.Cfg.LinkPreviewSettings = utils.LinkPreviewSettings{Enable: true}
+
+	ts := newLinkPreviewServer()
+	defer ts.Close()
+	defer allowLocalLinkPreviews()()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "TestLinkPreview", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	waitForPreview := func(postId string) *model.LinkPreview {
+		for i := 0; i < 20; i++ {
+			time.Sleep(100 * time.Millisecond)
+			post := Client.Must(Client.GetPost(channel1.Id, postId, "")).Data.(*model.PostList).Posts[postId]
+			if preview := post.LinkPreview(); preview != nil {
+				return preview
+			}
+		}
+		return nil
+	}
+
+	link := ts.URL + "/page?" + model.NewId()
+	post1 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "look at " + link})).Data.(*model.Post)
+
+	if preview := waitForPreview(post1.Id); preview == nil || preview.Url != link || preview.Title != "Mattermost & friends" {
+		t.Fatal("should have attached the preview")
+	}
+
+	post1.Message = "nevermind"
+	Client.Must(Client.UpdatePost(post1))
+
+	time.Sleep(500 * time.Millisecond)
+	if post := Client.Must(Client.GetPost(channel1.Id, post1.Id, "")).Data.(*model.PostList).Posts[post1.Id]; post.LinkPreview() != nil {
+		t.Fatal("should have dropped the preview when the link was edited out")
+	}
+
+	user1.Props = model.StringMap{model.USER_PROP_LINK_PREVIEWS: "false"}
+	Client.Must(Client.UpdateUser(user1))
+
+	post2 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "look at " + ts.URL + "/page?" + model.NewId()})).Data.(*model.Post)
+
+	if waitForPreview(post2.Id) != nil {
+		t.Fatal("user opted out of link previews")
+	}
+}
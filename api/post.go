@@ -20,6 +20,8 @@ func InitPost(r *mux.Router) {
 	l4g.Debug("Initializing post api routes")
 
 	r.Handle("/posts/search", ApiUserRequired(searchPosts)).Methods("GET")
+	r.Handle("/posts/saved/{offset:[0-9]+}/{limit:[0-9]+}", ApiUserRequired(getSavedPosts)).Methods("GET")
+	r.Handle("/posts/mentions/{offset:[0-9]+}/{limit:[0-9]+}", ApiUserRequired(getMentions)).Methods("GET")
 
 	sr := r.PathPrefix("/channels/{id:[A-Za-z0-9]+}").Subrouter()
 	sr.Handle("/create", ApiUserRequired(createPost)).Methods("POST")
@@ -28,6 +30,19 @@ func InitPost(r *mux.Router) {
 	sr.Handle("/posts/{offset:[0-9]+}/{limit:[0-9]+}", ApiUserRequiredActivity(getPosts, false)).Methods("GET")
 	sr.Handle("/post/{post_id:[A-Za-z0-9]+}", ApiUserRequired(getPost)).Methods("GET")
 	sr.Handle("/post/{post_id:[A-Za-z0-9]+}/delete", ApiUserRequired(deletePost)).Methods("POST")
+	sr.Handle("/post/{post_id:[A-Za-z0-9]+}/reactions", ApiUserRequired(listReactions)).Methods("GET")
+	sr.Handle("/post/{post_id:[A-Za-z0-9]+}/reactions/add", ApiUserRequired(addReaction)).Methods("POST")
+	sr.Handle("/post/{post_id:[A-Za-z0-9]+}/reactions/remove", ApiUserRequired(removeReaction)).Methods("POST")
+	sr.Handle("/post/{post_id:[A-Za-z0-9]+}/pin", ApiUserRequired(pinPost)).Methods("POST")
+	sr.Handle("/post/{post_id:[A-Za-z0-9]+}/unpin", ApiUserRequired(unpinPost)).Methods("POST")
+	sr.Handle("/post/{post_id:[A-Za-z0-9]+}/save", ApiUserRequired(savePost)).Methods("POST")
+	sr.Handle("/post/{post_id:[A-Za-z0-9]+}/unsave", ApiUserRequired(unsavePost)).Methods("POST")
+	sr.Handle("/post/{post_id:[A-Za-z0-9]+}/thread/{offset:[0-9]+}/{limit:[0-9]+}", ApiUserRequired(getThread)).Methods("GET")
+	sr.Handle("/post/{post_id:[A-Za-z0-9]+}/follow", ApiUserRequired(followThread)).Methods("POST")
+	sr.Handle("/post/{post_id:[A-Za-z0-9]+}/unfollow", ApiUserRequired(unfollowThread)).Methods("POST")
+	sr.Handle("/pinned", ApiUserRequired(getPinnedPosts)).Methods("GET")
+
+	HandleWebSocket(model.WEBSOCKET_CREATE_POST, createPostWebSocket)
 }
 
 func createPost(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -37,11 +52,44 @@ func createPost(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if rp := createUserPost(c, post); rp != nil {
+		w.Write([]byte(rp.ToJson()))
+	}
+}
+
+func createPostWebSocket(c *Context, data map[string]string) map[string]string {
+	post := model.PostFromJson(strings.NewReader(data["post"]))
+	if post == nil {
+		c.SetInvalidParam("createPost", "post")
+		return nil
+	}
+
+	if rp := createUserPost(c, post); rp != nil {
+		return map[string]string{"post": rp.ToJson()}
+	}
+
+	return nil
+}
+
+func createUserPost(c *Context, post *model.Post) *model.Post {
+	// Only the server gets to post system messages
+	if post.Type != model.POST_DEFAULT {
+		c.SetInvalidParam("createPost", "type")
+		return nil
+	}
+
 	// Create and save post object to channel
 	cchan := Srv.Store.Channel().CheckPermissionsTo(c.Session.TeamId, post.ChannelId, c.Session.UserId)
 
 	if !c.HasPermissionsToChannel(cchan, "createPost") {
-		return
+		if isArchivedChannelMember(post.ChannelId, c.Session.UserId) {
+			SendEphemeralPost(c.Session.TeamId, c.Session.UserId, &model.Post{ChannelId: post.ChannelId, Message: "This channel has been archived, you can't post in it anymore."})
+		}
+		return nil
+	}
+
+	if !checkChannelWideMention(c, post) {
+		return nil
 	}
 
 	if rp, err := CreatePost(c, post, true); err != nil {
@@ -51,10 +99,62 @@ func createPost(c *Context, w http.ResponseWriter, r *http.Request) {
 			c.Err.StatusCode = http.StatusBadRequest
 		}
 
-		return
+		return nil
 	} else {
-		w.Write([]byte(rp.ToJson()))
+		return rp
+	}
+}
+
+// checkChannelWideMention stops @channel and @all going out to a large channel
+// unless the poster is an admin or has confirmed they meant it.
+func checkChannelWideMention(c *Context, post *model.Post) bool {
+	confirmed := post.Props[model.POST_PROP_CONFIRM_CHANNEL_MENTION] == "true"
+	delete(post.Props, model.POST_PROP_CONFIRM_CHANNEL_MENTION)
+
+	size := utils.Cfg.TeamSettings.LargeChannelMentionSize
+	if size <= 0 || !hasChannelWideMention(post.Message) {
+		return true
 	}
+
+	mchan := Srv.Store.Channel().GetMember(post.ChannelId, c.Session.UserId)
+
+	var count int64
+	if result := <-Srv.Store.Channel().GetMemberCount(post.ChannelId); result.Err != nil {
+		c.Err = result.Err
+		return false
+	} else {
+		count = result.Data.(int64)
+	}
+
+	if count <= int64(size) {
+		return true
+	}
+
+	if result := <-mchan; result.Err != nil {
+		c.Err = result.Err
+		return false
+	} else if member := result.Data.(model.ChannelMember); strings.Contains(member.Roles, model.CHANNEL_ROLE_ADMIN) || strings.Contains(c.Session.Roles, model.ROLE_ADMIN) {
+		return true
+	}
+
+	if utils.Cfg.TeamSettings.LargeChannelMentionsAdminOnly {
+		c.Err = model.NewAppError("createPost", "Only channel admins can use @channel or @all in a channel this large, try @here instead", "channel_id="+post.ChannelId)
+		c.Err.StatusCode = http.StatusForbidden
+		return false
+	}
+
+	if !confirmed {
+		c.Err = model.NewAppError("createPost", fmt.Sprintf("Using @channel or @all will notify %v people, are you sure?", count-1), "channel_id="+post.ChannelId)
+		c.Err.StatusCode = http.StatusPreconditionFailed
+		return false
+	}
+
+	return true
+}
+
+func hasChannelWideMention(message string) bool {
+	results := model.NewMentionParser().Parse(message)
+	return results.Specials[model.MENTION_CHANNEL] || results.Specials[model.MENTION_ALL]
 }
 
 func createValetPost(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -66,6 +166,11 @@ func createValetPost(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if post.Type != model.POST_DEFAULT {
+		c.SetInvalidParam("createValetPost", "type")
+		return
+	}
+
 	cchan := Srv.Store.Channel().CheckOpenChannelPermissions(c.Session.TeamId, post.ChannelId)
 
 	// Any one with access to the team can post as valet to any open channel
@@ -103,15 +208,10 @@ func CreateValetPost(c *Context, post *model.Post) (*model.Post, *model.AppError
 
 	post.Filenames = []string{} // no files allowed in valet posts yet
 
-	if result := <-Srv.Store.User().GetByUsername(c.Session.TeamId, "valet"); result.Err != nil {
-		// if the bot doesn't exist, create it
-		if tresult := <-Srv.Store.Team().Get(c.Session.TeamId); tresult.Err != nil {
-			return nil, tresult.Err
-		} else {
-			post.UserId = (CreateValet(c, tresult.Data.(*model.Team))).Id
-		}
+	if valet, err := GetValet(c); err != nil {
+		return nil, err
 	} else {
-		post.UserId = result.Data.(*model.User).Id
+		post.UserId = valet.Id
 	}
 
 	var rpost *model.Post
@@ -126,12 +226,63 @@ func CreateValetPost(c *Context, post *model.Post) (*model.Post, *model.AppError
 	return rpost, nil
 }
 
+func isArchivedChannelMember(channelId string, userId string) bool {
+	cchan := Srv.Store.Channel().Get(channelId)
+	mchan := Srv.Store.Channel().GetMember(channelId, userId)
+
+	if cresult := <-cchan; cresult.Err != nil || cresult.Data.(*model.Channel).DeleteAt == 0 {
+		return false
+	} else if mresult := <-mchan; mresult.Err != nil {
+		return false
+	}
+
+	return true
+}
+
+// SendEphemeralPost shows post to a single user in its channel. It only goes
+// over the websocket and is never saved so it's gone on the next reload.
+func SendEphemeralPost(teamId string, userId string, post *model.Post) *model.Post {
+	post.Id = model.NewId()
+	post.CreateAt = model.GetMillis()
+	post.UpdateAt = post.CreateAt
+	post.Type = model.POST_EPHEMERAL
+	if len(post.UserId) == 0 {
+		post.UserId = userId
+	}
+	post.MakeNonNil()
+
+	message := model.NewMessage(teamId, post.ChannelId, userId, model.ACTION_EPHEMERAL_POST)
+	message.Add("post", post.ToJson())
+
+	store.PublishAndForget(message)
+
+	return post
+}
+
+// GetValet returns the team's valet bot, creating it if it doesn't exist yet.
+func GetValet(c *Context) (*model.User, *model.AppError) {
+	if result := <-Srv.Store.User().GetByUsername(c.Session.TeamId, model.BOT_USERNAME); result.Err != nil {
+		// if the bot doesn't exist, create it
+		if tresult := <-Srv.Store.Team().Get(c.Session.TeamId); tresult.Err != nil {
+			return nil, tresult.Err
+		} else if valet := CreateValet(c, tresult.Data.(*model.Team)); valet == nil {
+			return nil, c.Err
+		} else {
+			return valet, nil
+		}
+	} else {
+		return result.Data.(*model.User), nil
+	}
+}
+
 func CreatePost(c *Context, post *model.Post, doUpdateLastViewed bool) (*model.Post, *model.AppError) {
 	var pchan store.StoreChannel
 	if len(post.RootId) > 0 {
 		pchan = Srv.Store.Post().Get(post.RootId)
 	}
 
+	var rootPost *model.Post
+
 	// Verify the parent/child relationships are correct
 	if pchan != nil {
 		if presult := <-pchan; presult.Err != nil {
@@ -142,6 +293,8 @@ func CreatePost(c *Context, post *model.Post, doUpdateLastViewed bool) (*model.P
 				return nil, model.NewAppError("createPost", "Invalid ChannelId for RootId parameter", "")
 			}
 
+			rootPost = list.Posts[post.RootId]
+
 			if post.ParentId == "" {
 				post.ParentId = post.RootId
 			}
@@ -200,8 +353,20 @@ func CreatePost(c *Context, post *model.Post, doUpdateLastViewed bool) (*model.P
 	} else {
 		rpost = result.Data.(*model.Post)
 
+		// Whoever started the thread and whoever replies to it follow it, this
+		// has to land before the notifications go looking for followers
+		if rootPost != nil {
+			if result := <-Srv.Store.Thread().AutoFollow(rootPost.Id, []string{rootPost.UserId, rpost.UserId}); result.Err != nil {
+				l4g.Error("Failed to follow thread root_id=%v err=%v", rootPost.Id, result.Err)
+			}
+		}
+
 		fireAndForgetNotifications(rpost, c.Session.TeamId, c.TeamUrl)
 
+		if !rpost.IsSystemMessage() {
+			fireAndForgetLinkPreview(c.Session.TeamId, rpost)
+			deleteDraftForPost(c, rpost)
+		}
 	}
 
 	return rpost, nil
@@ -210,11 +375,24 @@ func CreatePost(c *Context, post *model.Post, doUpdateLastViewed bool) (*model.P
 func fireAndForgetNotifications(post *model.Post, teamId, teamUrl string) {
 
 	go func() {
+		// System messages quote whatever the user typed, like a new header, so
+		// an @channel in there mustn't alert or email anyone
+		if post.IsSystemMessage() {
+			publishPost(teamId, post, nil, nil)
+			return
+		}
+
 		// Get a list of user names (to be used as keywords) and ids for the given team
 		uchan := Srv.Store.User().GetProfiles(teamId)
 		echan := Srv.Store.Channel().GetMembers(post.ChannelId)
 		cchan := Srv.Store.Channel().Get(post.ChannelId)
 		tchan := Srv.Store.Team().Get(teamId)
+		gchan := Srv.Store.Group().GetMentionMap(teamId)
+
+		var fchan store.StoreChannel
+		if len(post.RootId) > 0 {
+			fchan = Srv.Store.Thread().GetFollowers(post.RootId)
+		}
 
 		var channel *model.Channel
 		var channelName string
@@ -236,6 +414,7 @@ func fireAndForgetNotifications(post *model.Post, teamId, teamUrl string) {
 		}
 
 		var mentionedUsers []string
+		var followerIds []string
 
 		if result := <-uchan; result.Err != nil {
 			l4g.Error("Failed to retrieve user profiles team_id=%v, err=%v", teamId, result.Err)
@@ -249,7 +428,14 @@ func fireAndForgetNotifications(post *model.Post, teamId, teamUrl string) {
 			}
 			senderName := profileMap[post.UserId].Username
 
+			presences, err := store.GetStatuses(teamId)
+			if err != nil {
+				l4g.Error("Failed to retrieve statuses team_id=%v, err=%v", teamId, err)
+				presences = make(map[string]*model.Status)
+			}
+
 			toEmailMap := make(map[string]bool)
+			followerEmailMap := make(map[string]bool)
 
 			if channel.Type == model.CHANNEL_DIRECT {
 
@@ -267,7 +453,7 @@ func fireAndForgetNotifications(post *model.Post, teamId, teamUrl string) {
 				if _, ok := otherUser.NotifyProps["email"]; ok && otherUser.NotifyProps["email"] == "false" {
 					sendEmail = false
 				}
-				if sendEmail && (otherUser.IsOffline() || otherUser.IsAway()) {
+				if sendEmail && !isUserOnline(presences, otherUserId) {
 					toEmailMap[otherUserId] = true
 				}
 
@@ -287,67 +473,51 @@ func fireAndForgetNotifications(post *model.Post, teamId, teamUrl string) {
 					profileMap = tempProfileMap
 				}
 
-				// Build map for keywords
-				keywordMap := make(map[string][]string)
-				for _, profile := range profileMap {
-					if len(profile.NotifyProps["mention_keys"]) > 0 {
-
-						// Add all the user's mention keys
-						splitKeys := strings.Split(profile.NotifyProps["mention_keys"], ",")
-						for _, k := range splitKeys {
-							keywordMap[k] = append(keywordMap[strings.ToLower(k)], profile.Id)
-						}
-
-						// If turned on, add the user's case sensitive first name
-						if profile.NotifyProps["first_name"] == "true" {
-							splitName := strings.Split(profile.FullName, " ")
-							if len(splitName) > 0 && splitName[0] != "" {
-								keywordMap[splitName[0]] = append(keywordMap[splitName[0]], profile.Id)
-							}
-						}
+				addMention := func(userId string) {
+					if post.UserId == userId {
+						return
 					}
-
-					// Add @all to keywords if user has them turned on
-					if profile.NotifyProps["all"] == "true" {
-						keywordMap["@all"] = append(keywordMap["@all"], profile.Id)
+					sendEmail := true
+					if _, ok := profileMap[userId].NotifyProps["email"]; ok && profileMap[userId].NotifyProps["email"] == "false" {
+						sendEmail = false
 					}
-
-					// Add @channel to keywords if user has them turned on
-					if profile.NotifyProps["channel"] == "true" {
-						keywordMap["@channel"] = append(keywordMap["@channel"], profile.Id)
+					if sendEmail && !isUserOnline(presences, userId) {
+						toEmailMap[userId] = true
+					} else {
+						toEmailMap[userId] = false
 					}
 				}
 
-				// Build a map as a list of unique user_ids that are mentioned in this post
-				splitF := func(c rune) bool {
-					return model.SplitRunes[c]
+				var mentionMap map[string][]string
+				if gResult := <-gchan; gResult.Err != nil {
+					l4g.Error("Failed to get group mentions team_id=%v err=%v", teamId, gResult.Err.Message)
+				} else {
+					mentionMap = gResult.Data.(map[string][]string)
 				}
-				splitMessage := strings.FieldsFunc(post.Message, splitF)
-				for _, word := range splitMessage {
 
-					// Non-case-sensitive check for regular keys
-					userIds1, keyMatch := keywordMap[strings.ToLower(word)]
-
-					// Case-sensitive check for first name
-					userIds2, firstNameMatch := keywordMap[word]
+				mentionIds := getMentionedUserIds(post, profileMap, mentionMap, presences)
+				for _, id := range mentionIds {
+					addMention(id)
+				}
 
-					userIds := append(userIds1, userIds2...)
+				if len(mentionIds) > 0 {
+					fireAndForgetSaveMentions(post, mentionIds)
+				}
 
-					// If one of the non-case-senstive keys or the first name matches the word
-					//  then we add en entry to the sendEmail map
-					if keyMatch || firstNameMatch {
-						for _, userId := range userIds {
-							if post.UserId == userId {
+				// People following the thread hear about replies without being
+				// mentioned, so they don't add to anyone's mention count
+				if fchan != nil {
+					if fResult := <-fchan; fResult.Err != nil {
+						l4g.Error("Failed to get thread followers root_id=%v err=%v", post.RootId, fResult.Err.Message)
+					} else {
+						for _, userId := range fResult.Data.([]string) {
+							if _, mentioned := toEmailMap[userId]; mentioned || userId == post.UserId {
 								continue
 							}
-							sendEmail := true
-							if _, ok := profileMap[userId].NotifyProps["email"]; ok && profileMap[userId].NotifyProps["email"] == "false" {
-								sendEmail = false
-							}
-							if sendEmail && (profileMap[userId].IsAway() || profileMap[userId].IsOffline()) {
-								toEmailMap[userId] = true
-							} else {
-								toEmailMap[userId] = false
+
+							if profile, ok := profileMap[userId]; ok {
+								followerIds = append(followerIds, userId)
+								followerEmailMap[userId] = profile.NotifyProps["email"] != "false" && !isUserOnline(presences, userId)
 							}
 						}
 					}
@@ -363,7 +533,9 @@ func fireAndForgetNotifications(post *model.Post, teamId, teamUrl string) {
 				for k := range toEmailMap {
 					mentionedUsers = append(mentionedUsers, k)
 				}
+			}
 
+			if len(toEmailMap) != 0 || len(followerEmailMap) != 0 {
 				var teamName string
 				if result := <-tchan; result.Err != nil {
 					l4g.Error("Failed to retrieve team team_id=%v, err=%v", teamId, result.Err)
@@ -376,72 +548,198 @@ func fireAndForgetNotifications(post *model.Post, teamId, teamUrl string) {
 				location, _ := time.LoadLocation("UTC")
 				tm := time.Unix(post.CreateAt/1000, 0).In(location)
 
-				subjectPage := NewServerTemplatePage("post_subject", teamUrl)
-				subjectPage.Props["TeamName"] = teamName
-				subjectPage.Props["SubjectText"] = subjectText
-				subjectPage.Props["Month"] = tm.Month().String()[:3]
-				subjectPage.Props["Day"] = fmt.Sprintf("%d", tm.Day())
-				subjectPage.Props["Year"] = fmt.Sprintf("%d", tm.Year())
+				sendEmails := func(emailMap map[string]bool, subjectText string, bodyText string) {
+					subjectPage := NewServerTemplatePage("post_subject", teamUrl)
+					subjectPage.Props["TeamName"] = teamName
+					subjectPage.Props["SubjectText"] = subjectText
+					subjectPage.Props["Month"] = tm.Month().String()[:3]
+					subjectPage.Props["Day"] = fmt.Sprintf("%d", tm.Day())
+					subjectPage.Props["Year"] = fmt.Sprintf("%d", tm.Year())
 
-				for id, doSend := range toEmailMap {
+					for id, doSend := range emailMap {
 
-					if !doSend {
-						continue
-					}
+						if !doSend {
+							continue
+						}
 
-					// skip if inactive
-					if profileMap[id].DeleteAt > 0 {
-						continue
-					}
+						// skip if inactive
+						if profileMap[id].DeleteAt > 0 {
+							continue
+						}
 
-					firstName := strings.Split(profileMap[id].FullName, " ")[0]
-
-					bodyPage := NewServerTemplatePage("post_body", teamUrl)
-					bodyPage.Props["FullName"] = firstName
-					bodyPage.Props["TeamName"] = teamName
-					bodyPage.Props["ChannelName"] = channelName
-					bodyPage.Props["BodyText"] = bodyText
-					bodyPage.Props["SenderName"] = senderName
-					bodyPage.Props["Hour"] = fmt.Sprintf("%02d", tm.Hour())
-					bodyPage.Props["Minute"] = fmt.Sprintf("%02d", tm.Minute())
-					bodyPage.Props["Month"] = tm.Month().String()[:3]
-					bodyPage.Props["Day"] = fmt.Sprintf("%d", tm.Day())
-					bodyPage.Props["PostMessage"] = model.ClearMentionTags(post.Message)
-					bodyPage.Props["TeamLink"] = teamUrl + "/channels/" + channel.Name
-
-					if err := utils.SendMail(profileMap[id].Email, subjectPage.Render(), bodyPage.Render()); err != nil {
-						l4g.Error("Failed to send mention email successfully email=%v err=%v", profileMap[id].Email, err)
-					}
+						firstName := strings.Split(profileMap[id].FullName, " ")[0]
+
+						bodyPage := NewServerTemplatePage("post_body", teamUrl)
+						bodyPage.Props["FullName"] = firstName
+						bodyPage.Props["TeamName"] = teamName
+						bodyPage.Props["ChannelName"] = channelName
+						bodyPage.Props["BodyText"] = bodyText
+						bodyPage.Props["SenderName"] = senderName
+						bodyPage.Props["Hour"] = fmt.Sprintf("%02d", tm.Hour())
+						bodyPage.Props["Minute"] = fmt.Sprintf("%02d", tm.Minute())
+						bodyPage.Props["Month"] = tm.Month().String()[:3]
+						bodyPage.Props["Day"] = fmt.Sprintf("%d", tm.Day())
+						bodyPage.Props["PostMessage"] = model.ClearMentionTags(post.PlainText())
+						bodyPage.Props["TeamLink"] = teamUrl + "/channels/" + channel.Name
+
+						if err := utils.SendMail(profileMap[id].Email, subjectPage.Render(), bodyPage.Render()); err != nil {
+							l4g.Error("Failed to send mention email successfully email=%v err=%v", profileMap[id].Email, err)
+						}
 
-					if len(utils.Cfg.EmailSettings.ApplePushServer) > 0 {
-						sessionChan := Srv.Store.Session().GetSessions(id)
-						if result := <-sessionChan; result.Err != nil {
-							l4g.Error("Failed to retrieve sessions in notifications id=%v, err=%v", id, result.Err)
-						} else {
-							sessions := result.Data.([]*model.Session)
-							alreadySeen := make(map[string]string)
+						if len(utils.Cfg.EmailSettings.ApplePushServer) > 0 {
+							sessionChan := Srv.Store.Session().GetSessions(id)
+							if result := <-sessionChan; result.Err != nil {
+								l4g.Error("Failed to retrieve sessions in notifications id=%v, err=%v", id, result.Err)
+							} else {
+								sessions := result.Data.([]*model.Session)
+								alreadySeen := make(map[string]string)
 
-							for _, session := range sessions {
-								if len(session.DeviceId) > 0 && alreadySeen[session.DeviceId] == "" {
+								for _, session := range sessions {
+									if len(session.DeviceId) > 0 && alreadySeen[session.DeviceId] == "" {
 
-									alreadySeen[session.DeviceId] = session.DeviceId
+										alreadySeen[session.DeviceId] = session.DeviceId
 
-									utils.FireAndForgetSendAppleNotify(session.DeviceId, subjectPage.Render(), 1)
+										utils.FireAndForgetSendAppleNotify(session.DeviceId, subjectPage.Render(), 1)
+									}
 								}
 							}
 						}
 					}
 				}
+
+				sendEmails(toEmailMap, subjectText, bodyText)
+				sendEmails(followerEmailMap, "New Reply", "You have one new reply in a thread you follow.")
 			}
 		}
 
-		message := model.NewMessage(teamId, post.ChannelId, post.UserId, model.ACTION_POSTED)
-		message.Add("post", post.ToJson())
-		if len(mentionedUsers) != 0 {
-			message.Add("mentions", model.ArrayToJson(mentionedUsers))
+		publishPost(teamId, post, mentionedUsers, followerIds)
+	}()
+}
+
+// publishPost tells the team about a new post along with who it mentioned
+// and which thread followers it should notify.
+func publishPost(teamId string, post *model.Post, mentionedUsers []string, followerIds []string) {
+	message := model.NewMessage(teamId, post.ChannelId, post.UserId, model.ACTION_POSTED)
+	message.Add("post", post.ToJson())
+	if len(mentionedUsers) != 0 {
+		message.Add("mentions", model.ArrayToJson(mentionedUsers))
+	}
+	if len(followerIds) != 0 {
+		message.Add("followers", model.ArrayToJson(followerIds))
+	}
+
+	store.PublishAndForget(message)
+}
+
+// getMentionedUserIds returns the channel members in profileMap that the post
+// mentions, mentionMap has the team's groups for @group mentions.
+func getMentionedUserIds(post *model.Post, profileMap map[string]*model.User, mentionMap map[string][]string, presences map[string]*model.Status) []string {
+	parser := model.NewMentionParser()
+	for _, profile := range profileMap {
+		parser.AddUser(profile)
+	}
+
+	// Mentioning a group mentions the members who are in the channel
+	for name, userIds := range mentionMap {
+		parser.AddGroup(name, channelUserIds(profileMap, userIds))
+	}
+
+	results := parser.Parse(post.Message)
+	var mentionIds []string
+	for _, profile := range profileMap {
+		if profile.Id != post.UserId && results.IsMentioned(profile, isUserOnline(presences, profile.Id)) {
+			mentionIds = append(mentionIds, profile.Id)
 		}
+	}
 
-		store.PublishAndForget(message)
+	return mentionIds
+}
+
+// channelUserIds returns the userIds that are in profileMap.
+func channelUserIds(profileMap map[string]*model.User, userIds []string) []string {
+	inChannel := make([]string, 0, len(userIds))
+	for _, userId := range userIds {
+		if _, ok := profileMap[userId]; ok {
+			inChannel = append(inChannel, userId)
+		}
+	}
+
+	return inChannel
+}
+
+// fireAndForgetSaveMentions records who the post mentioned so it shows up in
+// their recent mentions.
+func fireAndForgetSaveMentions(post *model.Post, userIds []string) {
+	go func() {
+		if result := <-Srv.Store.PostMention().Save(post, userIds); result.Err != nil {
+			l4g.Error("Failed to save mentions for post_id=%v err=%v", post.Id, result.Err)
+		}
+	}()
+}
+
+// fireAndForgetReindexMentions replaces the mentions recorded for an edited
+// post so recent mentions match what it says now. Nobody is notified again.
+func fireAndForgetReindexMentions(post *model.Post, teamId string) {
+	go func() {
+		if result := <-Srv.Store.Channel().Get(post.ChannelId); result.Err != nil {
+			l4g.Error("Failed to retrieve channel channel_id=%v, err=%v", post.ChannelId, result.Err)
+			return
+		} else if result.Data.(*model.Channel).Type == model.CHANNEL_DIRECT {
+			// Direct messages aren't indexed, see fireAndForgetNotifications
+			return
+		}
+
+		uchan := Srv.Store.User().GetProfiles(teamId)
+		echan := Srv.Store.Channel().GetMembers(post.ChannelId)
+		gchan := Srv.Store.Group().GetMentionMap(teamId)
+
+		var profileMap map[string]*model.User
+		if result := <-uchan; result.Err != nil {
+			l4g.Error("Failed to retrieve user profiles team_id=%v, err=%v", teamId, result.Err)
+			return
+		} else {
+			profileMap = result.Data.(map[string]*model.User)
+		}
+
+		if result := <-echan; result.Err != nil {
+			l4g.Error("Failed to get channel members channel_id=%v err=%v", post.ChannelId, result.Err.Message)
+			return
+		} else {
+			tempProfileMap := make(map[string]*model.User)
+			for _, member := range result.Data.([]model.ChannelMember) {
+				tempProfileMap[member.UserId] = profileMap[member.UserId]
+			}
+
+			profileMap = tempProfileMap
+		}
+
+		var mentionMap map[string][]string
+		if result := <-gchan; result.Err != nil {
+			l4g.Error("Failed to get group mentions team_id=%v err=%v", teamId, result.Err.Message)
+		} else {
+			mentionMap = result.Data.(map[string][]string)
+		}
+
+		presences, err := store.GetStatuses(teamId)
+		if err != nil {
+			l4g.Error("Failed to retrieve statuses team_id=%v, err=%v", teamId, err)
+			presences = make(map[string]*model.Status)
+		}
+
+		mentionIds := getMentionedUserIds(post, profileMap, mentionMap, presences)
+		if result := <-Srv.Store.PostMention().Save(post, mentionIds); result.Err != nil {
+			l4g.Error("Failed to save mentions for post_id=%v err=%v", post.Id, result.Err)
+		}
+	}()
+}
+
+// fireAndForgetDeleteMentions drops a deleted post and the replies deleted
+// with it from recent mentions.
+func fireAndForgetDeleteMentions(postId string) {
+	go func() {
+		if result := <-Srv.Store.PostMention().DeleteForPost(postId); result.Err != nil {
+			l4g.Error("Failed to remove mentions for post_id=%v err=%v", postId, result.Err)
+		}
 	}()
 }
 
@@ -492,6 +790,12 @@ func updatePost(c *Context, w http.ResponseWriter, r *http.Request) {
 			c.Err.StatusCode = http.StatusForbidden
 			return
 		}
+
+		if oldPost.IsSystemMessage() {
+			c.Err = model.NewAppError("updatePost", "System messages can't be edited", "id="+post.Id)
+			c.Err.StatusCode = http.StatusForbidden
+			return
+		}
 	}
 
 	hashtags, _ := model.ParseHashtags(post.Message)
@@ -509,6 +813,9 @@ func updatePost(c *Context, w http.ResponseWriter, r *http.Request) {
 
 		store.PublishAndForget(message)
 
+		fireAndForgetLinkPreview(c.Session.TeamId, rpost)
+		fireAndForgetReindexMentions(rpost, c.Session.TeamId)
+
 		w.Write([]byte(rpost.ToJson()))
 	}
 }
@@ -652,6 +959,8 @@ func deletePost(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		fireAndForgetDeleteMentions(postId)
+
 		message := model.NewMessage(c.Session.TeamId, post.ChannelId, c.Session.UserId, model.ACTION_POST_DELETED)
 		message.Add("post_id", post.Id)
 		message.Add("channel_id", post.ChannelId)
@@ -664,6 +973,324 @@ func deletePost(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// getChannelPost loads a post the same way getPost does, making sure the
+// user can see the channel and that the post really is in it.
+func getChannelPost(c *Context, r *http.Request, where string) *model.Post {
+	params := mux.Vars(r)
+
+	channelId := params["id"]
+	if len(channelId) != 26 {
+		c.SetInvalidParam(where, "channelId")
+		return nil
+	}
+
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam(where, "postId")
+		return nil
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.Session.TeamId, channelId, c.Session.UserId)
+	pchan := Srv.Store.Post().Get(postId)
+
+	if !c.HasPermissionsToChannel(cchan, where) {
+		return nil
+	}
+
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return nil
+	} else {
+		post := result.Data.(*model.PostList).Posts[postId]
+
+		if post == nil {
+			c.SetInvalidParam(where, "postId")
+			return nil
+		}
+
+		if post.ChannelId != channelId {
+			c.Err = model.NewAppError(where, "You do not have the appropriate permissions", "")
+			c.Err.StatusCode = http.StatusForbidden
+			return nil
+		}
+
+		return post
+	}
+}
+
+func listReactions(c *Context, w http.ResponseWriter, r *http.Request) {
+	post := getChannelPost(c, r, "listReactions")
+	if post == nil {
+		return
+	}
+
+	if result := <-Srv.Store.Reaction().GetForPost(post.Id); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(model.ReactionsToJson(result.Data.([]*model.Reaction))))
+	}
+}
+
+func addReaction(c *Context, w http.ResponseWriter, r *http.Request) {
+	reaction := model.ReactionFromJson(r.Body)
+	if reaction == nil {
+		c.SetInvalidParam("addReaction", "reaction")
+		return
+	}
+
+	post := getChannelPost(c, r, "addReaction")
+	if post == nil {
+		return
+	}
+
+	reaction.UserId = c.Session.UserId
+	reaction.PostId = post.Id
+	reaction.CreateAt = 0
+
+	reaction.PreSave()
+	if err := reaction.IsValid(); err != nil {
+		c.Err = err
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	}
+
+	if result := <-Srv.Store.Reaction().Save(reaction); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	publishReaction(c, post, reaction, model.ACTION_REACTION_ADDED)
+	w.Write([]byte(reaction.ToJson()))
+}
+
+func removeReaction(c *Context, w http.ResponseWriter, r *http.Request) {
+	reaction := model.ReactionFromJson(r.Body)
+	if reaction == nil {
+		c.SetInvalidParam("removeReaction", "reaction")
+		return
+	}
+
+	post := getChannelPost(c, r, "removeReaction")
+	if post == nil {
+		return
+	}
+
+	// Users can only take back their own reactions
+	reaction.UserId = c.Session.UserId
+	reaction.PostId = post.Id
+
+	if result := <-Srv.Store.Reaction().Delete(reaction); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	publishReaction(c, post, reaction, model.ACTION_REACTION_REMOVED)
+	w.Write([]byte(reaction.ToJson()))
+}
+
+func publishReaction(c *Context, post *model.Post, reaction *model.Reaction, action string) {
+	message := model.NewMessage(c.Session.TeamId, post.ChannelId, c.Session.UserId, action)
+	message.Add("post_id", post.Id)
+	message.Add("reaction", reaction.ToJson())
+
+	store.PublishAndForget(message)
+}
+
+func pinPost(c *Context, w http.ResponseWriter, r *http.Request) {
+	setPostPinned(c, w, r, true)
+}
+
+func unpinPost(c *Context, w http.ResponseWriter, r *http.Request) {
+	setPostPinned(c, w, r, false)
+}
+
+func setPostPinned(c *Context, w http.ResponseWriter, r *http.Request, isPinned bool) {
+	post := getChannelPost(c, r, "setPostPinned")
+	if post == nil {
+		return
+	}
+
+	if result := <-Srv.Store.Post().UpdatePinned(post.Id, isPinned); result.Err != nil {
+		c.Err = result.Err
+		return
+	}
+
+	c.LogAudit(fmt.Sprintf("post_id=%v pinned=%v", post.Id, isPinned))
+
+	action := model.ACTION_POST_PINNED
+	if !isPinned {
+		action = model.ACTION_POST_UNPINNED
+	}
+
+	message := model.NewMessage(c.Session.TeamId, post.ChannelId, c.Session.UserId, action)
+	message.Add("post_id", post.Id)
+	message.Add("channel_id", post.ChannelId)
+
+	store.PublishAndForget(message)
+
+	post.IsPinned = isPinned
+	w.Write([]byte(post.ToJson()))
+}
+
+func getPinnedPosts(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	id := params["id"]
+	if len(id) != 26 {
+		c.SetInvalidParam("getPinnedPosts", "channelId")
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.Session.TeamId, id, c.Session.UserId)
+	pchan := Srv.Store.Post().GetPinnedPosts(id)
+
+	if !c.HasPermissionsToChannel(cchan, "getPinnedPosts") {
+		return
+	}
+
+	if result := <-pchan; result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(result.Data.(*model.PostList).ToJson()))
+	}
+}
+
+func savePost(c *Context, w http.ResponseWriter, r *http.Request) {
+	post := getChannelPost(c, r, "savePost")
+	if post == nil {
+		return
+	}
+
+	saved := &model.SavedPost{UserId: c.Session.UserId, PostId: post.Id}
+
+	if result := <-Srv.Store.SavedPost().Save(saved); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(result.Data.(*model.SavedPost).ToJson()))
+	}
+}
+
+func unsavePost(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	// A user can always clear their own saved post, even once they have
+	// left the channel it was posted in
+	postId := params["post_id"]
+	if len(postId) != 26 {
+		c.SetInvalidParam("unsavePost", "postId")
+		return
+	}
+
+	if result := <-Srv.Store.SavedPost().Delete(c.Session.UserId, postId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		data := map[string]string{"post_id": postId}
+		w.Write([]byte(model.MapToJson(data)))
+	}
+}
+
+func getSavedPosts(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	offset, err := strconv.Atoi(params["offset"])
+	if err != nil {
+		c.SetInvalidParam("getSavedPosts", "offset")
+		return
+	}
+
+	limit, err := strconv.Atoi(params["limit"])
+	if err != nil {
+		c.SetInvalidParam("getSavedPosts", "limit")
+		return
+	}
+
+	if result := <-Srv.Store.SavedPost().GetSavedPosts(c.Session.TeamId, c.Session.UserId, offset, limit); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(result.Data.(*model.PostList).ToJson()))
+	}
+}
+
+func getMentions(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	offset, err := strconv.Atoi(params["offset"])
+	if err != nil {
+		c.SetInvalidParam("getMentions", "offset")
+		return
+	}
+
+	limit, err := strconv.Atoi(params["limit"])
+	if err != nil {
+		c.SetInvalidParam("getMentions", "limit")
+		return
+	}
+
+	if result := <-Srv.Store.PostMention().GetMentions(c.Session.TeamId, c.Session.UserId, offset, limit); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(result.Data.(*model.PostList).ToJson()))
+	}
+}
+
+func getThread(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	offset, err := strconv.Atoi(params["offset"])
+	if err != nil {
+		c.SetInvalidParam("getThread", "offset")
+		return
+	}
+
+	limit, err := strconv.Atoi(params["limit"])
+	if err != nil {
+		c.SetInvalidParam("getThread", "limit")
+		return
+	}
+
+	post := getChannelPost(c, r, "getThread")
+	if post == nil {
+		return
+	}
+
+	if result := <-Srv.Store.Post().GetThread(post.ThreadId(), offset, limit); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(result.Data.(*model.PostList).ToJson()))
+	}
+}
+
+func followThread(c *Context, w http.ResponseWriter, r *http.Request) {
+	setThreadFollowing(c, w, r, true)
+}
+
+func unfollowThread(c *Context, w http.ResponseWriter, r *http.Request) {
+	setThreadFollowing(c, w, r, false)
+}
+
+func setThreadFollowing(c *Context, w http.ResponseWriter, r *http.Request, following bool) {
+	post := getChannelPost(c, r, "setThreadFollowing")
+	if post == nil {
+		return
+	}
+
+	follower := &model.ThreadFollower{RootId: post.ThreadId(), UserId: c.Session.UserId, Following: following}
+
+	if result := <-Srv.Store.Thread().SaveFollower(follower); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(result.Data.(*model.ThreadFollower).ToJson()))
+	}
+}
+
 func searchPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 	terms := r.FormValue("terms")
 
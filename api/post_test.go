@@ -330,10 +330,14 @@ func TestGetPosts(t *testing.T) {
 		t.Fatal("wrong order")
 	}
 
-	if len(r1.Posts) != 4 {
+	if len(r1.Posts) != 2 {
 		t.Fatal("wrong size")
 	}
 
+	if r1.Posts[post3.Id].ReplyCount != 1 {
+		t.Fatal("should have counted the reply")
+	}
+
 	r2 := Client.Must(Client.GetPosts(channel1.Id, 2, 2, "")).Data.(*model.PostList)
 
 	if r2.Order[0] != post2.Id {
@@ -344,7 +348,8 @@ func TestGetPosts(t *testing.T) {
 		t.Fatal("wrong order")
 	}
 
-	if len(r2.Posts) != 4 {
+	// post1 comes along as the root of post1a1
+	if len(r2.Posts) != 3 {
 		t.Log(r2.Posts)
 		t.Fatal("wrong size")
 	}
@@ -394,6 +399,26 @@ func TestSearchPosts(t *testing.T) {
 	if len(r3.Order) != 1 && r3.Order[0] == post3.Id {
 		t.Fatal("wrong serach")
 	}
+
+	post5 := &model.Post{ChannelId: channel1.Id, Message: "build status"}
+	post5.SetAttachments([]*model.PostAttachment{{Color: "good", Title: "Build passed", Text: "attachmentsearchterm"}})
+	post5 = Client.Must(Client.CreatePost(post5)).Data.(*model.Post)
+
+	if attachments := post5.Attachments(); len(attachments) != 1 || attachments[0].Title != "Build passed" {
+		t.Fatal("attachments weren't saved")
+	}
+
+	r4 := Client.Must(Client.SearchPosts("attachmentsearchterm")).Data.(*model.PostList)
+
+	if len(r4.Order) != 1 || r4.Order[0] != post5.Id {
+		t.Fatal("should have found the post by its attachment")
+	}
+
+	post6 := &model.Post{ChannelId: channel1.Id, Message: "bad attachment"}
+	post6.SetAttachments([]*model.PostAttachment{{Title: "Build passed", ImageUrl: "ftp://example.com/image.png"}})
+	if _, err := Client.CreatePost(post6); err == nil {
+		t.Fatal("should have rejected the attachment")
+	}
 }
 
 func TestSearchHashtagPosts(t *testing.T) {
@@ -524,6 +549,379 @@ func TestDeletePosts(t *testing.T) {
 	}
 }
 
+func TestReactions(t *testing.T) {
+	Setup()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "TestReactions", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	channel2 := &model.Channel{DisplayName: "TestReactions", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_PRIVATE, TeamId: team.Id}
+	channel2 = Client.Must(Client.CreateChannel(channel2)).Data.(*model.Channel)
+
+	post1 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+	post2 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel2.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+
+	reaction := Client.Must(Client.AddReaction(channel1.Id, &model.Reaction{PostId: post1.Id, EmojiName: "smile"})).Data.(*model.Reaction)
+	if reaction.UserId != user1.Id {
+		t.Fatal("should have reacted as user1")
+	}
+
+	if _, err := Client.AddReaction(channel1.Id, &model.Reaction{PostId: post1.Id, EmojiName: "not an emoji"}); err == nil {
+		t.Fatal("should have failed on a bad emoji name")
+	}
+
+	// The post has to be in the channel the request is for
+	if _, err := Client.AddReaction(channel1.Id, &model.Reaction{PostId: post2.Id, EmojiName: "smile"}); err == nil {
+		t.Fatal("should have failed on a post in another channel")
+	}
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	if _, err := Client.AddReaction(channel2.Id, &model.Reaction{PostId: post2.Id, EmojiName: "smile"}); err == nil {
+		t.Fatal("should not be able to react in a channel user2 isn't in")
+	}
+
+	if _, err := Client.ListReactions(channel2.Id, post2.Id); err == nil {
+		t.Fatal("should not be able to list reactions in a channel user2 isn't in")
+	}
+
+	Client.Must(Client.JoinChannel(channel1.Id))
+	Client.Must(Client.AddReaction(channel1.Id, &model.Reaction{PostId: post1.Id, EmojiName: "smile"}))
+	Client.Must(Client.AddReaction(channel1.Id, &model.Reaction{PostId: post1.Id, EmojiName: "+1"}))
+
+	if reactions := Client.Must(Client.ListReactions(channel1.Id, post1.Id)).Data.([]*model.Reaction); len(reactions) != 3 {
+		t.Fatal("should have three reactions")
+	}
+
+	list := Client.Must(Client.GetPosts(channel1.Id, 0, 10, "")).Data.(*model.PostList)
+	if summaries := list.Reactions[post1.Id]; len(summaries) != 2 || summaries[0].EmojiName != "smile" || summaries[0].Count != 2 {
+		t.Fatal("should have summarized the reactions")
+	}
+
+	// Removing only ever takes away the caller's own reaction
+	Client.Must(Client.RemoveReaction(channel1.Id, &model.Reaction{PostId: post1.Id, UserId: user1.Id, EmojiName: "smile"}))
+
+	list = Client.Must(Client.GetPosts(channel1.Id, 0, 10, "")).Data.(*model.PostList)
+	if summaries := list.Reactions[post1.Id]; len(summaries) != 2 || summaries[0].Count != 1 || summaries[0].UserIds[0] != user1.Id {
+		t.Fatal("should have left user1's reaction alone")
+	}
+}
+
+func TestPinPosts(t *testing.T) {
+	Setup()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "TestPinPosts", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_PRIVATE, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	post1 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+	post2 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+
+	if rpost := Client.Must(Client.PinPost(channel1.Id, post1.Id)).Data.(*model.Post); !rpost.IsPinned {
+		t.Fatal("should have pinned the post")
+	}
+
+	Client.Must(Client.PinPost(channel1.Id, post2.Id))
+	Client.Must(Client.UnpinPost(channel1.Id, post2.Id))
+
+	list := Client.Must(Client.GetPinnedPosts(channel1.Id)).Data.(*model.PostList)
+	if len(list.Order) != 1 || list.Order[0] != post1.Id {
+		t.Fatal("should only have post1 pinned")
+	}
+
+	if extra := Client.Must(Client.GetChannelExtraInfo(channel1.Id)).Data.(*model.ChannelExtra); extra.PinnedPostCount != 1 {
+		t.Fatal("extra info should count the pinned post")
+	}
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	if _, err := Client.PinPost(channel1.Id, post2.Id); err == nil {
+		t.Fatal("should not be able to pin in a channel user2 isn't in")
+	}
+
+	if _, err := Client.GetPinnedPosts(channel1.Id); err == nil {
+		t.Fatal("should not be able to see pins in a channel user2 isn't in")
+	}
+}
+
+func TestSavedPosts(t *testing.T) {
+	Setup()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "TestSavedPosts", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	channel2 := &model.Channel{DisplayName: "TestSavedPosts", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel2 = Client.Must(Client.CreateChannel(channel2)).Data.(*model.Channel)
+
+	post1 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+	post2 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+	post3 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel2.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+
+	Client.Must(Client.SavePost(channel1.Id, post1.Id))
+	Client.Must(Client.SavePost(channel1.Id, post2.Id))
+	Client.Must(Client.SavePost(channel2.Id, post3.Id))
+
+	// Saving twice is harmless
+	Client.Must(Client.SavePost(channel1.Id, post1.Id))
+
+	if _, err := Client.SavePost(channel2.Id, post1.Id); err == nil {
+		t.Fatal("should fail when the post isn't in the channel")
+	}
+
+	list := Client.Must(Client.GetSavedPosts(0, 10)).Data.(*model.PostList)
+	if len(list.Order) != 3 {
+		t.Fatal("should have three saved posts")
+	}
+
+	list = Client.Must(Client.GetSavedPosts(0, 2)).Data.(*model.PostList)
+	if len(list.Order) != 2 {
+		t.Fatal("should have paged the saved posts")
+	}
+
+	// Edits show up and deletes drop out
+	post1.Message = "a" + model.NewId() + "a"
+	Client.Must(Client.UpdatePost(post1))
+	Client.Must(Client.DeletePost(channel1.Id, post2.Id))
+
+	list = Client.Must(Client.GetSavedPosts(0, 10)).Data.(*model.PostList)
+	if len(list.Order) != 2 {
+		t.Fatal("should have dropped the deleted post")
+	}
+
+	if list.Posts[post1.Id] == nil || list.Posts[post1.Id].Message != post1.Message {
+		t.Fatal("should have the edited message")
+	}
+
+	// Leaving a channel hides its saved posts
+	Client.Must(Client.LeaveChannel(channel2.Id))
+
+	list = Client.Must(Client.GetSavedPosts(0, 10)).Data.(*model.PostList)
+	if len(list.Order) != 1 || list.Order[0] != post1.Id {
+		t.Fatal("should only have post1 saved")
+	}
+
+	Client.Must(Client.UnsavePost(channel1.Id, post1.Id))
+
+	if list = Client.Must(Client.GetSavedPosts(0, 10)).Data.(*model.PostList); len(list.Order) != 0 {
+		t.Fatal("should have no saved posts")
+	}
+
+	// Saved posts are per user
+	Client.Must(Client.SavePost(channel1.Id, post1.Id))
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	if list = Client.Must(Client.GetSavedPosts(0, 10)).Data.(*model.PostList); len(list.Order) != 0 {
+		t.Fatal("user2 should have no saved posts")
+	}
+}
+
+func TestGetMentions(t *testing.T) {
+	Setup()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "TestGetMentions", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	channel2 := &model.Channel{DisplayName: "TestGetMentions", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel2 = Client.Must(Client.CreateChannel(channel2)).Data.(*model.Channel)
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+	Client.Must(Client.JoinChannel(channel1.Id))
+	Client.Must(Client.JoinChannel(channel2.Id))
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	post1 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "hey @" + user2.Username + "."})).Data.(*model.Post)
+	time.Sleep(10 * time.Millisecond)
+	post2 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "@all have a look"})).Data.(*model.Post)
+	time.Sleep(10 * time.Millisecond)
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "no mentions in here"}))
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "`@" + user2.Username + "` is in code"}))
+	time.Sleep(10 * time.Millisecond)
+	post3 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel2.Id, Message: "ping " + user2.Username})).Data.(*model.Post)
+
+	// Mentions are saved in the background with the notifications
+	time.Sleep(500 * time.Millisecond)
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	list := Client.Must(Client.GetMentions(0, 10)).Data.(*model.PostList)
+	if len(list.Order) != 3 || list.Order[0] != post3.Id || list.Order[1] != post2.Id || list.Order[2] != post1.Id {
+		t.Fatal("should have the three mentions, newest first", list.Order)
+	}
+
+	list = Client.Must(Client.GetMentions(1, 1)).Data.(*model.PostList)
+	if len(list.Order) != 1 || list.Order[0] != post2.Id {
+		t.Fatal("should have paged the mentions")
+	}
+
+	if _, err := Client.GetMentions(0, 1001); err == nil {
+		t.Fatal("should have failed on the paging limit")
+	}
+
+	// Leaving a channel hides its mentions
+	Client.Must(Client.LeaveChannel(channel2.Id))
+
+	list = Client.Must(Client.GetMentions(0, 10)).Data.(*model.PostList)
+	if len(list.Order) != 2 || list.Posts[post3.Id] != nil {
+		t.Fatal("should have dropped the mention in channel2")
+	}
+
+	// Posters aren't mentioned by their own posts
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	if list = Client.Must(Client.GetMentions(0, 10)).Data.(*model.PostList); len(list.Order) != 0 {
+		t.Fatal("user1 should have no mentions")
+	}
+
+	// Editing a mention out drops the post, deleting it drops it too
+	post1.Message = "never mind"
+	Client.Must(Client.UpdatePost(post1))
+	Client.Must(Client.DeletePost(channel1.Id, post2.Id))
+
+	time.Sleep(500 * time.Millisecond)
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	if list = Client.Must(Client.GetMentions(0, 10)).Data.(*model.PostList); len(list.Order) != 0 {
+		t.Fatal("should have dropped the edited and deleted mentions", list.Order)
+	}
+}
+
+func TestThreads(t *testing.T) {
+	Setup()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	user3 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user3 = Client.Must(Client.CreateUser(user3, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user3.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "TestThreads", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	root := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+	Client.Must(Client.JoinChannel(channel1.Id))
+
+	time.Sleep(10 * time.Millisecond)
+	reply1 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a", RootId: root.Id})).Data.(*model.Post)
+
+	Client.LoginByEmail(team.Domain, user3.Email, "pwd")
+	Client.Must(Client.JoinChannel(channel1.Id))
+
+	time.Sleep(10 * time.Millisecond)
+	reply2 := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a", RootId: root.Id})).Data.(*model.Post)
+
+	// Asking for the thread through a reply gets the whole thread
+	list := Client.Must(Client.GetThread(channel1.Id, reply2.Id, 0, 1)).Data.(*model.PostList)
+	if len(list.Order) != 1 || list.Order[0] != reply1.Id {
+		t.Fatal("should have the first reply")
+	}
+
+	if list.Posts[root.Id] == nil || list.Posts[root.Id].ReplyCount != 2 || list.Posts[root.Id].LastReplyAt != reply2.CreateAt {
+		t.Fatal("should have the root post with its metadata")
+	}
+
+	// The author of the root and both repliers follow the thread
+	if followers := (<-Srv.Store.Thread().GetFollowers(root.Id)).Data.([]string); len(followers) != 3 {
+		t.Fatal("should have three followers")
+	}
+
+	// Replies reach followers without counting as mentions
+	time.Sleep(500 * time.Millisecond)
+
+	if member := (<-Srv.Store.Channel().GetMember(channel1.Id, user1.Id)).Data.(model.ChannelMember); member.MentionCount != 0 {
+		t.Fatal("replies should not have counted as mentions for the root's author")
+	}
+
+	if follower := Client.Must(Client.UnfollowThread(channel1.Id, root.Id)).Data.(*model.ThreadFollower); follower.Following || follower.RootId != root.Id {
+		t.Fatal("should have unfollowed the thread")
+	}
+
+	// Replying again doesn't undo the unfollow
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a", RootId: root.Id}))
+
+	if followers := (<-Srv.Store.Thread().GetFollowers(root.Id)).Data.([]string); len(followers) != 2 {
+		t.Fatal("should have two followers")
+	}
+
+	Client.Must(Client.FollowThread(channel1.Id, reply1.Id))
+
+	if followers := (<-Srv.Store.Thread().GetFollowers(root.Id)).Data.([]string); len(followers) != 3 {
+		t.Fatal("should be following again")
+	}
+
+	Client.Must(Client.LeaveChannel(channel1.Id))
+
+	if _, err := Client.GetThread(channel1.Id, root.Id, 0, 10); err == nil {
+		t.Fatal("should not be able to read the thread after leaving")
+	}
+}
+
 func TestEmailMention(t *testing.T) {
 	Setup()
 
@@ -546,6 +944,88 @@ func TestEmailMention(t *testing.T) {
 
 }
 
+func TestChannelWideMentions(t *testing.T) {
+	Setup()
+
+	settings := utils.Cfg.TeamSettings
+	defer func() { utils.Cfg.TeamSettings = settings }()
+	utils.Cfg.TeamSettings.LargeChannelMentionSize = 2
+	utils.Cfg.TeamSettings.LargeChannelMentionsAdminOnly = false
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	user3 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user3 = Client.Must(Client.CreateUser(user3, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user3.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "TestChannelWideMentions", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+	Client.Must(Client.AddChannelMember(channel1.Id, user2.Id))
+
+	// Two members is still small enough
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "hello @all"}))
+
+	Client.Must(Client.AddChannelMember(channel1.Id, user3.Id))
+
+	// user1 created the channel so they're its admin
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "hello @channel"}))
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	if _, err := Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "hello @Channel!"}); err == nil {
+		t.Fatal("should have asked for confirmation")
+	} else if err.StatusCode != http.StatusPreconditionFailed {
+		t.Fatal("wrong status code", err.StatusCode)
+	}
+
+	if _, err := Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "hello @here"}); err != nil {
+		t.Fatal("@here doesn't need confirming", err)
+	}
+
+	post := &model.Post{ChannelId: channel1.Id, Message: "hello @all", Props: model.StringMap{model.POST_PROP_CONFIRM_CHANNEL_MENTION: "true"}}
+	if rpost, err := Client.CreatePost(post); err != nil {
+		t.Fatal(err)
+	} else if _, ok := rpost.Data.(*model.Post).Props[model.POST_PROP_CONFIRM_CHANNEL_MENTION]; ok {
+		t.Fatal("the confirmation shouldn't have been saved")
+	}
+
+	utils.Cfg.TeamSettings.LargeChannelMentionsAdminOnly = true
+
+	if _, err := Client.CreatePost(post); err == nil {
+		t.Fatal("only channel admins should be able to mention the channel")
+	} else if err.StatusCode != http.StatusForbidden {
+		t.Fatal("wrong status code", err.StatusCode)
+	}
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "hello @all"}))
+
+	Client.LoginByEmail(team.Domain, user3.Email, "pwd")
+	Client.Must(Client.UpdateLastViewedAt(channel1.Id))
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "anyone @here?"}))
+	time.Sleep(500 * time.Millisecond)
+
+	Client.LoginByEmail(team.Domain, user3.Email, "pwd")
+	for _, unread := range Client.Must(Client.GetChannelUnreads()).Data.([]*model.ChannelUnread) {
+		if unread.ChannelId == channel1.Id && unread.MentionCount != 0 {
+			t.Fatal("@here shouldn't mention users who are offline")
+		}
+	}
+}
+
 func TestFuzzyPosts(t *testing.T) {
 	Setup()
 
@@ -0,0 +1,186 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	l4g "code.google.com/p/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+	"sync"
+	"time"
+)
+
+const (
+	PRESENCE_SWEEP_INTERVAL        = 60 * time.Second
+	PRESENCE_NODE_TTL              = 3 * PRESENCE_SWEEP_INTERVAL
+	PRESENCE_ACTIVITY_WRITE_MILLIS = 60 * 1000
+	PRESENCE_ACTIVITY_CACHE_SIZE   = 10000
+)
+
+// Presence tracks who is connected to this node. The statuses themselves
+// live in redis so every node sees the same thing.
+type Presence struct {
+	lock          sync.Mutex
+	conns         map[string]int
+	teams         map[string]string
+	activityCache *utils.Cache
+}
+
+var presence = &Presence{
+	conns:         make(map[string]int),
+	teams:         make(map[string]string),
+	activityCache: utils.NewLru(PRESENCE_ACTIVITY_CACHE_SIZE),
+}
+
+// Start marks this node as alive, cleans up after nodes that died and then
+// periodically re-derives the status of everyone connected to this node so
+// idle users go away and manual statuses expire.
+func (p *Presence) Start() {
+	p.heartbeat()
+
+	go func() {
+		ticker := time.NewTicker(PRESENCE_SWEEP_INTERVAL)
+
+		for {
+			<-ticker.C
+
+			p.heartbeat()
+
+			p.lock.Lock()
+			users := make(map[string]string, len(p.teams))
+			for userId, teamId := range p.teams {
+				users[userId] = teamId
+			}
+			p.lock.Unlock()
+
+			for userId, teamId := range users {
+				p.update(teamId, userId, true, nil)
+			}
+		}
+	}()
+}
+
+// heartbeat keeps this node's connections counting and re-derives the status
+// of anyone who was connected to a node that stopped sending heartbeats.
+func (p *Presence) heartbeat() {
+	if err := store.StatusHeartbeat(PRESENCE_NODE_TTL); err != nil {
+		l4g.Error("Failed to send presence heartbeat err=%v", err)
+	}
+
+	teams, err := store.ReapStatusNodes()
+	if err != nil {
+		l4g.Error("Failed to reap dead presence nodes err=%v", err)
+		return
+	}
+
+	for teamId, userIds := range teams {
+		for _, userId := range userIds {
+			if count, err := store.GetStatusConnections(teamId, userId); err != nil {
+				l4g.Error("Failed to get connections for user_id=%v err=%v", userId, err)
+			} else {
+				p.update(teamId, userId, count > 0, nil)
+			}
+		}
+	}
+}
+
+func (p *Presence) Connected(teamId string, userId string) {
+	p.lock.Lock()
+	p.conns[userId]++
+	p.teams[userId] = teamId
+	p.lock.Unlock()
+
+	p.activityCache.Add(userId, model.GetMillis())
+
+	if count, err := store.IncrementStatusConnections(teamId, userId, 1); err != nil {
+		l4g.Error("Failed to record connection for user_id=%v err=%v", userId, err)
+	} else {
+		p.update(teamId, userId, count > 0, func(status *model.Status) {
+			status.LastActivityAt = model.GetMillis()
+		})
+	}
+}
+
+func (p *Presence) Disconnected(teamId string, userId string) {
+	p.lock.Lock()
+	p.conns[userId]--
+	if p.conns[userId] <= 0 {
+		delete(p.conns, userId)
+		delete(p.teams, userId)
+	}
+	p.lock.Unlock()
+
+	if count, err := store.IncrementStatusConnections(teamId, userId, -1); err != nil {
+		l4g.Error("Failed to record disconnection for user_id=%v err=%v", userId, err)
+	} else {
+		p.update(teamId, userId, count > 0, nil)
+	}
+}
+
+// Activity marks the user as active. Writes are throttled so busy users cost
+// at most one redis write per PRESENCE_ACTIVITY_WRITE_MILLIS.
+func (p *Presence) Activity(teamId string, userId string) {
+	now := model.GetMillis()
+
+	if last, ok := p.activityCache.Get(userId); ok && now-last.(int64) < PRESENCE_ACTIVITY_WRITE_MILLIS {
+		return
+	}
+
+	p.activityCache.Add(userId, now)
+
+	if count, err := store.GetStatusConnections(teamId, userId); err != nil {
+		l4g.Error("Failed to get connections for user_id=%v err=%v", userId, err)
+	} else {
+		p.update(teamId, userId, count > 0, func(status *model.Status) {
+			status.LastActivityAt = now
+		})
+	}
+}
+
+func (p *Presence) SetManual(teamId string, userId string, manual string, until int64) (*model.Status, *model.AppError) {
+	count, err := store.GetStatusConnections(teamId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.update(teamId, userId, count > 0, func(status *model.Status) {
+		status.Manual = manual
+		status.ManualUntil = until
+	})
+}
+
+// update applies change to the stored status, re-derives it and tells the
+// team when the result is different from before.
+func (p *Presence) update(teamId string, userId string, connected bool, change func(status *model.Status)) (*model.Status, *model.AppError) {
+	before, status, err := store.UpdateStatus(teamId, userId, func(status *model.Status) {
+		if change != nil {
+			change(status)
+		}
+
+		status.Status = status.Derive(connected, model.GetMillis())
+	})
+
+	if err != nil {
+		l4g.Error("Failed to update status for user_id=%v err=%v", userId, err)
+		return nil, err
+	}
+
+	if status.Status != before.Status {
+		message := model.NewMessage(teamId, "", userId, model.ACTION_STATUS_CHANGE)
+		message.Add("status", status.Status)
+
+		store.PublishAndForget(message)
+	}
+
+	return status, nil
+}
+
+func isUserOnline(statuses map[string]*model.Status, userId string) bool {
+	if status, ok := statuses[userId]; ok {
+		return status.Status == model.USER_ONLINE
+	}
+
+	return false
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	l4g "code.google.com/p/log4go"
+	"github.com/mattermost/platform/model"
+	"time"
+)
+
+const (
+	REMINDER_INTERVAL    = 10 * time.Second
+	REMINDER_BATCH_SIZE  = 100
+	REMINDER_CLAIM_LEASE = 5 * 60 * 1000
+	REMINDER_RETRY_DELAY = 60 * 1000
+	REMINDER_MAX_TRIES   = 10
+)
+
+// startReminderScheduler delivers reminders once they're due. They live in
+// the database so restarts don't lose them, and the claim in the store means
+// only one server sends each one. A reminder that fails to send is released
+// and tried again a minute later, up to REMINDER_MAX_TRIES times.
+func startReminderScheduler() {
+	go func() {
+		ticker := time.NewTicker(REMINDER_INTERVAL)
+
+		for {
+			<-ticker.C
+			sendDueReminders()
+		}
+	}()
+}
+
+func sendDueReminders() {
+	now := model.GetMillis()
+
+	var due []*model.Reminder
+	if result := <-Srv.Store.Reminder().GetDue(now, now-REMINDER_CLAIM_LEASE, REMINDER_BATCH_SIZE); result.Err != nil {
+		l4g.Error("Failed to get the reminders err=%v", result.Err)
+		return
+	} else {
+		due = result.Data.([]*model.Reminder)
+	}
+
+	for _, reminder := range due {
+		if result := <-Srv.Store.Reminder().Claim(reminder, model.GetMillis()); result.Err != nil {
+			l4g.Error("Failed to claim the reminder id=%v err=%v", reminder.Id, result.Err)
+		} else if result.Data.(bool) {
+			sendReminder(reminder)
+		}
+	}
+}
+
+func sendReminder(reminder *model.Reminder) {
+	c := &Context{
+		Session:   model.Session{UserId: reminder.CreatorId, TeamId: reminder.TeamId},
+		RequestId: model.NewId(),
+		TeamUrl:   reminder.TeamUrl,
+		Path:      "/api/v1/reminders/send",
+	}
+
+	// There's no one to send it to anymore, so there's no point trying again
+	if result := <-Srv.Store.User().Get(reminder.UserId); result.Err != nil && result.Err.Message == "We couldn't find the existing account" {
+		giveUpReminder(reminder, "the user doesn't exist")
+		return
+	} else if result.Err == nil && result.Data.(*model.User).DeleteAt > 0 {
+		giveUpReminder(reminder, "the user was deactivated")
+		return
+	}
+
+	message := "Reminder: " + reminder.Message
+	if reminder.CreatorId != reminder.UserId {
+		if result := <-Srv.Store.User().Get(reminder.CreatorId); result.Err == nil {
+			message = "@" + result.Data.(*model.User).Username + " asked me to remind you: " + reminder.Message
+		}
+	}
+
+	if err := sendValetMessage(c, reminder.UserId, message); err != nil {
+		if reminder.Attempts+1 >= REMINDER_MAX_TRIES {
+			giveUpReminder(reminder, err.Error())
+			return
+		}
+
+		l4g.Error("Failed to send the reminder id=%v err=%v", reminder.Id, err)
+
+		if result := <-Srv.Store.Reminder().Release(reminder.Id, model.GetMillis()+REMINDER_RETRY_DELAY); result.Err != nil {
+			l4g.Error("Failed to release the reminder id=%v err=%v", reminder.Id, result.Err)
+		}
+		return
+	}
+
+	if result := <-Srv.Store.Reminder().Finish(reminder.Id); result.Err != nil {
+		l4g.Error("Failed to finish the reminder id=%v err=%v", reminder.Id, result.Err)
+	}
+}
+
+// giveUpReminder drops a reminder that can't ever be sent.
+func giveUpReminder(reminder *model.Reminder, reason string) {
+	l4g.Error("Giving up on the reminder id=%v attempts=%v reason=%v", reminder.Id, reminder.Attempts+1, reason)
+
+	if result := <-Srv.Store.Reminder().Finish(reminder.Id); result.Err != nil {
+		l4g.Error("Failed to finish the reminder id=%v err=%v", reminder.Id, result.Err)
+	}
+}
+
+// sendValetMessage has the valet send the user a direct message.
+func sendValetMessage(c *Context, userId string, message string) *model.AppError {
+	valet, err := GetValet(c)
+	if err != nil {
+		return err
+	}
+
+	vc := &Context{
+		Session:   model.Session{UserId: valet.Id, TeamId: c.Session.TeamId},
+		RequestId: c.RequestId,
+		IpAddress: c.IpAddress,
+		TeamUrl:   c.TeamUrl,
+		Path:      c.Path,
+	}
+
+	channel, err := GetDirectChannel(vc, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = CreateValetPost(vc, &model.Post{ChannelId: channel.Id, Message: message})
+	return err
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	l4g "code.google.com/p/log4go"
+	"github.com/gorilla/mux"
+	"github.com/mattermost/platform/model"
+	"net/http"
+	"time"
+)
+
+const (
+	SCHEDULED_POST_INTERVAL    = 10 * time.Second
+	SCHEDULED_POST_BATCH_SIZE  = 100
+	SCHEDULED_POST_CLAIM_LEASE = 5 * 60 * 1000
+)
+
+func InitScheduledPost(r *mux.Router) {
+	l4g.Debug("Initializing scheduled post api routes")
+
+	sr := r.PathPrefix("/scheduled_posts").Subrouter()
+	sr.Handle("/", ApiUserRequired(getScheduledPosts)).Methods("GET")
+	sr.Handle("/create", ApiUserRequired(createScheduledPost)).Methods("POST")
+	sr.Handle("/update", ApiUserRequired(updateScheduledPost)).Methods("POST")
+	sr.Handle("/{id:[A-Za-z0-9]+}/cancel", ApiUserRequired(cancelScheduledPost)).Methods("POST")
+
+	startScheduler()
+}
+
+func createScheduledPost(c *Context, w http.ResponseWriter, r *http.Request) {
+	scheduled := model.ScheduledPostFromJson(r.Body)
+	if scheduled == nil {
+		c.SetInvalidParam("createScheduledPost", "scheduled_post")
+		return
+	}
+
+	if scheduled.ScheduledAt <= model.GetMillis() {
+		c.SetInvalidParam("createScheduledPost", "scheduled_at")
+		return
+	}
+
+	cchan := Srv.Store.Channel().CheckPermissionsTo(c.Session.TeamId, scheduled.ChannelId, c.Session.UserId)
+	if !c.HasPermissionsToChannel(cchan, "createScheduledPost") {
+		return
+	}
+
+	if !checkScheduledChannelWideMention(c, scheduled) {
+		return
+	}
+
+	scheduled.Id = ""
+	scheduled.UserId = c.Session.UserId
+	scheduled.TeamId = c.Session.TeamId
+	scheduled.TeamUrl = c.TeamUrl
+
+	if result := <-Srv.Store.ScheduledPost().Save(scheduled); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	} else {
+		w.Write([]byte(result.Data.(*model.ScheduledPost).ToJson()))
+	}
+}
+
+func getScheduledPosts(c *Context, w http.ResponseWriter, r *http.Request) {
+	if result := <-Srv.Store.ScheduledPost().GetForUser(c.Session.TeamId, c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else {
+		w.Write([]byte(model.ScheduledPostsToJson(result.Data.([]*model.ScheduledPost))))
+	}
+}
+
+func updateScheduledPost(c *Context, w http.ResponseWriter, r *http.Request) {
+	scheduled := model.ScheduledPostFromJson(r.Body)
+	if scheduled == nil {
+		c.SetInvalidParam("updateScheduledPost", "scheduled_post")
+		return
+	}
+
+	if scheduled.ScheduledAt <= model.GetMillis() {
+		c.SetInvalidParam("updateScheduledPost", "scheduled_at")
+		return
+	}
+
+	var oldScheduled *model.ScheduledPost
+	if result := <-Srv.Store.ScheduledPost().Get(scheduled.Id); result.Err != nil {
+		c.SetInvalidParam("updateScheduledPost", "id")
+		return
+	} else {
+		oldScheduled = result.Data.(*model.ScheduledPost)
+	}
+
+	if oldScheduled.UserId != c.Session.UserId {
+		c.Err = model.NewAppError("updateScheduledPost", "You do not have the appropriate permissions", "")
+		c.Err.StatusCode = http.StatusForbidden
+		return
+	}
+
+	oldScheduled.Message = scheduled.Message
+	oldScheduled.ScheduledAt = scheduled.ScheduledAt
+	oldScheduled.ConfirmChannelMention = scheduled.ConfirmChannelMention
+
+	if !checkScheduledChannelWideMention(c, oldScheduled) {
+		return
+	}
+
+	if result := <-Srv.Store.ScheduledPost().Update(oldScheduled); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	} else {
+		w.Write([]byte(result.Data.(*model.ScheduledPost).ToJson()))
+	}
+}
+
+// checkScheduledChannelWideMention asks for the same confirmation as posting
+// right away, scheduling @all a minute ahead shouldn't get around it.
+func checkScheduledChannelWideMention(c *Context, scheduled *model.ScheduledPost) bool {
+	post := &model.Post{ChannelId: scheduled.ChannelId, Message: scheduled.Message, Props: model.StringMap{}}
+	if scheduled.ConfirmChannelMention {
+		post.Props[model.POST_PROP_CONFIRM_CHANNEL_MENTION] = "true"
+	}
+	scheduled.ConfirmChannelMention = false
+
+	return checkChannelWideMention(c, post)
+}
+
+func cancelScheduledPost(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	id := params["id"]
+	if len(id) != 26 {
+		c.SetInvalidParam("cancelScheduledPost", "id")
+		return
+	}
+
+	if result := <-Srv.Store.ScheduledPost().Delete(id, c.Session.UserId); result.Err != nil {
+		c.Err = result.Err
+		c.Err.StatusCode = http.StatusBadRequest
+		return
+	} else {
+		data := map[string]string{"id": id}
+		w.Write([]byte(model.MapToJson(data)))
+	}
+}
+
+// startScheduler posts scheduled messages once they're due. Every server runs
+// one, the claim in the store makes sure each message only goes out once.
+func startScheduler() {
+	go func() {
+		ticker := time.NewTicker(SCHEDULED_POST_INTERVAL)
+
+		for {
+			<-ticker.C
+			sendDueScheduledPosts()
+		}
+	}()
+}
+
+func sendDueScheduledPosts() {
+	now := model.GetMillis()
+
+	var due []*model.ScheduledPost
+	if result := <-Srv.Store.ScheduledPost().GetDue(now, now-SCHEDULED_POST_CLAIM_LEASE, SCHEDULED_POST_BATCH_SIZE); result.Err != nil {
+		l4g.Error("Failed to get the scheduled posts err=%v", result.Err)
+		return
+	} else {
+		due = result.Data.([]*model.ScheduledPost)
+	}
+
+	for _, scheduled := range due {
+		if result := <-Srv.Store.ScheduledPost().Claim(scheduled, model.GetMillis()); result.Err != nil {
+			l4g.Error("Failed to claim the scheduled post id=%v err=%v", scheduled.Id, result.Err)
+		} else if result.Data.(bool) {
+			sendScheduledPost(scheduled)
+		}
+	}
+}
+
+func sendScheduledPost(scheduled *model.ScheduledPost) {
+	// A server that claimed this before us may have gotten as far as posting
+	if scheduled.State == model.SCHEDULED_POST_SENDING {
+		if result := <-Srv.Store.ScheduledPost().GetSentPostId(scheduled); result.Err != nil {
+			l4g.Error("Failed to check the scheduled post id=%v err=%v", scheduled.Id, result.Err)
+			return
+		} else if postId := result.Data.(string); len(postId) > 0 {
+			finishScheduledPost(scheduled.Id, postId, "")
+			return
+		}
+	}
+
+	c := &Context{
+		Session:   model.Session{UserId: scheduled.UserId, TeamId: scheduled.TeamId},
+		RequestId: model.NewId(),
+		TeamUrl:   scheduled.TeamUrl,
+		Path:      "/api/v1/scheduled_posts/send",
+	}
+
+	uchan := Srv.Store.User().Get(scheduled.UserId)
+	cchan := Srv.Store.Channel().CheckPermissionsTo(scheduled.TeamId, scheduled.ChannelId, scheduled.UserId)
+
+	if result := <-uchan; result.Err != nil {
+		finishScheduledPost(scheduled.Id, "", result.Err.Message)
+		return
+	} else if user := result.Data.(*model.User); user.DeleteAt > 0 {
+		finishScheduledPost(scheduled.Id, "", "The user has been deactivated")
+		return
+	} else {
+		c.Session.Roles = user.Roles
+	}
+
+	if !c.HasPermissionsToChannel(cchan, "sendScheduledPost") {
+		finishScheduledPost(scheduled.Id, "", c.Err.Message)
+		return
+	}
+
+	post := &model.Post{ChannelId: scheduled.ChannelId, RootId: scheduled.RootId, Message: scheduled.Message}
+	post.Props = model.StringMap{model.POST_PROP_SCHEDULED_POST_ID: scheduled.Id}
+
+	// The user confirmed any @channel when they scheduled it, but they may not
+	// be allowed to use it in this channel anymore
+	post.Props[model.POST_PROP_CONFIRM_CHANNEL_MENTION] = "true"
+	if !checkChannelWideMention(c, post) {
+		finishScheduledPost(scheduled.Id, "", c.Err.Message)
+		return
+	}
+
+	if rpost, err := CreatePost(c, post, false); err != nil {
+		finishScheduledPost(scheduled.Id, "", err.Message)
+	} else {
+		finishScheduledPost(scheduled.Id, rpost.Id, "")
+	}
+}
+
+func finishScheduledPost(id string, postId string, errorMessage string) {
+	if len(errorMessage) > 0 {
+		l4g.Info("Failed to send scheduled post id=%v err=%v", id, errorMessage)
+	}
+
+	if result := <-Srv.Store.ScheduledPost().Finish(id, postId, errorMessage); result.Err != nil {
+		l4g.Error("Failed to finish the scheduled post id=%v err=%v", id, result.Err)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+	"net/http"
+	"testing"
+)
+
+func TestScheduledPosts(t *testing.T) {
+	Setup()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "TestScheduledPosts", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	channel2 := &model.Channel{DisplayName: "TestScheduledPosts", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel2 = Client.Must(Client.CreateChannel(channel2)).Data.(*model.Channel)
+
+	if _, err := Client.CreateScheduledPost(&model.ScheduledPost{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a", ScheduledAt: model.GetMillis() - 1000}); err == nil {
+		t.Fatal("should not schedule posts in the past")
+	}
+
+	s1 := Client.Must(Client.CreateScheduledPost(&model.ScheduledPost{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a", ScheduledAt: model.GetMillis() + 60000})).Data.(*model.ScheduledPost)
+	s2 := Client.Must(Client.CreateScheduledPost(&model.ScheduledPost{ChannelId: channel2.Id, Message: "a" + model.NewId() + "a", ScheduledAt: model.GetMillis() + 60000})).Data.(*model.ScheduledPost)
+	s3 := Client.Must(Client.CreateScheduledPost(&model.ScheduledPost{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a", ScheduledAt: model.GetMillis() + 60000})).Data.(*model.ScheduledPost)
+
+	if s1.State != model.SCHEDULED_POST_PENDING || s1.UserId != user1.Id {
+		t.Fatal("should be pending for user1")
+	}
+
+	s1.Message = "a" + model.NewId() + "a"
+	if rs1 := Client.Must(Client.UpdateScheduledPost(s1)).Data.(*model.ScheduledPost); rs1.Message != s1.Message {
+		t.Fatal("should have updated the message")
+	}
+
+	Client.Must(Client.CancelScheduledPost(s3.Id))
+
+	if list := Client.Must(Client.GetScheduledPosts()).Data.([]*model.ScheduledPost); len(list) != 2 {
+		t.Fatal("should have two scheduled posts")
+	}
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	if _, err := Client.UpdateScheduledPost(s1); err == nil {
+		t.Fatal("should not be able to edit someone else's scheduled post")
+	}
+
+	if _, err := Client.CancelScheduledPost(s1.Id); err == nil {
+		t.Fatal("should not be able to cancel someone else's scheduled post")
+	}
+
+	if list := Client.Must(Client.GetScheduledPosts()).Data.([]*model.ScheduledPost); len(list) != 0 {
+		t.Fatal("user2 should have no scheduled posts")
+	}
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	// Leaving the channel means the post can't go out
+	Client.Must(Client.LeaveChannel(channel2.Id))
+
+	for _, scheduled := range []*model.ScheduledPost{s1, s2} {
+		rs := (<-Srv.Store.ScheduledPost().Get(scheduled.Id)).Data.(*model.ScheduledPost)
+		rs.ScheduledAt = model.GetMillis() - 1000
+		<-Srv.Store.ScheduledPost().Update(rs)
+	}
+
+	sendDueScheduledPosts()
+	sendDueScheduledPosts()
+
+	rs1 := (<-Srv.Store.ScheduledPost().Get(s1.Id)).Data.(*model.ScheduledPost)
+	if rs1.State != model.SCHEDULED_POST_SENT || len(rs1.PostId) != 26 {
+		t.Fatal("s1 should have been sent")
+	}
+
+	rs2 := (<-Srv.Store.ScheduledPost().Get(s2.Id)).Data.(*model.ScheduledPost)
+	if rs2.State != model.SCHEDULED_POST_FAILED || len(rs2.Error) == 0 {
+		t.Fatal("s2 should have failed")
+	}
+
+	list := Client.Must(Client.GetPosts(channel1.Id, 0, 10, "")).Data.(*model.PostList)
+	sent := 0
+	for _, post := range list.Posts {
+		if post.Message == s1.Message {
+			sent++
+		}
+	}
+
+	if sent != 1 || list.Posts[rs1.PostId] == nil {
+		t.Fatal("should have posted s1 exactly once")
+	}
+
+	if _, err := Client.UpdateScheduledPost(s1); err == nil {
+		t.Fatal("should not be able to edit a sent post")
+	}
+}
+
+func TestScheduledChannelWideMentions(t *testing.T) {
+	Setup()
+
+	settings := utils.Cfg.TeamSettings
+	defer func() { utils.Cfg.TeamSettings = settings }()
+	utils.Cfg.TeamSettings.LargeChannelMentionSize = 2
+	utils.Cfg.TeamSettings.LargeChannelMentionsAdminOnly = false
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	user3 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user3 = Client.Must(Client.CreateUser(user3, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user3.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "TestScheduledChannelWideMentions", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+	Client.Must(Client.AddChannelMember(channel1.Id, user2.Id))
+	Client.Must(Client.AddChannelMember(channel1.Id, user3.Id))
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	scheduled := &model.ScheduledPost{ChannelId: channel1.Id, Message: "hello @all", ScheduledAt: model.GetMillis() + 60000}
+	if _, err := Client.CreateScheduledPost(scheduled); err == nil {
+		t.Fatal("should have asked for confirmation")
+	} else if err.StatusCode != http.StatusPreconditionFailed {
+		t.Fatal("wrong status code", err.StatusCode)
+	}
+
+	s1 := Client.Must(Client.CreateScheduledPost(&model.ScheduledPost{ChannelId: channel1.Id, Message: "hello", ScheduledAt: model.GetMillis() + 60000})).Data.(*model.ScheduledPost)
+	s1.Message = "hello @channel"
+	if _, err := Client.UpdateScheduledPost(s1); err == nil {
+		t.Fatal("should have asked for confirmation on the edit too")
+	}
+
+	scheduled.ConfirmChannelMention = true
+	s2 := Client.Must(Client.CreateScheduledPost(scheduled)).Data.(*model.ScheduledPost)
+	if s2.ConfirmChannelMention {
+		t.Fatal("the confirmation shouldn't have been saved")
+	}
+
+	// Only admins can use @all by the time it goes out
+	utils.Cfg.TeamSettings.LargeChannelMentionsAdminOnly = true
+
+	rs2 := (<-Srv.Store.ScheduledPost().Get(s2.Id)).Data.(*model.ScheduledPost)
+	rs2.ScheduledAt = model.GetMillis() - 1000
+	<-Srv.Store.ScheduledPost().Update(rs2)
+
+	sendDueScheduledPosts()
+
+	if rs2 = (<-Srv.Store.ScheduledPost().Get(s2.Id)).Data.(*model.ScheduledPost); rs2.State != model.SCHEDULED_POST_FAILED || len(rs2.Error) == 0 {
+		t.Fatal("should not have sent @all for someone who isn't an admin")
+	}
+}
@@ -48,11 +48,14 @@ func InitUser(r *mux.Router) {
 
 	sr.Handle("/me", ApiAppHandler(getMe)).Methods("GET")
 	sr.Handle("/status", ApiUserRequiredActivity(getStatuses, false)).Methods("GET")
+	sr.Handle("/status/set", ApiUserRequired(setStatus)).Methods("POST")
 	sr.Handle("/profiles", ApiUserRequired(getProfiles)).Methods("GET")
 	sr.Handle("/{id:[A-Za-z0-9]+}", ApiUserRequired(getUser)).Methods("GET")
 	sr.Handle("/{id:[A-Za-z0-9]+}/sessions", ApiUserRequired(getSessions)).Methods("GET")
 	sr.Handle("/{id:[A-Za-z0-9]+}/audits", ApiUserRequired(getAudits)).Methods("GET")
 	sr.Handle("/{id:[A-Za-z0-9]+}/image", ApiUserRequired(getProfileImage)).Methods("GET")
+
+	HandleWebSocket(model.WEBSOCKET_GET_STATUSES, getStatusesWebSocket)
 }
 
 func createUser(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -165,6 +168,10 @@ func CreateUser(c *Context, team *model.Team, user *model.User) *model.User {
 		user.Roles = ""
 	}
 
+	if !isUsernameFree(c, team.Id, user.Username, "createUser") {
+		return nil
+	}
+
 	user.MakeNonNil()
 	if len(user.Props["theme"]) == 0 {
 		user.AddProp("theme", utils.Cfg.TeamSettings.DefaultThemeColor)
@@ -386,6 +393,7 @@ func revokeSession(c *Context, w http.ResponseWriter, r *http.Request) {
 					c.Err = result.Err
 					return
 				} else {
+					InvalidateSessions(session.TeamId, session.UserId, session.AltId, CLOSE_REASON_SESSION_REVOKED)
 					w.Write([]byte(model.MapToJson(props)))
 					return
 				}
@@ -408,6 +416,8 @@ func RevokeAllSession(c *Context, userId string) {
 				c.Err = result.Err
 				return
 			}
+
+			InvalidateSessions(session.TeamId, session.UserId, session.AltId, CLOSE_REASON_SESSION_REVOKED)
 		}
 	}
 }
@@ -451,6 +461,10 @@ func Logout(c *Context, w http.ResponseWriter, r *http.Request) {
 		c.Err = result.Err
 		return
 	}
+
+	if len(c.Session.UserId) > 0 {
+		InvalidateSessions(c.Session.TeamId, c.Session.UserId, c.Session.AltId, CLOSE_REASON_SESSION_REVOKED)
+	}
 }
 
 func getMe(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -744,6 +758,13 @@ func updateUser(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if result := <-Srv.Store.User().Get(user.Id); result.Err != nil {
+		c.Err = result.Err
+		return
+	} else if oldUser := result.Data.(*model.User); strings.ToLower(user.Username) != oldUser.Username && !isUsernameFree(c, oldUser.TeamId, user.Username, "updateUser") {
+		return
+	}
+
 	if result := <-Srv.Store.User().Update(user, false); result.Err != nil {
 		c.Err = result.Err
 		return
@@ -766,6 +787,17 @@ func updateUser(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// isUsernameFree makes sure @username doesn't already mention a group on the team.
+func isUsernameFree(c *Context, teamId string, username string, where string) bool {
+	if result := <-Srv.Store.Group().GetByName(teamId, strings.ToLower(username)); result.Err == nil {
+		c.Err = model.NewAppError(where, "A group with that name already exists", "username="+username)
+		c.Err.StatusCode = http.StatusBadRequest
+		return false
+	}
+
+	return true
+}
+
 func updatePassword(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.LogAudit("attempted")
 
@@ -894,6 +926,14 @@ func updateRoles(c *Context, w http.ResponseWriter, r *http.Request) {
 	} else {
 		c.LogAuditWithUserId(user.Id, "roles="+new_roles)
 
+		// Sessions carry the roles they were created with
+		if result := <-Srv.Store.Session().UpdateRoles(user.Id, new_roles); result.Err != nil {
+			c.Err = result.Err
+			return
+		}
+
+		InvalidateSessions(user.TeamId, user.Id, "", CLOSE_REASON_SESSION_UPDATED)
+
 		ruser := result.Data.([2]*model.User)[0]
 		options := utils.SanitizeOptions
 		options["passwordupdate"] = false
@@ -1205,26 +1245,66 @@ func updateUserNotify(c *Context, w http.ResponseWriter, r *http.Request) {
 
 func getStatuses(c *Context, w http.ResponseWriter, r *http.Request) {
 
+	if statuses := GetStatuses(c); c.Err == nil {
+		//w.Header().Set("Cache-Control", "max-age=9, public") // 2 mins
+		w.Write([]byte(model.MapToJson(statuses)))
+	}
+}
+
+func getStatusesWebSocket(c *Context, data map[string]string) map[string]string {
+	return GetStatuses(c)
+}
+
+func GetStatuses(c *Context) map[string]string {
+
 	if result := <-Srv.Store.User().GetProfiles(c.Session.TeamId); result.Err != nil {
 		c.Err = result.Err
-		return
+		return nil
 	} else {
 
 		profiles := result.Data.(map[string]*model.User)
 
+		presences, err := store.GetStatuses(c.Session.TeamId)
+		if err != nil {
+			c.Err = err
+			return nil
+		}
+
 		statuses := map[string]string{}
 		for _, profile := range profiles {
-			if profile.IsOffline() {
-				statuses[profile.Id] = model.USER_OFFLINE
-			} else if profile.IsAway() {
-				statuses[profile.Id] = model.USER_AWAY
+			if status, ok := presences[profile.Id]; ok {
+				statuses[profile.Id] = status.Status
 			} else {
-				statuses[profile.Id] = model.USER_ONLINE
+				statuses[profile.Id] = model.USER_OFFLINE
 			}
 		}
 
-		//w.Header().Set("Cache-Control", "max-age=9, public") // 2 mins
-		w.Write([]byte(model.MapToJson(statuses)))
+		return statuses
+	}
+}
+
+func setStatus(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+
+	manual := props["status"]
+	if !model.IsManualStatusValid(manual) {
+		c.SetInvalidParam("setStatus", "status")
+		return
+	}
+
+	var until int64
+	if len(props["until"]) > 0 {
+		var err error
+		if until, err = strconv.ParseInt(props["until"], 10, 64); err != nil || until < model.GetMillis() {
+			c.SetInvalidParam("setStatus", "until")
+			return
+		}
+	}
+
+	if status, err := presence.SetManual(c.Session.TeamId, c.Session.UserId, manual, until); err != nil {
+		c.Err = err
 		return
+	} else {
+		w.Write([]byte(status.ToJson()))
 	}
 }
@@ -16,6 +16,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -525,6 +526,41 @@ func TestUserUpdate(t *testing.T) {
 	}
 }
 
+func TestUsernameTakenByGroup(t *testing.T) {
+	Setup()
+
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: model.NewId() + "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	userTeamAdmin := &model.User{TeamId: team.Id, Email: team.Email, FullName: "Corey Hulen", Password: "pwd"}
+	userTeamAdmin = Client.Must(Client.CreateUser(userTeamAdmin, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(userTeamAdmin.Id)
+
+	Client.LoginByEmail(team.Domain, userTeamAdmin.Email, "pwd")
+	Client.Must(Client.CreateGroup(&model.Group{Name: "backend", DisplayName: "Backend"}))
+
+	user := &model.User{TeamId: team.Id, Email: strings.ToLower(model.NewId()) + "corey@test.com", Username: "backend", FullName: "Corey Hulen", Password: "pwd"}
+	if _, err := Client.CreateUser(user, ""); err == nil {
+		t.Fatal("should not create a user with a group's name")
+	}
+
+	user.Username = "n" + model.NewId()
+	user = Client.Must(Client.CreateUser(user, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user.Id)
+
+	Client.LoginByEmail(team.Domain, user.Email, "pwd")
+
+	user.Username = "backend"
+	if _, err := Client.UpdateUser(user); err == nil {
+		t.Fatal("should not rename a user onto a group's name")
+	}
+
+	user.Username = "n" + model.NewId()
+	if ruser := Client.Must(Client.UpdateUser(user)).Data.(*model.User); ruser.Username != user.Username {
+		t.Fatal("should have renamed the user")
+	}
+}
+
 func TestUserUpdatePassword(t *testing.T) {
 	Setup()
 
@@ -965,3 +1001,39 @@ func TestStatuses(t *testing.T) {
 	}
 
 }
+
+func TestSetStatus(t *testing.T) {
+	Setup()
+
+	team := model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	rteam, _ := Client.CreateTeam(&team)
+
+	user := model.User{TeamId: rteam.Data.(*model.Team).Id, Email: strings.ToLower(model.NewId()) + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	ruser := Client.Must(Client.CreateUser(&user, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(ruser.Id)
+
+	Client.LoginByEmail(team.Domain, user.Email, user.Password)
+
+	status := Client.Must(Client.SetStatus(map[string]string{"status": model.USER_DND})).Data.(*model.Status)
+	if status.Manual != model.USER_DND || status.UserId != ruser.Id {
+		t.Fatal("manual status not saved")
+	}
+
+	if status.Status != model.USER_OFFLINE {
+		t.Fatal("should still be offline without a connection")
+	}
+
+	if _, err := Client.SetStatus(map[string]string{"status": model.USER_OFFLINE}); err == nil {
+		t.Fatal("should not be able to pick offline")
+	}
+
+	if _, err := Client.SetStatus(map[string]string{"status": model.USER_AWAY, "until": "1234"}); err == nil {
+		t.Fatal("should not be able to set a status that ends in the past")
+	}
+
+	until := strconv.FormatInt(model.GetMillis()+60*1000, 10)
+	status = Client.Must(Client.SetStatus(map[string]string{"status": model.USER_AWAY, "until": until})).Data.(*model.Status)
+	if status.Manual != model.USER_AWAY || strconv.FormatInt(status.ManualUntil, 10) != until {
+		t.Fatal("away until not saved")
+	}
+}
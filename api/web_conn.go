@@ -4,10 +4,13 @@
 package api
 
 import (
+	"bytes"
 	l4g "code.google.com/p/log4go"
 	"github.com/gorilla/websocket"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+	"net/http"
 	"time"
 )
 
@@ -15,33 +18,56 @@ const (
 	WRITE_WAIT  = 10 * time.Second
 	PONG_WAIT   = 60 * time.Second
 	PING_PERIOD = (PONG_WAIT * 9) / 10
-	MAX_SIZE    = 512
+	MAX_SIZE    = 16 * 1024
 	REDIS_WAIT  = 60 * time.Second
+
+	TYPING_CACHE_SIZE      = 10000
+	TYPING_INTERVAL_MILLIS = 1000
+
+	DEFAULT_SEND_BUFFER_SIZE = 64
 )
 
+var typingCache *utils.Cache = utils.NewLru(TYPING_CACHE_SIZE)
+
 type WebConn struct {
 	WebSocket          *websocket.Conn
 	Send               chan *model.Message
+	Reply              chan interface{}
 	TeamId             string
 	UserId             string
+	Session            model.Session
+	TeamUrl            string
+	IpAddress          string
 	ChannelAccessCache map[string]bool
+	CloseReason        string // set by the hub before it closes Send
 }
 
-func NewWebConn(ws *websocket.Conn, teamId string, userId string, sessionId string) *WebConn {
-	go func() {
-		achan := Srv.Store.User().UpdateUserAndSessionActivity(userId, sessionId, model.GetMillis())
-		pchan := Srv.Store.User().UpdateLastPingAt(userId, model.GetMillis())
+func NewWebConn(c *Context, ws *websocket.Conn) *WebConn {
+	userId := c.Session.UserId
+	sessionId := c.Session.Id
 
-		if result := <-achan; result.Err != nil {
+	go func() {
+		if result := <-Srv.Store.User().UpdateUserAndSessionActivity(userId, sessionId, model.GetMillis()); result.Err != nil {
 			l4g.Error("Failed to update LastActivityAt for user_id=%v and session_id=%v, err=%v", userId, sessionId, result.Err)
 		}
-
-		if result := <-pchan; result.Err != nil {
-			l4g.Error("Failed to updated LastPingAt for user_id=%v, err=%v", userId, result.Err)
-		}
 	}()
 
-	return &WebConn{Send: make(chan *model.Message, 64), WebSocket: ws, UserId: userId, TeamId: teamId, ChannelAccessCache: make(map[string]bool)}
+	size := utils.Cfg.WebSocketSettings.SendBufferSize
+	if size <= 0 {
+		size = DEFAULT_SEND_BUFFER_SIZE
+	}
+
+	return &WebConn{
+		Send:               make(chan *model.Message, size),
+		Reply:              make(chan interface{}, 16),
+		WebSocket:          ws,
+		UserId:             userId,
+		TeamId:             c.Session.TeamId,
+		Session:            c.Session,
+		TeamUrl:            c.TeamUrl,
+		IpAddress:          c.IpAddress,
+		ChannelAccessCache: make(map[string]bool),
+	}
 }
 
 func (c *WebConn) readPump() {
@@ -53,28 +79,124 @@ func (c *WebConn) readPump() {
 	c.WebSocket.SetReadDeadline(time.Now().Add(PONG_WAIT))
 	c.WebSocket.SetPongHandler(func(string) error {
 		c.WebSocket.SetReadDeadline(time.Now().Add(PONG_WAIT))
-
-		go func() {
-			if result := <-Srv.Store.User().UpdateLastPingAt(c.UserId, model.GetMillis()); result.Err != nil {
-				l4g.Error("Failed to updated LastPingAt for user_id=%v, err=%v", c.UserId, result.Err)
-			}
-		}()
-
 		return nil
 	})
 
 	for {
-		var msg model.Message
-		if err := c.WebSocket.ReadJSON(&msg); err != nil {
+		_, data, err := c.WebSocket.ReadMessage()
+		if err != nil {
 			return
+		}
+
+		presence.Activity(c.TeamId, c.UserId)
+
+		if req := model.WebSocketRequestFromJson(bytes.NewReader(data)); req != nil && req.Seq > 0 {
+			c.handleRequest(req)
+		} else if msg := model.MessageFromJson(bytes.NewReader(data)); msg != nil {
+			if err := publishClientMessage(c.TeamId, c.UserId, msg); err != nil {
+				c.rejectMessage(msg, err)
+			}
 		} else {
-			msg.TeamId = c.TeamId
-			msg.UserId = c.UserId
-			store.PublishAndForget(&msg)
+			return
 		}
 	}
 }
 
+// handleRequest runs a command sent over the websocket through the same
+// Context based checks as the REST api and replies with the result.
+func (c *WebConn) handleRequest(req *model.WebSocketRequest) {
+	ctx := &Context{
+		Session:   c.Session,
+		RequestId: model.NewId(),
+		IpAddress: c.IpAddress,
+		TeamUrl:   c.TeamUrl,
+		Path:      "/api/v1/websocket/" + req.Action,
+	}
+
+	if req.Data == nil {
+		req.Data = make(map[string]string)
+	}
+
+	var data map[string]string
+	if h, ok := webSocketHandlers[req.Action]; !ok {
+		ctx.Err = model.NewAppError("handleRequest", "Unknown websocket action", "action="+req.Action)
+		ctx.Err.StatusCode = http.StatusNotFound
+	} else if ctx.Session.IsExpired() {
+		ctx.Err = model.NewAppError("handleRequest", "Invalid or expired session, please login again.", "id="+ctx.Session.Id)
+		ctx.Err.StatusCode = http.StatusUnauthorized
+	} else {
+		data = h(ctx, req.Data)
+	}
+
+	if ctx.Err != nil {
+		ctx.Err.RequestId = ctx.RequestId
+		ctx.LogError(ctx.Err)
+		ctx.Err.Where = ctx.Path
+		data = nil
+	}
+
+	c.reply(model.NewWebSocketResponse(req.Seq, data, ctx.Err))
+}
+
+func (c *WebConn) rejectMessage(msg *model.Message, err *model.AppError) {
+	l4g.Error("Rejected websocket message from user_id=%v action=%v err=%v", c.UserId, msg.Action, err)
+
+	message := model.NewMessage(c.TeamId, "", c.UserId, model.ACTION_ERROR)
+	message.Add("action", msg.Action)
+	message.Add("error", err.Message)
+
+	c.reply(message)
+}
+
+// reply queues a frame meant only for this connection. It gives up after
+// WRITE_WAIT so a dead writer can never wedge the reader.
+func (c *WebConn) reply(frame interface{}) {
+	select {
+	case c.Reply <- frame:
+	case <-time.After(WRITE_WAIT):
+		l4g.Error("Dropped websocket reply for user_id=%v", c.UserId)
+	}
+}
+
+// publishClientMessage validates an event a client wants to send to the rest
+// of the team and republishes a clean copy of it.
+func publishClientMessage(teamId string, userId string, msg *model.Message) *model.AppError {
+	if err := msg.IsValidFromClient(); err != nil {
+		return err
+	}
+
+	if msg.Action == model.ACTION_TYPING && !allowTyping(userId) {
+		return nil
+	}
+
+	if len(msg.ChannelId) > 0 && !hasPermissionsToChannel(Srv.Store.Channel().CheckPermissionsTo(teamId, msg.ChannelId, userId)) {
+		err := model.NewAppError("publishClientMessage", "You do not have the appropriate permissions", "channel_id="+msg.ChannelId)
+		err.StatusCode = http.StatusForbidden
+		return err
+	}
+
+	// Only republish what was validated, never the client's own copy
+	message := model.NewMessage(teamId, msg.ChannelId, userId, msg.Action)
+	for key, value := range msg.Props {
+		message.Add(key, value)
+	}
+
+	store.PublishAndForget(message)
+
+	return nil
+}
+
+func allowTyping(userId string) bool {
+	now := model.GetMillis()
+
+	if last, ok := typingCache.Get(userId); ok && now-last.(int64) < TYPING_INTERVAL_MILLIS {
+		return false
+	}
+
+	typingCache.Add(userId, now)
+	return true
+}
+
 func (c *WebConn) writePump() {
 	ticker := time.NewTicker(PING_PERIOD)
 
@@ -88,30 +210,23 @@ func (c *WebConn) writePump() {
 		case msg, ok := <-c.Send:
 			if !ok {
 				c.WebSocket.SetWriteDeadline(time.Now().Add(WRITE_WAIT))
-				c.WebSocket.WriteMessage(websocket.CloseMessage, []byte{})
+				c.WebSocket.WriteMessage(websocket.CloseMessage, c.closeMessage())
 				return
 			}
 
-			if len(msg.ChannelId) > 0 {
-				allowed, ok := c.ChannelAccessCache[msg.ChannelId]
-				if !ok {
-					allowed = hasPermissionsToChannel(Srv.Store.Channel().CheckPermissionsTo(c.TeamId, msg.ChannelId, c.UserId))
-					c.ChannelAccessCache[msg.ChannelId] = allowed
-				}
-
-				if allowed {
-					c.WebSocket.SetWriteDeadline(time.Now().Add(WRITE_WAIT))
-					if err := c.WebSocket.WriteJSON(msg); err != nil {
-						return
-					}
-				}
-			} else {
+			if c.isAllowed(msg) {
 				c.WebSocket.SetWriteDeadline(time.Now().Add(WRITE_WAIT))
 				if err := c.WebSocket.WriteJSON(msg); err != nil {
 					return
 				}
 			}
 
+		case msg := <-c.Reply:
+			c.WebSocket.SetWriteDeadline(time.Now().Add(WRITE_WAIT))
+			if err := c.WebSocket.WriteJSON(msg); err != nil {
+				return
+			}
+
 		case <-ticker.C:
 			c.WebSocket.SetWriteDeadline(time.Now().Add(WRITE_WAIT))
 			if err := c.WebSocket.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
@@ -121,6 +236,57 @@ func (c *WebConn) writePump() {
 	}
 }
 
+func (c *WebConn) closeMessage() []byte {
+	if len(c.CloseReason) == 0 {
+		return websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	}
+
+	return websocket.FormatCloseMessage(websocket.ClosePolicyViolation, c.CloseReason)
+}
+
+// isAllowed reports whether this connection's user may see msg. It must only
+// be called from the single goroutine delivering to the connection since
+// ChannelAccessCache is not locked.
+func (c *WebConn) isAllowed(msg *model.Message) bool {
+	// Ephemeral posts are addressed to one user who may have just lost access
+	// to the channel, e.g. because it was archived
+	if msg.Action == model.ACTION_EPHEMERAL_POST {
+		return msg.UserId == c.UserId
+	}
+
+	// Unread counts are only the business of the user's own sessions
+	if msg.Action == model.ACTION_CHANNEL_UNREAD && msg.UserId != c.UserId {
+		return false
+	}
+
+	// Drafts go to the user's other sessions, never to anyone else
+	if msg.Action == model.ACTION_DRAFT_UPDATED || msg.Action == model.ACTION_DRAFT_DELETED {
+		return msg.UserId == c.UserId && msg.Props["session_alt_id"] != c.Session.AltId
+	}
+
+	if len(msg.ChannelId) == 0 {
+		return true
+	}
+
+	// Membership changed so whatever we remember about this channel is stale
+	if msg.Action == model.ACTION_USER_ADDED || msg.Action == model.ACTION_USER_REMOVED {
+		delete(c.ChannelAccessCache, msg.ChannelId)
+	}
+
+	allowed, ok := c.ChannelAccessCache[msg.ChannelId]
+	if !ok {
+		allowed = hasPermissionsToChannel(Srv.Store.Channel().CheckPermissionsTo(c.TeamId, msg.ChannelId, c.UserId))
+		c.ChannelAccessCache[msg.ChannelId] = allowed
+	}
+
+	// The removed user no longer has access but still needs to be told about it
+	if msg.Action == model.ACTION_USER_REMOVED && msg.UserId == c.UserId {
+		return true
+	}
+
+	return allowed
+}
+
 func hasPermissionsToChannel(sc store.StoreChannel) bool {
 	if cresult := <-sc; cresult.Err != nil {
 		return false
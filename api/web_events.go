@@ -0,0 +1,235 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	l4g "code.google.com/p/log4go"
+	"fmt"
+	"github.com/mattermost/platform/model"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Fallbacks for clients that can't open a websocket. Both register a WebConn
+// without a socket with the hub and deliver its Send channel over plain http.
+
+const (
+	LONG_POLL_WAIT = 30 * time.Second
+)
+
+func eventStream(c *Context, w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		c.Err = model.NewAppError("eventStream", "Streaming is not supported", "")
+		c.Err.StatusCode = http.StatusNotImplemented
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	wc := NewWebConn(c, nil)
+	hub.Register(wc)
+	defer hub.Unregister(wc)
+
+	ticker := time.NewTicker(PING_PERIOD)
+	defer ticker.Stop()
+
+	gone := w.(http.CloseNotifier).CloseNotify()
+
+	for {
+		select {
+		case msg, ok := <-wc.Send:
+			if !ok {
+				fmt.Fprintf(w, "event: close\ndata: %v\n\n", closeReason(wc))
+				flusher.Flush()
+				return
+			}
+
+			if wc.isAllowed(msg) {
+				if _, err := fmt.Fprintf(w, "data: %v\n\n", msg.ToJson()); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+
+		case <-ticker.C:
+			// A comment line keeps proxies from timing out an idle stream
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case <-gone:
+			return
+		}
+	}
+}
+
+// closeReason is why the hub closed the connection, defaulting to
+// CLOSE_REASON_CLOSED when it didn't give one.
+func closeReason(wc *WebConn) string {
+	if len(wc.CloseReason) > 0 {
+		return wc.CloseReason
+	}
+
+	return CLOSE_REASON_CLOSED
+}
+
+type longPoll struct {
+	id         string
+	sessionId  string
+	conn       *WebConn
+	lastPollAt int64
+	lock       sync.Mutex
+	polling    sync.Mutex // only one request at a time may drain conn
+}
+
+// LongPolls holds the connections of long polling clients between polls so
+// nothing published in the meantime is lost.
+type LongPolls struct {
+	lock  sync.Mutex
+	polls map[string]*longPoll
+}
+
+var longPolls = &LongPolls{polls: make(map[string]*longPoll)}
+
+// Start drops any poller that hasn't come back within PONG_WAIT, the same
+// amount of time a websocket gets to answer a ping.
+func (lp *LongPolls) Start() {
+	go func() {
+		ticker := time.NewTicker(PONG_WAIT)
+
+		for {
+			<-ticker.C
+
+			expired := []*longPoll{}
+			cutoff := model.GetMillis() - int64(PONG_WAIT/time.Millisecond)
+
+			lp.lock.Lock()
+			for id, p := range lp.polls {
+				if p.lastPolledAt() < cutoff {
+					delete(lp.polls, id)
+					expired = append(expired, p)
+				}
+			}
+			lp.lock.Unlock()
+
+			for _, p := range expired {
+				l4g.Debug("long poll expired for user_id=%v", p.conn.UserId)
+				hub.Unregister(p.conn)
+			}
+		}
+	}()
+}
+
+// Get returns the poller for pollId, registering a new one when the id is
+// unknown or belongs to another session.
+func (lp *LongPolls) Get(c *Context, pollId string) *longPoll {
+	lp.lock.Lock()
+	p, ok := lp.polls[pollId]
+	lp.lock.Unlock()
+
+	if ok && p.sessionId == c.Session.Id {
+		return p
+	}
+
+	p = &longPoll{id: model.NewId(), sessionId: c.Session.Id, conn: NewWebConn(c, nil), lastPollAt: model.GetMillis()}
+	hub.Register(p.conn)
+
+	lp.lock.Lock()
+	lp.polls[p.id] = p
+	lp.lock.Unlock()
+
+	return p
+}
+
+func (lp *LongPolls) Remove(p *longPoll) {
+	lp.lock.Lock()
+	_, ok := lp.polls[p.id]
+	delete(lp.polls, p.id)
+	lp.lock.Unlock()
+
+	if ok {
+		hub.Unregister(p.conn)
+	}
+}
+
+func (p *longPoll) touch() {
+	p.lock.Lock()
+	p.lastPollAt = model.GetMillis()
+	p.lock.Unlock()
+}
+
+func (p *longPoll) lastPolledAt() int64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.lastPollAt
+}
+
+func pollEvents(c *Context, w http.ResponseWriter, r *http.Request) {
+	p := longPolls.Get(c, r.URL.Query().Get("poll_id"))
+
+	p.polling.Lock()
+	defer p.polling.Unlock()
+
+	p.touch()
+	defer p.touch()
+
+	result := &model.MessagePoll{PollId: p.id, Messages: []*model.Message{}}
+	closed := false
+
+	timer := time.NewTimer(LONG_POLL_WAIT)
+	defer timer.Stop()
+
+	gone := w.(http.CloseNotifier).CloseNotify()
+
+	// Wait for the first message the user is allowed to see
+	for waiting := true; waiting && len(result.Messages) == 0; {
+		select {
+		case msg, ok := <-p.conn.Send:
+			if !ok {
+				closed = true
+				waiting = false
+			} else if p.conn.isAllowed(msg) {
+				result.Messages = append(result.Messages, msg)
+			}
+
+		case <-timer.C:
+			waiting = false
+
+		case <-gone:
+			return
+		}
+	}
+
+	// Then take whatever else is already queued without waiting
+	for draining := !closed; draining; {
+		select {
+		case msg, ok := <-p.conn.Send:
+			if !ok {
+				closed = true
+				draining = false
+			} else if p.conn.isAllowed(msg) {
+				result.Messages = append(result.Messages, msg)
+			}
+
+		default:
+			draining = false
+		}
+	}
+
+	if closed {
+		result.CloseReason = closeReason(p.conn)
+		longPolls.Remove(p)
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(result.ToJson()))
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"bufio"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupEventsTest() (*model.Team, *model.User, *model.User, *model.Channel, *model.Channel) {
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "Test Events 1", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+	Client.Must(Client.AddChannelMember(channel1.Id, user2.Id))
+
+	channel2 := &model.Channel{DisplayName: "Test Events 2", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_PRIVATE, TeamId: team.Id}
+	channel2 = Client.Must(Client.CreateChannel(channel2)).Data.(*model.Channel)
+
+	return team, user1, user2, channel1, channel2
+}
+
+func TestPollEvents(t *testing.T) {
+	Setup()
+
+	team, user1, user2, channel1, channel2 := setupEventsTest()
+
+	pollClient := model.NewClient(Client.Url)
+	pollClient.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	// The first poll registers user2 and waits for something to happen
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}))
+	}()
+
+	poll := pollClient.Must(pollClient.PollEvents("")).Data.(*model.MessagePoll)
+	if len(poll.PollId) != 26 {
+		t.Fatal("should have been given a poll id")
+	}
+
+	// Events published between polls are queued for the next one, and a post
+	// to a channel user2 isn't in must be filtered out
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel2.Id, Message: "a" + model.NewId() + "a"}))
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}))
+	time.Sleep(500 * time.Millisecond)
+
+	found := false
+	for i := 0; i < 5 && !found; i++ {
+		next := pollClient.Must(pollClient.PollEvents(poll.PollId)).Data.(*model.MessagePoll)
+		if next.PollId != poll.PollId {
+			t.Fatal("poll id should not have changed")
+		}
+
+		for _, msg := range next.Messages {
+			if msg.ChannelId == channel2.Id {
+				t.Fatal("should not receive events for a channel user2 isn't a member of")
+			}
+
+			if msg.Action == model.ACTION_POSTED && msg.ChannelId == channel1.Id && msg.UserId == user1.Id {
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		t.Fatal("should have received the post")
+	}
+
+	// Somebody else's poll id is not honoured
+	other := Client.Must(Client.PollEvents(poll.PollId)).Data.(*model.MessagePoll)
+	if other.PollId == poll.PollId {
+		t.Fatal("should not be able to take over another session's poll")
+	}
+
+	// Closing the session's connections tells the poller why
+	hub.CloseSessions(team.Id, user2.Id, "", CLOSE_REASON_SESSION_REVOKED)
+	time.Sleep(100 * time.Millisecond)
+
+	if closed := pollClient.Must(pollClient.PollEvents(poll.PollId)).Data.(*model.MessagePoll); closed.CloseReason != CLOSE_REASON_SESSION_REVOKED {
+		t.Fatal("should have been told the poller was closed", closed.CloseReason)
+	}
+
+	hub.Stop(team.Id)
+}
+
+func TestEventStream(t *testing.T) {
+	Setup()
+
+	team, user1, user2, channel1, _ := setupEventsTest()
+
+	streamClient := model.NewClient(Client.Url)
+	streamClient.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	rq, _ := http.NewRequest("GET", "http://localhost:"+utils.Cfg.ServiceSettings.Port+"/api/v1/events/stream", nil)
+	rq.Header.Set(model.HEADER_AUTH, "BEARER "+streamClient.AuthToken)
+
+	rp, err := http.DefaultClient.Do(rq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Body.Close()
+
+	if rp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatal("bad content type " + rp.Header.Get("Content-Type"))
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}))
+
+	reader := bufio.NewReader(rp.Body)
+	received := make(chan bool)
+	go func() {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				received <- false
+				return
+			}
+
+			if strings.HasPrefix(line, "data: ") {
+				msg := model.MessageFromJson(strings.NewReader(strings.TrimPrefix(line, "data: ")))
+				if msg != nil && msg.Action == model.ACTION_POSTED && msg.ChannelId == channel1.Id && msg.UserId == user1.Id {
+					received <- true
+					return
+				}
+			}
+		}
+	}()
+
+	select {
+	case ok := <-received:
+		if !ok {
+			t.Fatal("stream ended before the post arrived")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("should have received the post")
+	}
+
+	// Closing the session's connections ends the stream with the reason
+	hub.CloseSessions(team.Id, user2.Id, "", CLOSE_REASON_SESSION_REVOKED)
+
+	closed := make(chan string)
+	go func() {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				closed <- ""
+				return
+			}
+
+			if line == "event: close\n" {
+				data, _ := reader.ReadString('\n')
+				closed <- strings.TrimSpace(strings.TrimPrefix(data, "data: "))
+				return
+			}
+		}
+	}()
+
+	select {
+	case reason := <-closed:
+		if reason != CLOSE_REASON_SESSION_REVOKED {
+			t.Fatal("should have been told why the stream closed", reason)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("should have closed the stream")
+	}
+
+	hub.Stop(team.Id)
+}
@@ -11,22 +11,39 @@ type Hub struct {
 	teamHubs   map[string]*TeamHub
 	register   chan *WebConn
 	unregister chan *WebConn
+	invalidate chan *sessionInvalidation
 	stop       chan string
 }
 
+type sessionInvalidation struct {
+	teamId string
+	userId string
+	altId  string
+	reason string
+}
+
 var hub = &Hub{
 	register:   make(chan *WebConn),
 	unregister: make(chan *WebConn),
+	invalidate: make(chan *sessionInvalidation),
 	teamHubs:   make(map[string]*TeamHub),
 	stop:       make(chan string),
 }
 
 func (h *Hub) Register(webConn *WebConn) {
 	h.register <- webConn
+	presence.Connected(webConn.TeamId, webConn.UserId)
 }
 
 func (h *Hub) Unregister(webConn *WebConn) {
 	h.unregister <- webConn
+	presence.Disconnected(webConn.TeamId, webConn.UserId)
+}
+
+// CloseSessions closes this server's connections for the user's session with
+// altId, or for all of the user's sessions when altId is empty.
+func (h *Hub) CloseSessions(teamId string, userId string, altId string, reason string) {
+	h.invalidate <- &sessionInvalidation{teamId: teamId, userId: userId, altId: altId, reason: reason}
 }
 
 func (h *Hub) Stop(teamId string) {
@@ -54,6 +71,11 @@ func (h *Hub) Start() {
 					nh.Unregister(c)
 				}
 
+			case inv := <-h.invalidate:
+				if nh, ok := h.teamHubs[inv.teamId]; ok {
+					nh.invalidate <- inv
+				}
+
 			case s := <-h.stop:
 				if len(s) == 0 {
 					l4g.Debug("stopping all connections")
@@ -5,16 +5,45 @@ package api
 
 import (
 	l4g "code.google.com/p/log4go"
+	"encoding/json"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/mattermost/platform/model"
 	"net/http"
 )
 
+// WebSocketHandler serves a command sent over an open websocket. Like the
+// http handlers it reports failure by setting c.Err.
+type WebSocketHandler func(c *Context, data map[string]string) map[string]string
+
+var webSocketHandlers = make(map[string]WebSocketHandler)
+
+func HandleWebSocket(action string, h WebSocketHandler) {
+	webSocketHandlers[action] = h
+}
+
 func InitWebSocket(r *mux.Router) {
 	l4g.Debug("Initializing web socket api routes")
 	r.Handle("/websocket", ApiUserRequired(connect)).Methods("GET")
+	// Polling or holding a stream open isn't the user doing anything
+	r.Handle("/events/stream", ApiUserRequiredActivity(eventStream, false)).Methods("GET")
+	r.Handle("/events/poll", ApiUserRequiredActivity(pollEvents, false)).Methods("GET")
+	r.Handle("/websocket/metrics", ApiAdminSystemRequired(getWebSocketMetrics)).Methods("GET")
+
+	HandleWebSocket(model.WEBSOCKET_TYPING, userTyping)
+
 	hub.Start()
+	presence.Start()
+	StartClusterListener()
+	longPolls.Start()
+}
+
+func getWebSocketMetrics(c *Context, w http.ResponseWriter, r *http.Request) {
+	if b, err := json.Marshal(GetAllTeamHubMetrics()); err != nil {
+		c.Err = model.NewAppError("getWebSocketMetrics", "Unable to encode the metrics", err.Error())
+	} else {
+		w.Write(b)
+	}
 }
 
 func connect(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -33,8 +62,20 @@ func connect(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	wc := NewWebConn(ws, c.Session.TeamId, c.Session.UserId, c.Session.Id)
+	wc := NewWebConn(c, ws)
 	hub.Register(wc)
 	go wc.writePump()
 	wc.readPump()
 }
+
+func userTyping(c *Context, data map[string]string) map[string]string {
+	msg := model.NewMessage(c.Session.TeamId, data["channel_id"], c.Session.UserId, model.ACTION_TYPING)
+	msg.Add("parent_id", data["parent_id"])
+
+	if err := publishClientMessage(c.Session.TeamId, c.Session.UserId, msg); err != nil {
+		c.Err = err
+		return nil
+	}
+
+	return nil
+}
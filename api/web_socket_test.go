@@ -8,6 +8,7 @@ import (
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 )
@@ -54,16 +55,19 @@ func TestSocket(t *testing.T) {
 	time.Sleep(300 * time.Millisecond)
 	Client.Must(Client.JoinChannel(channel1.Id))
 
-	// Read the join channel message that gets generated
+	// Read the membership change and join channel messages that get generated
 	var rmsg model.Message
 	if err := c2.ReadJSON(&rmsg); err != nil {
 		t.Fatal(err)
 	}
 
+	if err := c2.ReadJSON(&rmsg); err != nil {
+		t.Fatal(err)
+	}
+
 	// Test sending message without a channelId
 	m := model.NewMessage("", "", "", model.ACTION_TYPING)
-	m.Add("RootId", model.NewId())
-	m.Add("ParentId", model.NewId())
+	m.Add("parent_id", model.NewId())
 
 	c1.WriteJSON(m)
 
@@ -75,14 +79,16 @@ func TestSocket(t *testing.T) {
 		t.Fatal("Ids do not match")
 	}
 
-	if m.Props["RootId"] != rmsg.Props["RootId"] {
+	if m.Props["parent_id"] != rmsg.Props["parent_id"] {
 		t.Fatal("Ids do not match")
 	}
 
+	// Typing is rate limited per user
+	time.Sleep(TYPING_INTERVAL_MILLIS * time.Millisecond)
+
 	// Test sending messsage to Channel you have access to
 	m = model.NewMessage("", channel1.Id, "", model.ACTION_TYPING)
-	m.Add("RootId", model.NewId())
-	m.Add("ParentId", model.NewId())
+	m.Add("parent_id", model.NewId())
 
 	c1.WriteJSON(m)
 
@@ -94,14 +100,15 @@ func TestSocket(t *testing.T) {
 		t.Fatal("Ids do not match")
 	}
 
-	if m.Props["RootId"] != rmsg.Props["RootId"] {
+	if m.Props["parent_id"] != rmsg.Props["parent_id"] {
 		t.Fatal("Ids do not match")
 	}
 
+	time.Sleep(TYPING_INTERVAL_MILLIS * time.Millisecond)
+
 	// Test sending message to Channel you *do not* have access too
 	m = model.NewMessage("", channel2.Id, "", model.ACTION_TYPING)
-	m.Add("RootId", model.NewId())
-	m.Add("ParentId", model.NewId())
+	m.Add("parent_id", model.NewId())
 
 	c1.WriteJSON(m)
 
@@ -119,6 +126,485 @@ func TestSocket(t *testing.T) {
 
 }
 
+func TestSocketClientActions(t *testing.T) {
+	Setup()
+
+	url := "ws://localhost:" + utils.Cfg.ServiceSettings.Port + "/api/v1/websocket"
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	header1 := http.Header{}
+	header1.Set(model.HEADER_AUTH, "BEARER "+Client.AuthToken)
+
+	c1, _, err := websocket.DefaultDialer.Dial(url, header1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	header2 := http.Header{}
+	header2.Set(model.HEADER_AUTH, "BEARER "+Client.AuthToken)
+
+	c2, _, err := websocket.DefaultDialer.Dial(url, header2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+
+	time.Sleep(300 * time.Millisecond)
+
+	// A forged post must be rejected back to the sender and never reach anyone else
+	m := model.NewMessage("", "", "", model.ACTION_POSTED)
+	m.Add("post", "{}")
+	c1.WriteJSON(m)
+
+	var rmsg model.Message
+	c1.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := c1.ReadJSON(&rmsg); err != nil {
+		t.Fatal(err)
+	}
+
+	if rmsg.Action != model.ACTION_ERROR || rmsg.Props["action"] != model.ACTION_POSTED {
+		t.Fatal("should have received an error frame")
+	}
+
+	// Unknown props are rejected too
+	m = model.NewMessage("", "", "", model.ACTION_TYPING)
+	m.Add("post", "{}")
+	c1.WriteJSON(m)
+
+	c1.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := c1.ReadJSON(&rmsg); err != nil {
+		t.Fatal(err)
+	}
+
+	if rmsg.Action != model.ACTION_ERROR || rmsg.Props["action"] != model.ACTION_TYPING {
+		t.Fatal("should have received an error frame")
+	}
+
+	// Only the first of two quick typing events gets through
+	m = model.NewMessage("", "", "", model.ACTION_TYPING)
+	m.Add("parent_id", "")
+	c1.WriteJSON(m)
+	c1.WriteJSON(m)
+
+	c2.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := c2.ReadJSON(&rmsg); err != nil {
+		t.Fatal(err)
+	}
+
+	if rmsg.Action != model.ACTION_TYPING || rmsg.UserId != user1.Id {
+		t.Fatal("should have received the typing event")
+	}
+
+	c2.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := c2.ReadJSON(&rmsg); err == nil {
+		t.Fatal("should not have received a second message, got " + rmsg.Action)
+	}
+
+	hub.Stop(team.Id)
+}
+
+func TestSocketMembershipChange(t *testing.T) {
+	Setup()
+
+	url := "ws://localhost:" + utils.Cfg.ServiceSettings.Port + "/api/v1/websocket"
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	header2 := http.Header{}
+	header2.Set(model.HEADER_AUTH, "BEARER "+Client.AuthToken)
+
+	c2, _, err := websocket.DefaultDialer.Dial(url, header2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "Test Web Socket 1", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_PRIVATE, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	time.Sleep(300 * time.Millisecond)
+
+	// readUntil reads frames from c2 until one matches or the deadline passes
+	readUntil := func(action string, channelId string) bool {
+		c2.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			var rmsg model.Message
+			if err := c2.ReadJSON(&rmsg); err != nil {
+				return false
+			}
+
+			if rmsg.Action == action && rmsg.ChannelId == channelId {
+				return true
+			}
+		}
+	}
+
+	Client.Must(Client.AddChannelMember(channel1.Id, user2.Id))
+
+	if !readUntil(model.ACTION_USER_ADDED, channel1.Id) {
+		t.Fatal("added member should have been told about the membership change")
+	}
+
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}))
+
+	if !readUntil(model.ACTION_POSTED, channel1.Id) {
+		t.Fatal("member should have received the post")
+	}
+
+	Client.Must(Client.RemoveChannelMember(channel1.Id, user2.Id))
+
+	if !readUntil(model.ACTION_USER_REMOVED, channel1.Id) {
+		t.Fatal("removed member should have been told about the membership change")
+	}
+
+	Client.Must(Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}))
+
+	if readUntil(model.ACTION_POSTED, channel1.Id) {
+		t.Fatal("removed member should not receive posts for the channel")
+	}
+
+	hub.Stop(team.Id)
+}
+
+func TestSocketRequests(t *testing.T) {
+	Setup()
+
+	url := "ws://localhost:" + utils.Cfg.ServiceSettings.Port + "/api/v1/websocket"
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	channel2 := &model.Channel{DisplayName: "Test Web Socket 2", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_PRIVATE, TeamId: team.Id}
+	channel2 = Client.Must(Client.CreateChannel(channel2)).Data.(*model.Channel)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "Test Web Socket 1", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	header1 := http.Header{}
+	header1.Set(model.HEADER_AUTH, "BEARER "+Client.AuthToken)
+
+	c1, _, err := websocket.DefaultDialer.Dial(url, header1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+
+	// send writes a request and skips any broadcast events until its response shows up
+	send := func(req *model.WebSocketRequest) *model.WebSocketResponse {
+		if err := c1.WriteJSON(req); err != nil {
+			t.Fatal(err)
+		}
+
+		c1.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var resp model.WebSocketResponse
+			if err := c1.ReadJSON(&resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.SeqReply == req.Seq {
+				return &resp
+			}
+		}
+	}
+
+	post := &model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}
+	resp := send(&model.WebSocketRequest{Seq: 1, Action: model.WEBSOCKET_CREATE_POST, Data: map[string]string{"post": post.ToJson()}})
+	if resp.Status != model.STATUS_OK {
+		t.Fatal(resp.Error)
+	}
+
+	if rpost := model.PostFromJson(strings.NewReader(resp.Data["post"])); rpost == nil || rpost.Message != post.Message || rpost.UserId != user1.Id {
+		t.Fatal("post did not round trip")
+	}
+
+	post = &model.Post{ChannelId: channel2.Id, Message: "a" + model.NewId() + "a"}
+	resp = send(&model.WebSocketRequest{Seq: 2, Action: model.WEBSOCKET_CREATE_POST, Data: map[string]string{"post": post.ToJson()}})
+	if resp.Status != model.STATUS_FAIL || resp.Error == nil || resp.Error.StatusCode != http.StatusForbidden {
+		t.Fatal("should not be able to post to a channel you are not a member of")
+	}
+
+	resp = send(&model.WebSocketRequest{Seq: 3, Action: model.WEBSOCKET_UPDATE_LAST_VIEWED, Data: map[string]string{"channel_id": channel1.Id}})
+	if resp.Status != model.STATUS_OK || resp.Data["id"] != channel1.Id {
+		t.Fatal("should have updated last viewed")
+	}
+
+	resp = send(&model.WebSocketRequest{Seq: 4, Action: model.WEBSOCKET_UPDATE_LAST_VIEWED, Data: map[string]string{"channel_id": "junk"}})
+	if resp.Status != model.STATUS_FAIL || resp.Error.StatusCode != http.StatusBadRequest {
+		t.Fatal("should have failed on a bad channel id")
+	}
+
+	resp = send(&model.WebSocketRequest{Seq: 5, Action: model.WEBSOCKET_GET_STATUSES})
+	if resp.Status != model.STATUS_OK || len(resp.Data[user1.Id]) == 0 {
+		t.Fatal("should have returned statuses")
+	}
+
+	resp = send(&model.WebSocketRequest{Seq: 6, Action: model.WEBSOCKET_TYPING, Data: map[string]string{"channel_id": channel1.Id, "parent_id": ""}})
+	if resp.Status != model.STATUS_OK {
+		t.Fatal(resp.Error)
+	}
+
+	resp = send(&model.WebSocketRequest{Seq: 7, Action: "junk"})
+	if resp.Status != model.STATUS_FAIL || resp.Error.StatusCode != http.StatusNotFound {
+		t.Fatal("should have failed on an unknown action")
+	}
+
+	hub.Stop(team.Id)
+}
+
+func TestSocketStatusChange(t *testing.T) {
+	Setup()
+
+	url := "ws://localhost:" + utils.Cfg.ServiceSettings.Port + "/api/v1/websocket"
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+
+	header2 := http.Header{}
+	header2.Set(model.HEADER_AUTH, "BEARER "+Client.AuthToken)
+
+	c2, _, err := websocket.DefaultDialer.Dial(url, header2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	header1 := http.Header{}
+	header1.Set(model.HEADER_AUTH, "BEARER "+Client.AuthToken)
+
+	// readStatus waits for a status change for user1 on c2
+	readStatus := func() string {
+		c2.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			var rmsg model.Message
+			if err := c2.ReadJSON(&rmsg); err != nil {
+				return ""
+			}
+
+			if rmsg.Action == model.ACTION_STATUS_CHANGE && rmsg.UserId == user1.Id {
+				return rmsg.Props["status"]
+			}
+		}
+	}
+
+	c1, _, err := websocket.DefaultDialer.Dial(url, header1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status := readStatus(); status != model.USER_ONLINE {
+		t.Fatal("should have gone online, got " + status)
+	}
+
+	if statuses := Client.Must(Client.GetStatuses()).Data.(map[string]string); statuses[user1.Id] != model.USER_ONLINE {
+		t.Fatal("should be online")
+	}
+
+	Client.Must(Client.SetStatus(map[string]string{"status": model.USER_DND}))
+
+	if status := readStatus(); status != model.USER_DND {
+		t.Fatal("should have gone dnd, got " + status)
+	}
+
+	c1.Close()
+
+	if status := readStatus(); status != model.USER_OFFLINE {
+		t.Fatal("should have gone offline, got " + status)
+	}
+
+	if statuses := Client.Must(Client.GetStatuses()).Data.(map[string]string); statuses[user1.Id] != model.USER_OFFLINE {
+		t.Fatal("should be offline")
+	}
+
+	hub.Stop(team.Id)
+}
+
+func TestSocketSessionRevoked(t *testing.T) {
+	Setup()
+
+	url := "ws://localhost:" + utils.Cfg.ServiceSettings.Port + "/api/v1/websocket"
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	header := http.Header{}
+	header.Set(model.HEADER_AUTH, "BEARER "+Client.AuthToken)
+
+	c1, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+
+	time.Sleep(300 * time.Millisecond)
+	Client.Must(Client.Logout())
+
+	c1.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var rmsg model.Message
+		if err := c1.ReadJSON(&rmsg); err != nil {
+			if !strings.Contains(err.Error(), CLOSE_REASON_SESSION_REVOKED) {
+				t.Fatal("should have been closed because the session was revoked, got " + err.Error())
+			}
+			break
+		}
+	}
+
+	hub.Stop(team.Id)
+}
+
+func TestSocketEphemeralPost(t *testing.T) {
+	Setup()
+
+	url := "ws://localhost:" + utils.Cfg.ServiceSettings.Port + "/api/v1/websocket"
+	team := &model.Team{Name: "Name", Domain: "z-z-" + model.NewId() + "a", Email: "test@nowhere.com", Type: model.TEAM_OPEN}
+	team = Client.Must(Client.CreateTeam(team)).Data.(*model.Team)
+
+	user1 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user1 = Client.Must(Client.CreateUser(user1, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user1.Id)
+
+	user2 := &model.User{TeamId: team.Id, Email: model.NewId() + "corey@test.com", FullName: "Corey Hulen", Password: "pwd"}
+	user2 = Client.Must(Client.CreateUser(user2, "")).Data.(*model.User)
+	Srv.Store.User().VerifyEmail(user2.Id)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+
+	channel1 := &model.Channel{DisplayName: "Test Web Scoket 1", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel1 = Client.Must(Client.CreateChannel(channel1)).Data.(*model.Channel)
+
+	channel2 := &model.Channel{DisplayName: "Test Web Scoket 2", Name: "a" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id}
+	channel2 = Client.Must(Client.CreateChannel(channel2)).Data.(*model.Channel)
+
+	header1 := http.Header{}
+	header1.Set(model.HEADER_AUTH, "BEARER "+Client.AuthToken)
+
+	Client.LoginByEmail(team.Domain, user2.Email, "pwd")
+	Client.Must(Client.JoinChannel(channel1.Id))
+	Client.Must(Client.JoinChannel(channel2.Id))
+
+	header2 := http.Header{}
+	header2.Set(model.HEADER_AUTH, "BEARER "+Client.AuthToken)
+
+	c1, _, err := websocket.DefaultDialer.Dial(url, header1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+
+	c2, _, err := websocket.DefaultDialer.Dial(url, header2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+
+	time.Sleep(300 * time.Millisecond)
+
+	Client.LoginByEmail(team.Domain, user1.Email, "pwd")
+	Client.Must(Client.DeleteChannel(channel1.Id))
+
+	if _, err := Client.CreatePost(&model.Post{ChannelId: channel1.Id, Message: "a" + model.NewId() + "a"}); err == nil {
+		t.Fatal("should have failed to post in an archived channel")
+	}
+
+	c1.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var rmsg model.Message
+		if err := c1.ReadJSON(&rmsg); err != nil {
+			t.Fatal("should have received the ephemeral post, got " + err.Error())
+		}
+
+		if rmsg.Action != model.ACTION_EPHEMERAL_POST {
+			continue
+		}
+
+		post := model.PostFromJson(strings.NewReader(rmsg.Props["post"]))
+		if post.Type != model.POST_EPHEMERAL || post.ChannelId != channel1.Id || post.UserId != user1.Id {
+			t.Fatal("ephemeral post didn't match")
+		}
+
+		if rposts := Client.Must(Client.GetPosts(channel1.Id, 0, 20, "")).Data.(*model.PostList); rposts.Posts[post.Id] != nil {
+			t.Fatal("ephemeral post should not have been saved")
+		}
+		break
+	}
+
+	// a post in a shared channel acts as a barrier, anything user2 reads before it must not be ephemeral
+	barrier := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel2.Id, Message: "a" + model.NewId() + "a"})).Data.(*model.Post)
+
+	c2.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var rmsg model.Message
+		if err := c2.ReadJSON(&rmsg); err != nil {
+			t.Fatal("should have received the barrier post, got " + err.Error())
+		}
+
+		if rmsg.Action == model.ACTION_EPHEMERAL_POST {
+			t.Fatal("should not have received another user's ephemeral post")
+		}
+
+		if rmsg.Action == model.ACTION_POSTED && rmsg.ChannelId == channel2.Id {
+			post := model.PostFromJson(strings.NewReader(rmsg.Props["post"]))
+			if post.Id == barrier.Id {
+				break
+			}
+		}
+	}
+
+	hub.Stop(team.Id)
+}
+
 func TestZZWebSocketTearDown(t *testing.T) {
 	// *IMPORTANT* - Kind of hacky
 	// This should be the last function in any test file
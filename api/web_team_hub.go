@@ -7,26 +7,100 @@ import (
 	l4g "code.google.com/p/log4go"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+	"io"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+const (
+	DEFAULT_BROADCAST_BUFFER_SIZE = 1024
+
+	REDIS_RECONNECT_MIN = 500 * time.Millisecond
+	REDIS_RECONNECT_MAX = 30 * time.Second
+
+	CLOSE_REASON_SLOW_CONSUMER = "slow consumer"
+	CLOSE_REASON_CLOSED        = "closed"
+)
+
+// TeamHubMetrics counts what a team's hubs had to give up on. The counters
+// outlive any single TeamHub so they keep adding up as hubs come and go.
+type TeamHubMetrics struct {
+	DroppedMessages    int64 `json:"dropped_messages"`
+	SlowDisconnects    int64 `json:"slow_disconnects"`
+	Resubscribes       int64 `json:"resubscribes"`
+	SubscriptionLostAt int64 `json:"subscription_lost_at"` // 0 while subscribed
+}
+
+var teamHubMetrics = struct {
+	sync.Mutex
+	teams map[string]*TeamHubMetrics
+}{teams: make(map[string]*TeamHubMetrics)}
+
+func GetTeamHubMetrics(teamId string) *TeamHubMetrics {
+	teamHubMetrics.Lock()
+	defer teamHubMetrics.Unlock()
+
+	metrics, ok := teamHubMetrics.teams[teamId]
+	if !ok {
+		metrics = &TeamHubMetrics{}
+		teamHubMetrics.teams[teamId] = metrics
+	}
+
+	return metrics
+}
+
+// GetAllTeamHubMetrics returns a snapshot of the counters of every team.
+func GetAllTeamHubMetrics() map[string]TeamHubMetrics {
+	teamHubMetrics.Lock()
+	defer teamHubMetrics.Unlock()
+
+	all := make(map[string]TeamHubMetrics, len(teamHubMetrics.teams))
+	for teamId, metrics := range teamHubMetrics.teams {
+		all[teamId] = TeamHubMetrics{
+			DroppedMessages:    atomic.LoadInt64(&metrics.DroppedMessages),
+			SlowDisconnects:    atomic.LoadInt64(&metrics.SlowDisconnects),
+			Resubscribes:       atomic.LoadInt64(&metrics.Resubscribes),
+			SubscriptionLostAt: atomic.LoadInt64(&metrics.SubscriptionLostAt),
+		}
+	}
+
+	return all
+}
+
 type TeamHub struct {
 	connections map[*WebConn]bool
 	broadcast   chan *model.Message
 	register    chan *WebConn
 	unregister  chan *WebConn
+	invalidate  chan *sessionInvalidation
 	stop        chan bool
 	teamId      string
+	metrics     *TeamHubMetrics
+	done        chan bool
+
+	pubsubLock sync.Mutex
+	pubsub     io.Closer
 }
 
 func NewTeamHub(teamId string) *TeamHub {
+	size := utils.Cfg.WebSocketSettings.BroadcastBufferSize
+	if size <= 0 {
+		size = DEFAULT_BROADCAST_BUFFER_SIZE
+	}
+
 	return &TeamHub{
-		broadcast:   make(chan *model.Message),
+		broadcast:   make(chan *model.Message, size),
 		register:    make(chan *WebConn),
 		unregister:  make(chan *WebConn),
+		invalidate:  make(chan *sessionInvalidation),
 		connections: make(map[*WebConn]bool),
 		stop:        make(chan bool),
+		done:        make(chan bool),
 		teamId:      teamId,
+		metrics:     GetTeamHubMetrics(teamId),
 	}
 }
 
@@ -43,42 +117,7 @@ func (h *TeamHub) Stop() {
 }
 
 func (h *TeamHub) Start() {
-
-	pubsub := store.RedisClient().PubSub()
-
-	go func() {
-		defer func() {
-			l4g.Debug("redis reader finished for teamId=%v", h.teamId)
-			hub.Stop(h.teamId)
-		}()
-
-		l4g.Debug("redis reader starting for teamId=%v", h.teamId)
-
-		err := pubsub.Subscribe(h.teamId)
-		if err != nil {
-			l4g.Error("Error while subscribing to redis %v %v", h.teamId, err)
-			return
-		}
-
-		for {
-			if payload, err := pubsub.ReceiveTimeout(REDIS_WAIT); err != nil {
-				if strings.Contains(err.Error(), "i/o timeout") {
-					if len(h.connections) == 0 {
-						l4g.Debug("No active connections so sending stop %v", h.teamId)
-						return
-					}
-				} else {
-					return
-				}
-			} else {
-				msg := store.GetMessageFromPayload(payload)
-				if msg != nil {
-					h.broadcast <- msg
-				}
-			}
-		}
-
-	}()
+	go h.readRedis()
 
 	go func() {
 		for {
@@ -90,15 +129,18 @@ func (h *TeamHub) Start() {
 					delete(h.connections, webCon)
 					close(webCon.Send)
 				}
+			case inv := <-h.invalidate:
+				for webCon := range h.connections {
+					if matchesSession(&webCon.Session, inv.userId, inv.altId) {
+						webCon.CloseReason = inv.reason
+						delete(h.connections, webCon)
+						close(webCon.Send)
+					}
+				}
 			case msg := <-h.broadcast:
 				for webCon := range h.connections {
 					if !(webCon.UserId == msg.UserId && msg.Action == model.ACTION_TYPING) {
-						select {
-						case webCon.Send <- msg:
-						default:
-							close(webCon.Send)
-							delete(h.connections, webCon)
-						}
+						h.deliver(webCon, msg)
 					}
 				}
 			case s := <-h.stop:
@@ -106,14 +148,247 @@ func (h *TeamHub) Start() {
 
 					l4g.Debug("team hub stopping for teamId=%v", h.teamId)
 
+					// Closing Send ends every kind of connection, whether it is a
+					// websocket, an event stream or a long poll
 					for webCon := range h.connections {
-						webCon.WebSocket.Close()
+						delete(h.connections, webCon)
+						close(webCon.Send)
 					}
 
-					pubsub.Close()
+					dropped := atomic.LoadInt64(&h.metrics.DroppedMessages)
+					disconnects := atomic.LoadInt64(&h.metrics.SlowDisconnects)
+					if dropped > 0 || disconnects > 0 {
+						l4g.Info("team hub for teamId=%v has dropped %v messages and disconnected %v slow connections", h.teamId, dropped, disconnects)
+					}
+
+					close(h.done)
+					h.setPubSub(nil)
 					return
 				}
 			}
 		}
 	}()
 }
+
+// readRedis feeds the team's redis channel into the hub. When the
+// subscription breaks it keeps trying to get it back, with an increasing
+// delay, while the connections stay open. Once it is back the clients are
+// told to resync since anything published in between is lost.
+func (h *TeamHub) readRedis() {
+	defer func() {
+		l4g.Debug("redis reader finished for teamId=%v", h.teamId)
+		hub.Stop(h.teamId)
+	}()
+
+	l4g.Debug("redis reader starting for teamId=%v", h.teamId)
+
+	delay := time.Duration(0)
+
+	for {
+		pubsub, err := store.Subscribe(h.teamId, REDIS_WAIT)
+		if err != nil {
+			h.subscriptionLost(err.Error())
+
+			if len(h.connections) == 0 {
+				l4g.Debug("No active connections so giving up on redis %v", h.teamId)
+				return
+			}
+
+			delay = nextRedisReconnectDelay(delay)
+			l4g.Info("retrying redis subscription for teamId=%v in %v", h.teamId, delay)
+
+			select {
+			case <-time.After(delay):
+				continue
+			case <-h.done:
+				return
+			}
+		}
+
+		if !h.setPubSub(pubsub) {
+			return
+		}
+
+		if lostAt := atomic.SwapInt64(&h.metrics.SubscriptionLostAt, 0); lostAt != 0 {
+			l4g.Info("redis subscription restored for teamId=%v", h.teamId)
+			atomic.AddInt64(&h.metrics.Resubscribes, 1)
+
+			if !h.queue(model.NewMessage(h.teamId, "", "", model.ACTION_RESYNC)) {
+				return
+			}
+		}
+
+		delay = 0
+
+		for {
+			if payload, err := pubsub.ReceiveTimeout(REDIS_WAIT); err != nil {
+				if strings.Contains(err.Error(), "i/o timeout") {
+					if len(h.connections) == 0 {
+						l4g.Debug("No active connections so sending stop %v", h.teamId)
+						return
+					}
+				} else {
+					select {
+					case <-h.done:
+						return
+					default:
+					}
+
+					h.subscriptionLost(err.Error())
+					break
+				}
+			} else {
+				msg := store.GetMessageFromPayload(payload)
+				if msg != nil && !h.queue(msg) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// queue hands msg to the broadcast loop unless the hub has stopped.
+func (h *TeamHub) queue(msg *model.Message) bool {
+	select {
+	case h.broadcast <- msg:
+		return true
+	case <-h.done:
+		return false
+	}
+}
+
+// setPubSub swaps in the connection the hub closes when it stops. It
+// returns false, closing pubsub, if the hub has already stopped.
+func (h *TeamHub) setPubSub(pubsub io.Closer) bool {
+	h.pubsubLock.Lock()
+	defer h.pubsubLock.Unlock()
+
+	if h.pubsub != nil {
+		h.pubsub.Close()
+		h.pubsub = nil
+	}
+
+	select {
+	case <-h.done:
+		if pubsub != nil {
+			pubsub.Close()
+		}
+		return false
+	default:
+		h.pubsub = pubsub
+		return true
+	}
+}
+
+func (h *TeamHub) subscriptionLost(reason string) {
+	if atomic.CompareAndSwapInt64(&h.metrics.SubscriptionLostAt, 0, model.GetMillis()) {
+		l4g.Error("Lost redis subscription for teamId=%v err=%v", h.teamId, reason)
+	}
+}
+
+func nextRedisReconnectDelay(delay time.Duration) time.Duration {
+	if delay < REDIS_RECONNECT_MIN {
+		return REDIS_RECONNECT_MIN
+	}
+
+	if delay *= 2; delay > REDIS_RECONNECT_MAX {
+		return REDIS_RECONNECT_MAX
+	}
+
+	return delay
+}
+
+// deliver queues msg for webCon without ever blocking the rest of the team.
+// When the connection has fallen behind far enough to fill its buffer, stale
+// low value messages are thrown away to make room, oldest first. A connection
+// that still can't keep up is disconnected and told why, so it knows to
+// reconnect and reload rather than silently miss messages.
+func (h *TeamHub) deliver(webCon *WebConn, msg *model.Message) {
+	select {
+	case webCon.Send <- msg:
+		return
+	default:
+	}
+
+	queued, dropped := compactMessages(drainMessages(webCon.Send), msg, cap(webCon.Send))
+
+	slow := len(queued) > cap(webCon.Send)
+	if slow {
+		dropped += len(queued) - cap(webCon.Send)
+		queued = queued[:cap(webCon.Send)]
+	}
+
+	// The hub is the only sender so this can't block. A reader that takes a
+	// message while the queue is being compacted can see it ahead of older
+	// ones, which is better than the disconnect it would otherwise get.
+	for _, m := range queued {
+		webCon.Send <- m
+	}
+
+	atomic.AddInt64(&h.metrics.DroppedMessages, int64(dropped))
+
+	if slow {
+		l4g.Info("disconnecting slow connection for user_id=%v team_id=%v", webCon.UserId, h.teamId)
+		atomic.AddInt64(&h.metrics.SlowDisconnects, 1)
+
+		webCon.CloseReason = CLOSE_REASON_SLOW_CONSUMER
+		delete(h.connections, webCon)
+		close(webCon.Send)
+	}
+}
+
+func drainMessages(send chan *model.Message) []*model.Message {
+	messages := make([]*model.Message, 0, cap(send))
+	for {
+		select {
+		case msg := <-send:
+			messages = append(messages, msg)
+		default:
+			return messages
+		}
+	}
+}
+
+// compactMessages appends msg to queued and makes it fit in size by dropping
+// low value messages. Ones superseded by a later message with the same key go
+// first, then the oldest. It returns what's left, which is still over size if
+// there weren't enough to drop, and how many were dropped.
+func compactMessages(queued []*model.Message, msg *model.Message, size int) ([]*model.Message, int) {
+	queued = append(queued, msg)
+
+	latest := make(map[string]int)
+	for i, m := range queued {
+		if isLowValueMessage(m) {
+			latest[lowValueKey(m)] = i
+		}
+	}
+
+	compacted := make([]*model.Message, 0, len(queued))
+	for i, m := range queued {
+		if !isLowValueMessage(m) || latest[lowValueKey(m)] == i {
+			compacted = append(compacted, m)
+		}
+	}
+
+	for i := 0; len(compacted) > size && i < len(compacted); {
+		if isLowValueMessage(compacted[i]) {
+			compacted = append(compacted[:i], compacted[i+1:]...)
+		} else {
+			i++
+		}
+	}
+
+	return compacted, len(queued) - len(compacted)
+}
+
+// lowValueKey identifies the messages that supersede each other, someone
+// typing in a channel only needs to be told once.
+func lowValueKey(msg *model.Message) string {
+	return msg.Action + ":" + msg.UserId + ":" + msg.ChannelId
+}
+
+// isLowValueMessage reports whether msg is only a hint that is superseded
+// soon anyway, like someone typing.
+func isLowValueMessage(msg *model.Message) bool {
+	return msg.Action == model.ACTION_TYPING
+}
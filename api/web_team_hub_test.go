@@ -0,0 +1,117 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api
+
+import (
+	"github.com/mattermost/platform/model"
+	"testing"
+)
+
+func TestTeamHubDeliver(t *testing.T) {
+	teamId := model.NewId()
+	h := NewTeamHub(teamId)
+
+	webCon := &WebConn{Send: make(chan *model.Message, 4), UserId: model.NewId(), TeamId: teamId}
+	h.connections[webCon] = true
+
+	channelId := model.NewId()
+	userId1 := model.NewId()
+	userId2 := model.NewId()
+
+	posted1 := model.NewMessage(teamId, channelId, userId1, model.ACTION_POSTED)
+	posted2 := model.NewMessage(teamId, channelId, userId1, model.ACTION_POSTED)
+	posted3 := model.NewMessage(teamId, channelId, userId1, model.ACTION_POSTED)
+	posted4 := model.NewMessage(teamId, channelId, userId1, model.ACTION_POSTED)
+	posted5 := model.NewMessage(teamId, channelId, userId1, model.ACTION_POSTED)
+	oldTyping := model.NewMessage(teamId, channelId, userId1, model.ACTION_TYPING)
+	otherTyping := model.NewMessage(teamId, channelId, userId2, model.ACTION_TYPING)
+	newTyping := model.NewMessage(teamId, channelId, userId1, model.ACTION_TYPING)
+
+	queued := func() []*model.Message {
+		messages := drainMessages(webCon.Send)
+		for _, m := range messages {
+			webCon.Send <- m
+		}
+		return messages
+	}
+
+	checkQueued := func(expected ...*model.Message) {
+		messages := queued()
+		if len(messages) != len(expected) {
+			t.Fatalf("expected %v queued messages, got %v", len(expected), len(messages))
+		}
+
+		for i := range expected {
+			if messages[i] != expected[i] {
+				t.Fatalf("wrong message queued at %v: %v", i, messages[i].Action)
+			}
+		}
+	}
+
+	h.deliver(webCon, posted1)
+	h.deliver(webCon, oldTyping)
+	h.deliver(webCon, otherTyping)
+	h.deliver(webCon, posted2)
+	checkQueued(posted1, oldTyping, otherTyping, posted2)
+
+	// A full buffer drops the typing event the new one supersedes
+	h.deliver(webCon, newTyping)
+	checkQueued(posted1, otherTyping, posted2, newTyping)
+
+	// Then the oldest typing events make room for posts
+	h.deliver(webCon, posted3)
+	checkQueued(posted1, posted2, newTyping, posted3)
+
+	h.deliver(webCon, posted4)
+	checkQueued(posted1, posted2, posted3, posted4)
+
+	if metrics := GetAllTeamHubMetrics()[teamId]; metrics.DroppedMessages != 3 || metrics.SlowDisconnects != 0 {
+		t.Fatal("should have counted three dropped messages")
+	}
+
+	// With nothing left to drop the connection is closed with a reason, the
+	// messages already queued can still be read
+	h.deliver(webCon, posted5)
+
+	if _, ok := h.connections[webCon]; ok {
+		t.Fatal("should have removed the connection")
+	}
+
+	if webCon.CloseReason != CLOSE_REASON_SLOW_CONSUMER {
+		t.Fatal("should have set the close reason")
+	}
+
+	for _, expected := range []*model.Message{posted1, posted2, posted3, posted4} {
+		if msg := <-webCon.Send; msg != expected {
+			t.Fatal("should have kept the queued posts in order")
+		}
+	}
+
+	if _, ok := <-webCon.Send; ok {
+		t.Fatal("should have closed the send channel")
+	}
+
+	if metrics := GetAllTeamHubMetrics()[teamId]; metrics.DroppedMessages != 4 || metrics.SlowDisconnects != 1 {
+		t.Fatal("should have counted the disconnect")
+	}
+}
+
+func TestNextRedisReconnectDelay(t *testing.T) {
+	delay := nextRedisReconnectDelay(0)
+	if delay != REDIS_RECONNECT_MIN {
+		t.Fatal("should start at the minimum delay")
+	}
+
+	if delay = nextRedisReconnectDelay(delay); delay != 2*REDIS_RECONNECT_MIN {
+		t.Fatal("should double the delay")
+	}
+
+	for i := 0; i < 20; i++ {
+		delay = nextRedisReconnectDelay(delay)
+	}
+
+	if delay != REDIS_RECONNECT_MAX {
+		t.Fatal("should not go past the maximum delay")
+	}
+}
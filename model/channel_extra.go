@@ -26,8 +26,9 @@ func (o *ExtraMember) Sanitize(options map[string]bool) {
 }
 
 type ChannelExtra struct {
-	Id      string        `json:"id"`
-	Members []ExtraMember `json:"members"`
+	Id              string        `json:"id"`
+	Members         []ExtraMember `json:"members"`
+	PinnedPostCount int64         `json:"pinned_post_count"`
 }
 
 func (o *ChannelExtra) ToJson() string {
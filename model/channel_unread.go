@@ -0,0 +1,56 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ChannelUnread is how much of a channel a user hasn't read yet.
+type ChannelUnread struct {
+	ChannelId    string `json:"channel_id"`
+	MsgCount     int64  `json:"msg_count"`
+	MentionCount int64  `json:"mention_count"`
+	LastViewedAt int64  `json:"last_viewed_at"`
+}
+
+func (o *ChannelUnread) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ChannelUnreadFromJson(data io.Reader) *ChannelUnread {
+	decoder := json.NewDecoder(data)
+	var o ChannelUnread
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func ChannelUnreadsToJson(o []*ChannelUnread) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func ChannelUnreadsFromJson(data io.Reader) []*ChannelUnread {
+	decoder := json.NewDecoder(data)
+	var o []*ChannelUnread
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
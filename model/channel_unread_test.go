@@ -0,0 +1,24 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChannelUnreadJson(t *testing.T) {
+	o := ChannelUnread{ChannelId: NewId(), MsgCount: 3, MentionCount: 1, LastViewedAt: GetMillis()}
+	json := o.ToJson()
+	ro := ChannelUnreadFromJson(strings.NewReader(json))
+
+	if o != *ro {
+		t.Fatal("unreads do not match")
+	}
+
+	list := ChannelUnreadsFromJson(strings.NewReader(ChannelUnreadsToJson([]*ChannelUnread{&o})))
+	if len(list) != 1 || *list[0] != o {
+		t.Fatal("unread lists do not match")
+	}
+}
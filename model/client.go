@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -317,6 +318,8 @@ func (c *Client) Command(channelId string, command string, suggest bool) (*Resul
 	m["command"] = command
 	m["channelId"] = channelId
 	m["suggest"] = strconv.FormatBool(suggest)
+	_, offset := time.Now().Zone()
+	m["utcOffset"] = strconv.Itoa(offset / 60)
 	if r, err := c.DoPost("/command", MapToJson(m)); err != nil {
 		return nil, err
 	} else {
@@ -455,6 +458,124 @@ func (c *Client) UpdateLastViewedAt(channelId string) (*Result, *AppError) {
 	}
 }
 
+func (c *Client) GetGroups() (*Result, *AppError) {
+	if r, err := c.DoGet("/groups/", "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), GroupsFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) CreateGroup(group *Group) (*Result, *AppError) {
+	if r, err := c.DoPost("/groups/create", group.ToJson()); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), GroupFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) UpdateGroup(group *Group) (*Result, *AppError) {
+	if r, err := c.DoPost("/groups/update", group.ToJson()); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), GroupFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) DeleteGroup(id string) (*Result, *AppError) {
+	if r, err := c.DoPost("/groups/"+id+"/delete", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) GetGroupMembers(id string) (*Result, *AppError) {
+	if r, err := c.DoGet("/groups/"+id+"/members", "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ArrayFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) AddGroupMember(id string, userId string) (*Result, *AppError) {
+	data := make(map[string]string)
+	data["user_id"] = userId
+	if r, err := c.DoPost("/groups/"+id+"/add", MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) RemoveGroupMember(id string, userId string) (*Result, *AppError) {
+	data := make(map[string]string)
+	data["user_id"] = userId
+	if r, err := c.DoPost("/groups/"+id+"/remove", MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) GetDrafts() (*Result, *AppError) {
+	if r, err := c.DoGet("/drafts/", "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), DraftsFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) UpsertDraft(draft *Draft) (*Result, *AppError) {
+	if r, err := c.DoPost("/drafts/upsert", draft.ToJson()); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), DraftFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) DeleteDraft(channelId string, rootId string) (*Result, *AppError) {
+	data := make(map[string]string)
+	data["channel_id"] = channelId
+	data["root_id"] = rootId
+	if r, err := c.DoPost("/drafts/delete", MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) GetChannelUnreads() (*Result, *AppError) {
+	if r, err := c.DoGet("/channels/unreads", "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ChannelUnreadsFromJson(r.Body)}, nil
+	}
+}
+
+// MarkChannelUnread makes postId and everything after it in the channel unread.
+func (c *Client) MarkChannelUnread(channelId string, postId string) (*Result, *AppError) {
+	data := make(map[string]string)
+	data["post_id"] = postId
+	if r, err := c.DoPost("/channels/"+channelId+"/mark_unread", MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ChannelUnreadFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) GetChannelExtraInfo(id string) (*Result, *AppError) {
 	if r, err := c.DoGet("/channels/"+id+"/extra_info", "", ""); err != nil {
 		return nil, err
@@ -518,6 +639,159 @@ func (c *Client) DeletePost(channelId string, postId string) (*Result, *AppError
 	}
 }
 
+func (c *Client) ListReactions(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoGet(fmt.Sprintf("/channels/%v/post/%v/reactions", channelId, postId), "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ReactionsFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) AddReaction(channelId string, reaction *Reaction) (*Result, *AppError) {
+	if r, err := c.DoPost(fmt.Sprintf("/channels/%v/post/%v/reactions/add", channelId, reaction.PostId), reaction.ToJson()); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ReactionFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) RemoveReaction(channelId string, reaction *Reaction) (*Result, *AppError) {
+	if r, err := c.DoPost(fmt.Sprintf("/channels/%v/post/%v/reactions/remove", channelId, reaction.PostId), reaction.ToJson()); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ReactionFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) PinPost(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoPost(fmt.Sprintf("/channels/%v/post/%v/pin", channelId, postId), ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) UnpinPost(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoPost(fmt.Sprintf("/channels/%v/post/%v/unpin", channelId, postId), ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) GetPinnedPosts(channelId string) (*Result, *AppError) {
+	if r, err := c.DoGet(fmt.Sprintf("/channels/%v/pinned", channelId), "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) SavePost(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoPost(fmt.Sprintf("/channels/%v/post/%v/save", channelId, postId), ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), SavedPostFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) UnsavePost(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoPost(fmt.Sprintf("/channels/%v/post/%v/unsave", channelId, postId), ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) GetSavedPosts(offset int, limit int) (*Result, *AppError) {
+	if r, err := c.DoGet(fmt.Sprintf("/posts/saved/%v/%v", offset, limit), "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) GetMentions(offset int, limit int) (*Result, *AppError) {
+	if r, err := c.DoGet(fmt.Sprintf("/posts/mentions/%v/%v", offset, limit), "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) GetThread(channelId string, postId string, offset int, limit int) (*Result, *AppError) {
+	if r, err := c.DoGet(fmt.Sprintf("/channels/%v/post/%v/thread/%v/%v", channelId, postId, offset, limit), "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), PostListFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) FollowThread(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoPost(fmt.Sprintf("/channels/%v/post/%v/follow", channelId, postId), ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ThreadFollowerFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) UnfollowThread(channelId string, postId string) (*Result, *AppError) {
+	if r, err := c.DoPost(fmt.Sprintf("/channels/%v/post/%v/unfollow", channelId, postId), ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ThreadFollowerFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) CreateScheduledPost(scheduled *ScheduledPost) (*Result, *AppError) {
+	if r, err := c.DoPost("/scheduled_posts/create", scheduled.ToJson()); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ScheduledPostFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) GetScheduledPosts() (*Result, *AppError) {
+	if r, err := c.DoGet("/scheduled_posts/", "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ScheduledPostsFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) UpdateScheduledPost(scheduled *ScheduledPost) (*Result, *AppError) {
+	if r, err := c.DoPost("/scheduled_posts/update", scheduled.ToJson()); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), ScheduledPostFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) CancelScheduledPost(id string) (*Result, *AppError) {
+	if r, err := c.DoPost("/scheduled_posts/"+id+"/cancel", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MapFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) SearchPosts(terms string) (*Result, *AppError) {
 	if r, err := c.DoGet("/posts/search?terms="+url.QueryEscape(terms), "", ""); err != nil {
 		return nil, err
@@ -656,6 +930,24 @@ func (c *Client) GetStatuses() (*Result, *AppError) {
 	}
 }
 
+func (c *Client) SetStatus(data map[string]string) (*Result, *AppError) {
+	if r, err := c.DoPost("/users/status/set", MapToJson(data)); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), StatusFromJson(r.Body)}, nil
+	}
+}
+
+func (c *Client) PollEvents(pollId string) (*Result, *AppError) {
+	if r, err := c.DoGet("/events/poll?poll_id="+url.QueryEscape(pollId), "", ""); err != nil {
+		return nil, err
+	} else {
+		return &Result{r.Header.Get(HEADER_REQUEST_ID),
+			r.Header.Get(HEADER_ETAG_SERVER), MessagePollFromJson(r.Body)}, nil
+	}
+}
+
 func (c *Client) GetMyTeam(etag string) (*Result, *AppError) {
 	if r, err := c.DoGet("/teams/me", "", etag); err != nil {
 		return nil, err
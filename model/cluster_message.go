@@ -0,0 +1,48 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	CLUSTER_EVENT_INVALIDATE_SESSIONS = "invalidate_sessions"
+)
+
+// ClusterMessage tells every app server about something that changed
+// underneath the caches they keep for themselves.
+type ClusterMessage struct {
+	Event string            `json:"event"`
+	Props map[string]string `json:"props"`
+}
+
+func NewClusterMessage(event string) *ClusterMessage {
+	return &ClusterMessage{Event: event, Props: make(map[string]string)}
+}
+
+func (o *ClusterMessage) Add(key string, value string) {
+	o.Props[key] = value
+}
+
+func (o *ClusterMessage) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ClusterMessageFromJson(data io.Reader) *ClusterMessage {
+	decoder := json.NewDecoder(data)
+	var o ClusterMessage
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterMessageJson(t *testing.T) {
+	m := NewClusterMessage(CLUSTER_EVENT_INVALIDATE_SESSIONS)
+	m.Add("user_id", NewId())
+	json := m.ToJson()
+	result := ClusterMessageFromJson(strings.NewReader(json))
+
+	if m.Event != result.Event {
+		t.Fatal("events do not match")
+	}
+
+	if m.Props["user_id"] != result.Props["user_id"] {
+		t.Fatal("props do not match")
+	}
+}
@@ -6,6 +6,7 @@ package model
 import (
 	"encoding/json"
 	"io"
+	"time"
 )
 
 const (
@@ -19,6 +20,7 @@ type Command struct {
 	ChannelId    string            `json:"channel_id"`
 	Suggest      bool              `json:"-"`
 	Suggestions  []*SuggestCommand `json:"suggestions"`
+	Location     *time.Location    `json:"-"` // the user's time zone, nil if their client didn't send it
 }
 
 func (o *Command) AddSuggestion(suggest *SuggestCommand) {
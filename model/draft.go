@@ -0,0 +1,105 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Draft is an unsent message, there's at most one per user, channel and
+// thread. RootId is empty for a draft in the channel itself.
+type Draft struct {
+	UserId    string      `json:"user_id"`
+	ChannelId string      `json:"channel_id"`
+	RootId    string      `json:"root_id"`
+	Message   string      `json:"message"`
+	Filenames StringArray `json:"filenames"`
+	CreateAt  int64       `json:"create_at"`
+	UpdateAt  int64       `json:"update_at"`
+}
+
+func (o *Draft) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func DraftFromJson(data io.Reader) *Draft {
+	decoder := json.NewDecoder(data)
+	var o Draft
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func DraftsToJson(o []*Draft) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func DraftsFromJson(data io.Reader) []*Draft {
+	decoder := json.NewDecoder(data)
+	var o []*Draft
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
+
+func (o *Draft) IsValid() *AppError {
+
+	if len(o.UserId) != 26 {
+		return NewAppError("Draft.IsValid", "Invalid user id", "")
+	}
+
+	if len(o.ChannelId) != 26 {
+		return NewAppError("Draft.IsValid", "Invalid channel id", "")
+	}
+
+	if !(len(o.RootId) == 26 || len(o.RootId) == 0) {
+		return NewAppError("Draft.IsValid", "Invalid root id", "")
+	}
+
+	if len(o.Message) > 4000 {
+		return NewAppError("Draft.IsValid", "Invalid message", "")
+	}
+
+	if len(ArrayToJson(o.Filenames)) > 4000 {
+		return NewAppError("Draft.IsValid", "Invalid filenames", "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("Draft.IsValid", "Create at must be a valid time", "")
+	}
+
+	if o.UpdateAt == 0 {
+		return NewAppError("Draft.IsValid", "Update at must be a valid time", "")
+	}
+
+	return nil
+}
+
+func (o *Draft) PreSave() {
+	o.UpdateAt = GetMillis()
+
+	if o.CreateAt == 0 {
+		o.CreateAt = o.UpdateAt
+	}
+
+	if o.Filenames == nil {
+		o.Filenames = []string{}
+	}
+}
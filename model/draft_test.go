@@ -0,0 +1,59 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDraftJson(t *testing.T) {
+	o := Draft{UserId: NewId(), ChannelId: NewId(), Message: "draft", Filenames: []string{"/a.png"}}
+	o.PreSave()
+	json := o.ToJson()
+	ro := DraftFromJson(strings.NewReader(json))
+
+	if o.ChannelId != ro.ChannelId || o.Message != ro.Message || len(ro.Filenames) != 1 || o.UpdateAt != ro.UpdateAt {
+		t.Fatal("drafts do not match")
+	}
+
+	drafts := DraftsFromJson(strings.NewReader(DraftsToJson([]*Draft{&o})))
+	if len(drafts) != 1 || drafts[0].UserId != o.UserId {
+		t.Fatal("draft lists do not match")
+	}
+}
+
+func TestDraftIsValid(t *testing.T) {
+	o := Draft{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ChannelId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PreSave()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.RootId = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.RootId = NewId()
+	o.Message = strings.Repeat("0", 4001)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
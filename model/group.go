@@ -0,0 +1,150 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+)
+
+// Group is a named set of users on a team that can be mentioned all at
+// once with @name.
+type Group struct {
+	Id          string `json:"id"`
+	CreateAt    int64  `json:"create_at"`
+	UpdateAt    int64  `json:"update_at"`
+	TeamId      string `json:"team_id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Description string `json:"description"`
+}
+
+type GroupMember struct {
+	GroupId  string `json:"group_id"`
+	UserId   string `json:"user_id"`
+	CreateAt int64  `json:"create_at"`
+}
+
+// Group names can't contain the characters a message is split on, or the
+// mention would never be found
+var validGroupName = regexp.MustCompile(`^[a-z0-9][a-z0-9\-_]+$`)
+
+func (o *Group) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func GroupFromJson(data io.Reader) *Group {
+	decoder := json.NewDecoder(data)
+	var o Group
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func GroupsToJson(o []*Group) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func GroupsFromJson(data io.Reader) []*Group {
+	decoder := json.NewDecoder(data)
+	var o []*Group
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
+
+func (o *Group) IsValid() *AppError {
+
+	if len(o.Id) != 26 {
+		return NewAppError("Group.IsValid", "Invalid Id", "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("Group.IsValid", "Create at must be a valid time", "id="+o.Id)
+	}
+
+	if o.UpdateAt == 0 {
+		return NewAppError("Group.IsValid", "Update at must be a valid time", "id="+o.Id)
+	}
+
+	if len(o.TeamId) != 26 {
+		return NewAppError("Group.IsValid", "Invalid team id", "id="+o.Id)
+	}
+
+	if len(o.Name) > 64 || !IsValidGroupName(o.Name) {
+		return NewAppError("Group.IsValid", "Name must be 2 or more lowercase alphanumeric characters, dashes or underscores", "id="+o.Id)
+	}
+
+	if len(o.DisplayName) > 64 {
+		return NewAppError("Group.IsValid", "Invalid display name", "id="+o.Id)
+	}
+
+	if len(o.Description) > 1024 {
+		return NewAppError("Group.IsValid", "Invalid description", "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *Group) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	o.CreateAt = GetMillis()
+	o.UpdateAt = o.CreateAt
+}
+
+func (o *Group) PreUpdate() {
+	o.UpdateAt = GetMillis()
+}
+
+// IsValidGroupName checks the name is usable as an @mention, the names
+// reserved for channel wide mentions are refused.
+func IsValidGroupName(name string) bool {
+	if !validGroupName.MatchString(name) {
+		return false
+	}
+
+	return IsUsernameValid(name)
+}
+
+func (o *GroupMember) IsValid() *AppError {
+
+	if len(o.GroupId) != 26 {
+		return NewAppError("GroupMember.IsValid", "Invalid group id", "")
+	}
+
+	if len(o.UserId) != 26 {
+		return NewAppError("GroupMember.IsValid", "Invalid user id", "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("GroupMember.IsValid", "Create at must be a valid time", "")
+	}
+
+	return nil
+}
+
+func (o *GroupMember) PreSave() {
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGroupJson(t *testing.T) {
+	o := Group{Id: NewId(), Name: "backend", DisplayName: "Backend"}
+	json := o.ToJson()
+	ro := GroupFromJson(strings.NewReader(json))
+
+	if o.Id != ro.Id || o.Name != ro.Name {
+		t.Fatal("groups do not match")
+	}
+
+	groups := GroupsFromJson(strings.NewReader(GroupsToJson([]*Group{&o})))
+	if len(groups) != 1 || groups[0].Id != o.Id {
+		t.Fatal("group lists do not match")
+	}
+}
+
+func TestGroupIsValid(t *testing.T) {
+	o := Group{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PreSave()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.TeamId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Name = "on-call_2"
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a", "Backend", "back.end", "back end", "-backend", "all", "channel", "here", strings.Repeat("a", 65)} {
+		o.Name = name
+		if err := o.IsValid(); err == nil {
+			t.Fatal("should be invalid " + name)
+		}
+	}
+
+	o.Name = "backend"
+	o.Description = strings.Repeat("0", 1025)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestGroupMemberIsValid(t *testing.T) {
+	o := GroupMember{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.GroupId = NewId()
+	o.UserId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PreSave()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+const (
+	POST_PROP_LINK_PREVIEW = "link_preview"
+
+	// Set to "false" in a user's props to stop their links being previewed
+	USER_PROP_LINK_PREVIEWS = "link_previews"
+
+	LINK_PREVIEW_TITLE_MAX_LENGTH       = 300
+	LINK_PREVIEW_DESCRIPTION_MAX_LENGTH = 500
+	LINK_PREVIEW_SITE_NAME_MAX_LENGTH   = 100
+	LINK_PREVIEW_URL_MAX_LENGTH         = 1000
+)
+
+// LinkPreview is the OpenGraph or oEmbed metadata of the first link in a post.
+type LinkPreview struct {
+	Url         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	ImageUrl    string `json:"image_url"`
+	SiteName    string `json:"site_name"`
+}
+
+func (o *LinkPreview) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func LinkPreviewFromJson(data io.Reader) *LinkPreview {
+	decoder := json.NewDecoder(data)
+	var o LinkPreview
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+// IsEmpty is true when the page had nothing worth showing.
+func (o *LinkPreview) IsEmpty() bool {
+	return len(o.Title) == 0 && len(o.Description) == 0 && len(o.ImageUrl) == 0
+}
+
+// Truncate trims the fields down so a preview always fits in the post's props.
+func (o *LinkPreview) Truncate() {
+	o.Title = truncateRunes(strings.TrimSpace(o.Title), LINK_PREVIEW_TITLE_MAX_LENGTH)
+	o.Description = truncateRunes(strings.TrimSpace(o.Description), LINK_PREVIEW_DESCRIPTION_MAX_LENGTH)
+	o.SiteName = truncateRunes(strings.TrimSpace(o.SiteName), LINK_PREVIEW_SITE_NAME_MAX_LENGTH)
+
+	if len(o.ImageUrl) > LINK_PREVIEW_URL_MAX_LENGTH {
+		o.ImageUrl = ""
+	}
+}
+
+// LinkPreview is the preview attached to the post, nil if it doesn't have one.
+func (o *Post) LinkPreview() *LinkPreview {
+	if preview, ok := o.Props[POST_PROP_LINK_PREVIEW]; ok {
+		return LinkPreviewFromJson(strings.NewReader(preview))
+	}
+
+	return nil
+}
+
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+
+	return string(runes[:max])
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinkPreviewJson(t *testing.T) {
+	o := LinkPreview{Url: "http://example.com", Title: NewId()}
+	json := o.ToJson()
+	ro := LinkPreviewFromJson(strings.NewReader(json))
+
+	if o.Title != ro.Title {
+		t.Fatal("Titles do not match")
+	}
+}
+
+func TestLinkPreviewTruncate(t *testing.T) {
+	o := LinkPreview{Title: strings.Repeat("é", LINK_PREVIEW_TITLE_MAX_LENGTH+1), ImageUrl: "http://example.com/" + strings.Repeat("a", LINK_PREVIEW_URL_MAX_LENGTH)}
+	o.Truncate()
+
+	if len([]rune(o.Title)) != LINK_PREVIEW_TITLE_MAX_LENGTH {
+		t.Fatal("should have truncated the title")
+	}
+
+	if o.ImageUrl != "" {
+		t.Fatal("should have dropped the long image url")
+	}
+
+	if o.IsEmpty() {
+		t.Fatal("still has a title")
+	}
+}
+
+func TestPostLinkPreview(t *testing.T) {
+	o := Post{}
+	if o.LinkPreview() != nil {
+		t.Fatal("should not have a preview")
+	}
+
+	o.AddProp(POST_PROP_LINK_PREVIEW, (&LinkPreview{Url: "http://example.com", Title: "title"}).ToJson())
+	if preview := o.LinkPreview(); preview == nil || preview.Title != "title" {
+		t.Fatal("should have the preview")
+	}
+}
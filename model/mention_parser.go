@@ -0,0 +1,204 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MENTION_CHANNEL = "@channel"
+	MENTION_ALL     = "@all"
+	MENTION_HERE    = "@here"
+)
+
+// Mention is one keyword found in a message, Start and End are byte offsets
+// into the message.
+type Mention struct {
+	Keyword string   `json:"keyword"`
+	Start   int      `json:"start"`
+	End     int      `json:"end"`
+	UserIds []string `json:"user_ids"`
+}
+
+// MentionResults is what a message mentions. Specials holds @channel, @all
+// and @here, it's up to the caller to work out who those reach.
+type MentionResults struct {
+	UserIds  map[string]bool
+	Specials map[string]bool
+	Mentions []*Mention
+}
+
+// MentionParser finds the users a message mentions. Keywords are matched
+// against whole words, case insensitively unless added as case sensitive.
+type MentionParser struct {
+	keywords      map[string][]string
+	caseSensitive map[string][]string
+}
+
+// Runes that end a word. Dots, dashes and underscores aren't in here since
+// usernames can contain them, they're trimmed off the end of a word instead.
+var mentionSplitRunes = map[rune]bool{',': true, '!': true, '?': true, ':': true, ';': true, '<': true, '>': true,
+	'(': true, ')': true, '{': true, '}': true, '[': true, ']': true, '+': true, '/': true, '\\': true,
+	'"': true, '\'': true, '*': true, '~': true, '`': true}
+
+func NewMentionParser() *MentionParser {
+	return &MentionParser{keywords: make(map[string][]string), caseSensitive: make(map[string][]string)}
+}
+
+// AddKeyword makes keyword, in any case, mention the user.
+func (p *MentionParser) AddKeyword(keyword string, userId string) {
+	addMentionKeyword(p.keywords, strings.ToLower(strings.TrimSpace(keyword)), userId)
+}
+
+// AddCaseSensitiveKeyword makes keyword, exactly as given, mention the user.
+func (p *MentionParser) AddCaseSensitiveKeyword(keyword string, userId string) {
+	addMentionKeyword(p.caseSensitive, strings.TrimSpace(keyword), userId)
+}
+
+// AddUser adds @username, the user's mention keys and, if they've turned it
+// on, their case sensitive first name.
+func (p *MentionParser) AddUser(user *User) {
+	p.AddKeyword("@"+user.Username, user.Id)
+
+	for _, k := range strings.Split(user.NotifyProps["mention_keys"], ",") {
+		p.AddKeyword(k, user.Id)
+	}
+
+	if user.NotifyProps["first_name"] == "true" {
+		p.AddCaseSensitiveKeyword(strings.Split(user.FullName, " ")[0], user.Id)
+	}
+}
+
+// AddGroup makes @name mention each of the users.
+func (p *MentionParser) AddGroup(name string, userIds []string) {
+	for _, userId := range userIds {
+		p.AddKeyword("@"+name, userId)
+	}
+}
+
+func addMentionKeyword(keywords map[string][]string, keyword string, userId string) {
+	if len(keyword) == 0 {
+		return
+	}
+
+	for _, id := range keywords[keyword] {
+		if id == userId {
+			return
+		}
+	}
+
+	keywords[keyword] = append(keywords[keyword], userId)
+}
+
+// Parse finds the mentions in message, anything in a code block or inline
+// code is ignored.
+func (p *MentionParser) Parse(message string) *MentionResults {
+	results := &MentionResults{UserIds: make(map[string]bool), Specials: make(map[string]bool)}
+
+	start := -1
+	for i := 0; i < len(message); {
+		c, size := utf8.DecodeRuneInString(message[i:])
+
+		if unicode.IsSpace(c) || mentionSplitRunes[c] {
+			if start >= 0 {
+				p.parseWord(message, start, i, results)
+				start = -1
+			}
+
+			if c == '`' {
+				i = skipCode(message, i)
+			} else {
+				i += size
+			}
+			continue
+		}
+
+		if start < 0 {
+			start = i
+		}
+		i += size
+	}
+
+	if start >= 0 {
+		p.parseWord(message, start, len(message), results)
+	}
+
+	return results
+}
+
+// parseWord checks the word, then the word with any trailing dots, dashes
+// and underscores taken off one at a time so "@bob." still finds @bob.
+func (p *MentionParser) parseWord(message string, start int, end int, results *MentionResults) {
+	for ; end > start; end-- {
+		word := message[start:end]
+		lower := strings.ToLower(word)
+
+		if lower == MENTION_CHANNEL || lower == MENTION_ALL || lower == MENTION_HERE {
+			results.Specials[lower] = true
+			results.Mentions = append(results.Mentions, &Mention{Keyword: lower, Start: start, End: end})
+			return
+		}
+
+		userIds := append(append([]string{}, p.keywords[lower]...), p.caseSensitive[word]...)
+		if len(userIds) > 0 {
+			for _, userId := range userIds {
+				results.UserIds[userId] = true
+			}
+
+			results.Mentions = append(results.Mentions, &Mention{Keyword: word, Start: start, End: end, UserIds: userIds})
+			return
+		}
+
+		if last := message[end-1]; last != '.' && last != '-' && last != '_' {
+			return
+		}
+	}
+}
+
+// skipCode returns where scanning should carry on from the backtick at i,
+// past the end of the code block or inline code if one starts there.
+func skipCode(message string, i int) int {
+	if strings.HasPrefix(message[i:], "```") {
+		if end := strings.Index(message[i+3:], "```"); end >= 0 {
+			return i + 3 + end + 3
+		}
+
+		// An unclosed block runs to the end of the message
+		return len(message)
+	}
+
+	if message[i] == '`' {
+		if end := strings.IndexAny(message[i+1:], "`\n"); end >= 0 && message[i+1+end] == '`' {
+			return i + 1 + end + 1
+		}
+	}
+
+	return i + 1
+}
+
+// IsMentioned is true if the results mention the user, either directly or
+// through a channel wide mention they haven't turned off. online says
+// whether the user was online when the message was posted, for @here.
+func (o *MentionResults) IsMentioned(user *User, online bool) bool {
+	if o.UserIds[user.Id] {
+		return true
+	}
+
+	if o.Specials[MENTION_ALL] && user.NotifyProps["all"] == "true" {
+		return true
+	}
+
+	if o.Specials[MENTION_CHANNEL] && user.NotifyProps["channel"] == "true" {
+		return true
+	}
+
+	if o.Specials[MENTION_HERE] && online && user.NotifyProps["channel"] == "true" {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newTestMentionParser() (*MentionParser, map[string]*User) {
+	users := map[string]*User{
+		"bob":   {Id: "bob_id", Username: "bob", FullName: "Bob Bobby"},
+		"jane":  {Id: "jane_id", Username: "jane.doe", FullName: "Jane Doe"},
+		"mike":  {Id: "mike_id", Username: "mike-smith", FullName: "mike smith"},
+		"alice": {Id: "alice_id", Username: "alice_w", FullName: "Alice W"},
+	}
+
+	for _, user := range users {
+		user.SetDefaultNotifications()
+	}
+
+	users["bob"].NotifyProps["mention_keys"] += ",Deploy"
+	users["jane"].NotifyProps["mention_keys"] = "jane.doe,@jane.doe"
+	users["alice"].NotifyProps["mention_keys"] = "alice_w,@alice_w"
+	users["alice"].NotifyProps["first_name"] = "false"
+
+	p := NewMentionParser()
+	for _, user := range users {
+		p.AddUser(user)
+	}
+	p.AddGroup("backend", []string{"bob_id", "mike_id"})
+
+	return p, users
+}
+
+func TestMentionParser(t *testing.T) {
+	p, _ := newTestMentionParser()
+
+	for _, test := range []struct {
+		message  string
+		userIds  []string
+		specials []string
+	}{
+		{"hello world", nil, nil},
+		{"hey bob", []string{"bob_id"}, nil},
+		{"hey @bob", []string{"bob_id"}, nil},
+		{"hey @BOB!", []string{"bob_id"}, nil},
+		{"(@bob)", []string{"bob_id"}, nil},
+		{"thanks @bob.", []string{"bob_id"}, nil},
+		{"@bob, @jane.doe and @mike-smith", []string{"bob_id", "jane_id", "mike_id"}, nil},
+		{"ask @jane.doe...", []string{"jane_id"}, nil},
+		{"@mike-smith-", []string{"mike_id"}, nil},
+		{"@alice_w_", []string{"alice_id"}, nil},
+		{"@jane", nil, nil},
+		{"@bobby", nil, nil},
+		{"bob@example.com", nil, nil},
+		{"http://bob.example.com/page", nil, nil},
+
+		// Mention keys are case insensitive, including ones with capitals
+		{"time to deploy", []string{"bob_id"}, nil},
+		{"DEPLOY!", []string{"bob_id"}, nil},
+
+		// First names are case sensitive and only if turned on
+		{"Jane, look", []string{"jane_id"}, nil},
+		{"jane, look", nil, nil},
+		{"Alice look", nil, nil},
+
+		{"ping @backend", []string{"bob_id", "mike_id"}, nil},
+		{"@Channel please read", nil, []string{"@channel"}},
+		{"@all: and @here", nil, []string{"@all", "@here"}},
+		{"@channels", nil, nil},
+
+		// Code is ignored
+		{"`@bob` is the syntax", nil, nil},
+		{"```\n@bob\n@all\n```\nhi @jane.doe", []string{"jane_id"}, nil},
+		{"```@bob", nil, nil},
+		{"a ` on its own then @bob", []string{"bob_id"}, nil},
+		{"no `closing\n@bob", []string{"bob_id"}, nil},
+
+		{"héllo @bob à bientôt", []string{"bob_id"}, nil},
+		{"@bob @jane.doe", []string{"bob_id", "jane_id"}, nil},
+	} {
+		results := p.Parse(test.message)
+
+		var userIds []string
+		for userId := range results.UserIds {
+			userIds = append(userIds, userId)
+		}
+		sort.Strings(userIds)
+
+		if strings.Join(userIds, ",") != strings.Join(test.userIds, ",") {
+			t.Fatalf("%q: expected users %v, got %v", test.message, test.userIds, userIds)
+		}
+
+		var specials []string
+		for special := range results.Specials {
+			specials = append(specials, special)
+		}
+		sort.Strings(specials)
+
+		if strings.Join(specials, ",") != strings.Join(test.specials, ",") {
+			t.Fatalf("%q: expected specials %v, got %v", test.message, test.specials, specials)
+		}
+	}
+}
+
+func TestMentionParserPositions(t *testing.T) {
+	p, _ := newTestMentionParser()
+
+	message := "hi @Bob. and @all"
+	results := p.Parse(message)
+
+	if len(results.Mentions) != 2 {
+		t.Fatal("should have found two mentions")
+	}
+
+	if m := results.Mentions[0]; message[m.Start:m.End] != "@Bob" || m.Keyword != "@Bob" || len(m.UserIds) != 1 || m.UserIds[0] != "bob_id" {
+		t.Fatal("wrong first mention", m)
+	}
+
+	if m := results.Mentions[1]; message[m.Start:m.End] != "@all" || m.Keyword != MENTION_ALL || len(m.UserIds) != 0 {
+		t.Fatal("wrong second mention", m)
+	}
+}
+
+func TestMentionResultsIsMentioned(t *testing.T) {
+	p, users := newTestMentionParser()
+	bob := users["bob"]
+
+	for _, test := range []struct {
+		message   string
+		online    bool
+		all       string
+		channel   string
+		mentioned bool
+	}{
+		{"hi @bob", false, "false", "false", true},
+		{"hi @jane.doe", true, "true", "true", false},
+		{"hi @all", false, "true", "false", true},
+		{"hi @all", false, "false", "true", false},
+		{"hi @channel", false, "false", "true", true},
+		{"hi @channel", false, "true", "false", false},
+		{"hi @here", true, "true", "true", true},
+		{"hi @here", false, "true", "true", false},
+		{"hi @here", true, "true", "false", false},
+	} {
+		bob.NotifyProps["all"] = test.all
+		bob.NotifyProps["channel"] = test.channel
+
+		if p.Parse(test.message).IsMentioned(bob, test.online) != test.mentioned {
+			t.Fatalf("%q online=%v all=%v channel=%v: expected mentioned=%v", test.message, test.online, test.all, test.channel, test.mentioned)
+		}
+	}
+}
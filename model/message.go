@@ -9,14 +9,33 @@ import (
 )
 
 const (
-	ACTION_TYPING       = "typing"
-	ACTION_POSTED       = "posted"
-	ACTION_POST_EDITED  = "post_edited"
-	ACTION_POST_DELETED = "post_deleted"
-	ACTION_VIEWED       = "viewed"
-	ACTION_NEW_USER     = "new_user"
+	ACTION_TYPING            = "typing"
+	ACTION_POSTED            = "posted"
+	ACTION_POST_EDITED       = "post_edited"
+	ACTION_POST_DELETED      = "post_deleted"
+	ACTION_VIEWED            = "viewed"
+	ACTION_NEW_USER          = "new_user"
+	ACTION_USER_ADDED        = "user_added"
+	ACTION_USER_REMOVED      = "user_removed"
+	ACTION_ERROR             = "error"
+	ACTION_STATUS_CHANGE     = "status_change"
+	ACTION_RESYNC            = "resync"
+	ACTION_REACTION_ADDED    = "reaction_added"
+	ACTION_REACTION_REMOVED  = "reaction_removed"
+	ACTION_POST_PINNED       = "post_pinned"
+	ACTION_POST_UNPINNED     = "post_unpinned"
+	ACTION_EPHEMERAL_POST    = "ephemeral_post"
+	ACTION_POST_LINK_PREVIEW = "post_link_preview"
+	ACTION_CHANNEL_UNREAD    = "channel_unread"
+	ACTION_DRAFT_UPDATED     = "draft_updated"
+	ACTION_DRAFT_DELETED     = "draft_deleted"
 )
 
+// The only props a client may attach to each action it is allowed to send
+var clientActionProps = map[string][]string{
+	ACTION_TYPING: {"parent_id"},
+}
+
 type Message struct {
 	TeamId    string            `json:"team_id"`
 	ChannelId string            `json:"channel_id"`
@@ -33,6 +52,38 @@ func NewMessage(teamId string, channekId string, userId string, action string) *
 	return &Message{TeamId: teamId, ChannelId: channekId, UserId: userId, Action: action, Props: make(map[string]string)}
 }
 
+func (o *Message) IsValidFromClient() *AppError {
+
+	allowed, ok := clientActionProps[o.Action]
+	if !ok {
+		return NewAppError("Message.IsValidFromClient", "Clients are not allowed to send this action", "action="+o.Action)
+	}
+
+	if !(len(o.ChannelId) == 26 || len(o.ChannelId) == 0) {
+		return NewAppError("Message.IsValidFromClient", "Invalid channel id", "action="+o.Action)
+	}
+
+	for key, value := range o.Props {
+		found := false
+		for _, k := range allowed {
+			if k == key {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return NewAppError("Message.IsValidFromClient", "Invalid prop", "action="+o.Action+", prop="+key)
+		}
+
+		if !(len(value) == 26 || len(value) == 0) {
+			return NewAppError("Message.IsValidFromClient", "Invalid prop value", "action="+o.Action+", prop="+key)
+		}
+	}
+
+	return nil
+}
+
 func (o *Message) ToJson() string {
 	b, err := json.Marshal(o)
 	if err != nil {
@@ -52,3 +103,33 @@ func MessageFromJson(data io.Reader) *Message {
 		return nil
 	}
 }
+
+// MessagePoll is the response to a long poll. PollId must be passed back on
+// the next poll, a different PollId means events may have been missed.
+// CloseReason is set when the server closed the poller, such as when the
+// session was revoked.
+type MessagePoll struct {
+	PollId      string     `json:"poll_id"`
+	Messages    []*Message `json:"messages"`
+	CloseReason string     `json:"close_reason,omitempty"`
+}
+
+func (o *MessagePoll) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func MessagePollFromJson(data io.Reader) *MessagePoll {
+	decoder := json.NewDecoder(data)
+	var o MessagePoll
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
@@ -22,3 +22,58 @@ func TestMessgaeJson(t *testing.T) {
 		t.Fatal("Ids do not match")
 	}
 }
+
+func TestMessageIsValidFromClient(t *testing.T) {
+	m := NewMessage("", NewId(), "", ACTION_TYPING)
+	m.Add("parent_id", "")
+
+	if err := m.IsValidFromClient(); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Add("parent_id", NewId())
+	if err := m.IsValidFromClient(); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Add("parent_id", "junk")
+	if err := m.IsValidFromClient(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	m.Add("parent_id", "")
+	m.Add("post", "{}")
+	if err := m.IsValidFromClient(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	m = NewMessage("", "junk", "", ACTION_TYPING)
+	if err := m.IsValidFromClient(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	m = NewMessage("", NewId(), "", ACTION_POSTED)
+	if err := m.IsValidFromClient(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	m = NewMessage("", "", "", ACTION_NEW_USER)
+	if err := m.IsValidFromClient(); err == nil {
+		t.Fatal("clients should not be able to send new_user")
+	}
+}
+
+func TestMessagePollJson(t *testing.T) {
+	m := NewMessage(NewId(), NewId(), NewId(), ACTION_TYPING)
+	p := &MessagePoll{PollId: NewId(), Messages: []*Message{m}, CloseReason: "closed"}
+
+	result := MessagePollFromJson(strings.NewReader(p.ToJson()))
+
+	if p.PollId != result.PollId || p.CloseReason != result.CloseReason {
+		t.Fatal("polls do not match")
+	}
+
+	if len(result.Messages) != 1 || result.Messages[0].ChannelId != m.ChannelId {
+		t.Fatal("messages do not match")
+	}
+}
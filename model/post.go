@@ -6,28 +6,52 @@ package model
 import (
 	"encoding/json"
 	"io"
+	"strings"
 )
 
 const (
 	POST_DEFAULT = ""
+
+	// Shown to a single user over the websocket and never saved
+	POST_EPHEMERAL = "ephemeral"
+
+	// Generated by the server for channel events, the details are in Props
+	POST_SYSTEM_MESSAGE_PREFIX = "system_"
+	POST_JOIN_CHANNEL          = POST_SYSTEM_MESSAGE_PREFIX + "join_channel"
+	POST_LEAVE_CHANNEL         = POST_SYSTEM_MESSAGE_PREFIX + "leave_channel"
+	POST_ADD_TO_CHANNEL        = POST_SYSTEM_MESSAGE_PREFIX + "add_to_channel"
+	POST_REMOVE_FROM_CHANNEL   = POST_SYSTEM_MESSAGE_PREFIX + "remove_from_channel"
+	POST_HEADER_CHANGE         = POST_SYSTEM_MESSAGE_PREFIX + "header_change"
+	POST_DISPLAYNAME_CHANGE    = POST_SYSTEM_MESSAGE_PREFIX + "displayname_change"
+	POST_CHANNEL_DELETED       = POST_SYSTEM_MESSAGE_PREFIX + "channel_deleted"
+
+	// Sent with a post to say the user really does want to notify a large
+	// channel with @channel or @all, it's never saved
+	POST_PROP_CONFIRM_CHANNEL_MENTION = "confirm_channel_mention"
 )
 
 type Post struct {
-	Id         string      `json:"id"`
-	CreateAt   int64       `json:"create_at"`
-	UpdateAt   int64       `json:"update_at"`
-	DeleteAt   int64       `json:"delete_at"`
-	UserId     string      `json:"user_id"`
-	ChannelId  string      `json:"channel_id"`
-	RootId     string      `json:"root_id"`
-	ParentId   string      `json:"parent_id"`
-	OriginalId string      `json:"original_id"`
-	Message    string      `json:"message"`
-	ImgCount   int64       `json:"img_count"`
-	Type       string      `json:"type"`
-	Props      StringMap   `json:"props"`
-	Hashtags   string      `json:"hashtags"`
-	Filenames  StringArray `json:"filenames"`
+	Id          string      `json:"id"`
+	CreateAt    int64       `json:"create_at"`
+	UpdateAt    int64       `json:"update_at"`
+	DeleteAt    int64       `json:"delete_at"`
+	UserId      string      `json:"user_id"`
+	ChannelId   string      `json:"channel_id"`
+	RootId      string      `json:"root_id"`
+	ParentId    string      `json:"parent_id"`
+	OriginalId  string      `json:"original_id"`
+	Message     string      `json:"message"`
+	ImgCount    int64       `json:"img_count"`
+	Type        string      `json:"type"`
+	Props       StringMap   `json:"props"`
+	Hashtags    string      `json:"hashtags"`
+	Filenames   StringArray `json:"filenames"`
+	IsPinned    bool        `json:"is_pinned"`
+	ReplyCount  int64       `json:"reply_count"`
+	LastReplyAt int64       `json:"last_reply_at"`
+
+	// AttachmentText is the plain text of the attachments so search can find it
+	AttachmentText string `json:"-"`
 }
 
 func (o *Post) ToJson() string {
@@ -100,7 +124,7 @@ func (o *Post) IsValid() *AppError {
 		return NewAppError("Post.IsValid", "Invalid hashtags", "id="+o.Id)
 	}
 
-	if !(o.Type == POST_DEFAULT) {
+	if !(o.Type == POST_DEFAULT || o.IsSystemMessage()) {
 		return NewAppError("Post.IsValid", "Invalid type", "id="+o.Type)
 	}
 
@@ -108,6 +132,32 @@ func (o *Post) IsValid() *AppError {
 		return NewAppError("Post.IsValid", "Invalid filenames", "id="+o.Id)
 	}
 
+	if len(MapToJson(o.Props)) > 4000 {
+		return NewAppError("Post.IsValid", "Invalid props", "id="+o.Id)
+	}
+
+	if attachments, ok := o.Props[POST_PROP_ATTACHMENTS]; ok {
+		list := PostAttachmentsFromJson(strings.NewReader(attachments))
+		if list == nil {
+			return NewAppError("Post.IsValid", "Invalid attachments", "id="+o.Id)
+		}
+
+		if len(list) > POST_ATTACHMENTS_MAX {
+			return NewAppError("Post.IsValid", "Too many attachments", "id="+o.Id)
+		}
+
+		for _, attachment := range list {
+			if attachment == nil {
+				return NewAppError("Post.IsValid", "Invalid attachments", "id="+o.Id)
+			}
+
+			if err := attachment.IsValid(); err != nil {
+				err.DetailedError += " id=" + o.Id
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -117,6 +167,9 @@ func (o *Post) PreSave() {
 	}
 
 	o.OriginalId = ""
+	o.IsPinned = false
+	o.ReplyCount = 0
+	o.LastReplyAt = 0
 
 	o.CreateAt = GetMillis()
 	o.UpdateAt = o.CreateAt
@@ -128,6 +181,69 @@ func (o *Post) PreSave() {
 	if o.Filenames == nil {
 		o.Filenames = []string{}
 	}
+
+	o.AttachmentText = o.AttachmentsToText()
+}
+
+// Attachments are the rich attachments stored in the post's props, nil if it
+// has none or they don't parse.
+func (o *Post) Attachments() []*PostAttachment {
+	if attachments, ok := o.Props[POST_PROP_ATTACHMENTS]; ok {
+		return PostAttachmentsFromJson(strings.NewReader(attachments))
+	}
+
+	return nil
+}
+
+func (o *Post) SetAttachments(attachments []*PostAttachment) {
+	o.AddProp(POST_PROP_ATTACHMENTS, PostAttachmentsToJson(attachments))
+}
+
+func (o *Post) AttachmentsToText() string {
+	var texts []string
+	for _, attachment := range o.Attachments() {
+		if attachment == nil {
+			continue
+		}
+
+		if text := attachment.ToText(); len(text) > 0 {
+			texts = append(texts, text)
+		}
+	}
+
+	return strings.Join(texts, "\n\n")
+}
+
+// PlainText is the message followed by its attachments, for places like
+// emails that can't render attachments.
+func (o *Post) PlainText() string {
+	if text := o.AttachmentsToText(); len(text) == 0 {
+		return o.Message
+	} else if len(o.Message) == 0 {
+		return text
+	} else {
+		return o.Message + "\n\n" + text
+	}
+}
+
+// ThreadId is the id of the root post of the thread this post belongs to.
+func (o *Post) ThreadId() string {
+	if len(o.RootId) > 0 {
+		return o.RootId
+	}
+
+	return o.Id
+}
+
+// IsSystemMessage is true for the posts the server generates on channel events.
+func (o *Post) IsSystemMessage() bool {
+	switch o.Type {
+	case POST_JOIN_CHANNEL, POST_LEAVE_CHANNEL, POST_ADD_TO_CHANNEL, POST_REMOVE_FROM_CHANNEL,
+		POST_HEADER_CHANGE, POST_DISPLAYNAME_CHANGE, POST_CHANNEL_DELETED:
+		return true
+	}
+
+	return false
 }
 
 func (o *Post) MakeNonNil() {
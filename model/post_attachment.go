@@ -0,0 +1,142 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	POST_PROP_ATTACHMENTS = "attachments"
+
+	POST_ATTACHMENTS_MAX       = 20
+	POST_ATTACHMENT_FIELDS_MAX = 20
+)
+
+var validAttachmentColor = regexp.MustCompile(`^(good|warning|danger|#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6})$`)
+
+// PostAttachment is structured content an integration can hang off a post, it
+// follows the shape of a Slack message attachment.
+type PostAttachment struct {
+	Fallback   string                 `json:"fallback"`
+	Color      string                 `json:"color"`
+	Pretext    string                 `json:"pretext"`
+	AuthorName string                 `json:"author_name"`
+	AuthorLink string                 `json:"author_link"`
+	AuthorIcon string                 `json:"author_icon"`
+	Title      string                 `json:"title"`
+	TitleLink  string                 `json:"title_link"`
+	Text       string                 `json:"text"`
+	Fields     []*PostAttachmentField `json:"fields"`
+	ImageUrl   string                 `json:"image_url"`
+	ThumbUrl   string                 `json:"thumb_url"`
+	Footer     string                 `json:"footer"`
+}
+
+type PostAttachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+func (o *PostAttachment) IsValid() *AppError {
+
+	if len(o.Color) > 0 && !validAttachmentColor.MatchString(o.Color) {
+		return NewAppError("PostAttachment.IsValid", "Invalid color", "color="+o.Color)
+	}
+
+	for name, link := range map[string]string{
+		"author_link": o.AuthorLink,
+		"author_icon": o.AuthorIcon,
+		"title_link":  o.TitleLink,
+		"image_url":   o.ImageUrl,
+		"thumb_url":   o.ThumbUrl,
+	} {
+		if len(link) > 0 && !isHttpUrl(link) {
+			return NewAppError("PostAttachment.IsValid", "Invalid "+name, name+"="+link)
+		}
+	}
+
+	if len(o.Fields) > POST_ATTACHMENT_FIELDS_MAX {
+		return NewAppError("PostAttachment.IsValid", "Too many fields", "")
+	}
+
+	for _, field := range o.Fields {
+		if field == nil || (len(field.Title) == 0 && len(field.Value) == 0) {
+			return NewAppError("PostAttachment.IsValid", "Invalid field", "")
+		}
+	}
+
+	if len(o.ToText()) == 0 && len(o.ImageUrl) == 0 && len(o.ThumbUrl) == 0 {
+		return NewAppError("PostAttachment.IsValid", "Attachment is empty", "")
+	}
+
+	return nil
+}
+
+// ToText renders the attachment as plain text for emails, notifications and
+// the search index.
+func (o *PostAttachment) ToText() string {
+	var lines []string
+
+	for _, line := range []string{o.Pretext, o.AuthorName, o.Title, o.Text} {
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	for _, field := range o.Fields {
+		if field == nil {
+			continue
+		}
+
+		if len(field.Title) > 0 && len(field.Value) > 0 {
+			lines = append(lines, field.Title+": "+field.Value)
+		} else {
+			lines = append(lines, field.Title+field.Value)
+		}
+	}
+
+	if len(o.Footer) > 0 {
+		lines = append(lines, o.Footer)
+	}
+
+	if len(lines) == 0 {
+		return o.Fallback
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func isHttpUrl(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+}
+
+func PostAttachmentsToJson(o []*PostAttachment) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func PostAttachmentsFromJson(data io.Reader) []*PostAttachment {
+	decoder := json.NewDecoder(data)
+	var o []*PostAttachment
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
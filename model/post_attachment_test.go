@@ -0,0 +1,83 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostAttachmentJson(t *testing.T) {
+	o := []*PostAttachment{{Title: NewId(), Fields: []*PostAttachmentField{{Title: "a", Value: "b", Short: true}}}}
+	json := PostAttachmentsToJson(o)
+	ro := PostAttachmentsFromJson(strings.NewReader(json))
+
+	if len(ro) != 1 || ro[0].Title != o[0].Title || !ro[0].Fields[0].Short {
+		t.Fatal("attachments do not match")
+	}
+}
+
+func TestPostAttachmentIsValid(t *testing.T) {
+	o := PostAttachment{}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("empty attachment should be invalid")
+	}
+
+	o.Text = "text"
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Color = "purple"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Color = "#ff00AA"
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Color = "danger"
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.TitleLink = "javascript:alert(1)"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.TitleLink = "https://example.com/build/1"
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Fields = []*PostAttachmentField{{}}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Fields = make([]*PostAttachmentField, POST_ATTACHMENT_FIELDS_MAX+1)
+	for i := range o.Fields {
+		o.Fields[i] = &PostAttachmentField{Title: "a", Value: "b"}
+	}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestPostAttachmentToText(t *testing.T) {
+	o := PostAttachment{Fallback: "fallback"}
+	if o.ToText() != "fallback" {
+		t.Fatal("should have used the fallback")
+	}
+
+	o.Title = "title"
+	o.Text = "text"
+	o.Fields = []*PostAttachmentField{{Title: "env", Value: "prod"}, {Value: "only value"}}
+	if text := o.ToText(); text != "title\ntext\nenv: prod\nonly value" {
+		t.Fatal("bad text " + text)
+	}
+}
@@ -9,8 +9,9 @@ import (
 )
 
 type PostList struct {
-	Order []string         `json:"order"`
-	Posts map[string]*Post `json:"posts"`
+	Order     []string                      `json:"order"`
+	Posts     map[string]*Post              `json:"posts"`
+	Reactions map[string][]*ReactionSummary `json:"reactions"`
 }
 
 func (o *PostList) ToJson() string {
@@ -31,6 +32,10 @@ func (o *PostList) MakeNonNil() {
 		o.Posts = make(map[string]*Post)
 	}
 
+	if o.Reactions == nil {
+		o.Reactions = make(map[string][]*ReactionSummary)
+	}
+
 	for _, v := range o.Posts {
 		v.MakeNonNil()
 	}
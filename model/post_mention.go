@@ -0,0 +1,34 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+// PostMention records that a post mentioned a user. CreateAt is the post's
+// CreateAt so a user's mentions come back in the order they were posted.
+type PostMention struct {
+	PostId    string `json:"post_id"`
+	UserId    string `json:"user_id"`
+	ChannelId string `json:"channel_id"`
+	CreateAt  int64  `json:"create_at"`
+}
+
+func (o *PostMention) IsValid() *AppError {
+
+	if len(o.PostId) != 26 {
+		return NewAppError("PostMention.IsValid", "Invalid post id", "")
+	}
+
+	if len(o.UserId) != 26 {
+		return NewAppError("PostMention.IsValid", "Invalid user id", "")
+	}
+
+	if len(o.ChannelId) != 26 {
+		return NewAppError("PostMention.IsValid", "Invalid channel id", "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("PostMention.IsValid", "Create at must be a valid time", "")
+	}
+
+	return nil
+}
@@ -78,6 +78,75 @@ func TestPostIsValid(t *testing.T) {
 	if err := o.IsValid(); err != nil {
 		t.Fatal(err)
 	}
+
+	o.Type = POST_JOIN_CHANNEL
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Type = POST_SYSTEM_MESSAGE_PREFIX + "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Type = POST_EPHEMERAL
+	if err := o.IsValid(); err == nil {
+		t.Fatal("ephemeral posts should never be saved")
+	}
+}
+
+func TestPostAttachments(t *testing.T) {
+	o := Post{Id: NewId(), CreateAt: GetMillis(), UpdateAt: GetMillis(), UserId: NewId(), ChannelId: NewId(), Message: "test"}
+	o.SetAttachments([]*PostAttachment{{Title: "build", Text: "passed"}})
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if attachments := o.Attachments(); len(attachments) != 1 || attachments[0].Title != "build" {
+		t.Fatal("attachments didn't round trip")
+	}
+
+	if o.PlainText() != "test\n\nbuild\npassed" {
+		t.Fatal("bad plain text " + o.PlainText())
+	}
+
+	o.PreSave()
+	if o.AttachmentText != "build\npassed" {
+		t.Fatal("attachment text should be set on save")
+	}
+
+	o.SetAttachments([]*PostAttachment{{Color: "junk", Text: "passed"}})
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Props[POST_PROP_ATTACHMENTS] = "{junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Props[POST_PROP_ATTACHMENTS] = "[]"
+	o.Props["big"] = strings.Repeat("0", 4000)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("props should be too big")
+	}
+}
+
+func TestPostIsSystemMessage(t *testing.T) {
+	o := Post{Message: "test"}
+	if o.IsSystemMessage() {
+		t.Fatal("default post isn't a system message")
+	}
+
+	o.Type = POST_HEADER_CHANGE
+	if !o.IsSystemMessage() {
+		t.Fatal("header change is a system message")
+	}
+
+	o.Type = POST_EPHEMERAL
+	if o.IsSystemMessage() {
+		t.Fatal("ephemeral post isn't a system message")
+	}
 }
 
 func TestPostPreSave(t *testing.T) {
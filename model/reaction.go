@@ -0,0 +1,119 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+)
+
+var validEmojiName = regexp.MustCompile(`^[a-zA-Z0-9_+\-]{1,64}$`)
+
+type Reaction struct {
+	UserId    string `json:"user_id"`
+	PostId    string `json:"post_id"`
+	EmojiName string `json:"emoji_name"`
+	CreateAt  int64  `json:"create_at"`
+}
+
+// ReactionSummary is everybody who reacted to a post with the same emoji.
+type ReactionSummary struct {
+	EmojiName string      `json:"emoji_name"`
+	Count     int         `json:"count"`
+	UserIds   StringArray `json:"user_ids"`
+}
+
+func (o *Reaction) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ReactionFromJson(data io.Reader) *Reaction {
+	decoder := json.NewDecoder(data)
+	var o Reaction
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func ReactionsToJson(o []*Reaction) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func ReactionsFromJson(data io.Reader) []*Reaction {
+	decoder := json.NewDecoder(data)
+	var o []*Reaction
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
+
+func (o *Reaction) IsValid() *AppError {
+
+	if len(o.UserId) != 26 {
+		return NewAppError("Reaction.IsValid", "Invalid user id", "")
+	}
+
+	if len(o.PostId) != 26 {
+		return NewAppError("Reaction.IsValid", "Invalid post id", "")
+	}
+
+	if !validEmojiName.MatchString(o.EmojiName) {
+		return NewAppError("Reaction.IsValid", "Invalid emoji name", "emoji_name="+o.EmojiName)
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("Reaction.IsValid", "Create at must be a valid time", "")
+	}
+
+	return nil
+}
+
+func (o *Reaction) PreSave() {
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+// SummarizeReactions groups reactions by post and then by emoji, keeping the
+// emojis in the order they were first used.
+func SummarizeReactions(reactions []*Reaction) map[string][]*ReactionSummary {
+	summaries := make(map[string][]*ReactionSummary)
+
+	for _, reaction := range reactions {
+		var summary *ReactionSummary
+
+		for _, s := range summaries[reaction.PostId] {
+			if s.EmojiName == reaction.EmojiName {
+				summary = s
+				break
+			}
+		}
+
+		if summary == nil {
+			summary = &ReactionSummary{EmojiName: reaction.EmojiName, UserIds: StringArray{}}
+			summaries[reaction.PostId] = append(summaries[reaction.PostId], summary)
+		}
+
+		summary.Count++
+		summary.UserIds = append(summary.UserIds, reaction.UserId)
+	}
+
+	return summaries
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReactionJson(t *testing.T) {
+	o := Reaction{UserId: NewId(), PostId: NewId(), EmojiName: "smile"}
+	json := o.ToJson()
+	ro := ReactionFromJson(strings.NewReader(json))
+
+	if o.UserId != ro.UserId || o.PostId != ro.PostId || o.EmojiName != ro.EmojiName {
+		t.Fatal("reactions do not match")
+	}
+
+	list := ReactionsFromJson(strings.NewReader(ReactionsToJson([]*Reaction{&o})))
+	if len(list) != 1 || list[0].EmojiName != o.EmojiName {
+		t.Fatal("reaction lists do not match")
+	}
+}
+
+func TestReactionIsValid(t *testing.T) {
+	o := Reaction{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PostId = NewId()
+	o.EmojiName = "smile face"
+	o.PreSave()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.EmojiName = strings.Repeat("a", 65)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.EmojiName = "+1"
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSummarizeReactions(t *testing.T) {
+	postId := NewId()
+	user1 := NewId()
+	user2 := NewId()
+
+	summaries := SummarizeReactions([]*Reaction{
+		{PostId: postId, UserId: user1, EmojiName: "smile"},
+		{PostId: postId, UserId: user1, EmojiName: "+1"},
+		{PostId: postId, UserId: user2, EmojiName: "smile"},
+	})
+
+	if len(summaries[postId]) != 2 {
+		t.Fatal("should have two emojis")
+	}
+
+	if s := summaries[postId][0]; s.EmojiName != "smile" || s.Count != 2 || s.UserIds[1] != user2 {
+		t.Fatal("should have summarized smile first")
+	}
+
+	if s := summaries[postId][1]; s.EmojiName != "+1" || s.Count != 1 {
+		t.Fatal("should have summarized +1")
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	REMINDER_DEFAULT_HOUR = 9
+)
+
+// Reminder is a message the valet sends to UserId at RemindAt on behalf of
+// CreatorId.
+type Reminder struct {
+	Id        string `json:"id"`
+	CreateAt  int64  `json:"create_at"`
+	CreatorId string `json:"creator_id"`
+	UserId    string `json:"user_id"`
+	TeamId    string `json:"team_id"`
+	Message   string `json:"message"`
+	RemindAt  int64  `json:"remind_at"`
+	TeamUrl   string `json:"-"`
+	ClaimedAt int64  `json:"-"`
+	Attempts  int    `json:"-"`
+}
+
+func (o *Reminder) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ReminderFromJson(data io.Reader) *Reminder {
+	decoder := json.NewDecoder(data)
+	var o Reminder
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func (o *Reminder) IsValid() *AppError {
+
+	if len(o.Id) != 26 {
+		return NewAppError("Reminder.IsValid", "Invalid Id", "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("Reminder.IsValid", "Create at must be a valid time", "id="+o.Id)
+	}
+
+	if len(o.CreatorId) != 26 {
+		return NewAppError("Reminder.IsValid", "Invalid creator id", "id="+o.Id)
+	}
+
+	if len(o.UserId) != 26 {
+		return NewAppError("Reminder.IsValid", "Invalid user id", "id="+o.Id)
+	}
+
+	if len(o.TeamId) != 26 {
+		return NewAppError("Reminder.IsValid", "Invalid team id", "id="+o.Id)
+	}
+
+	if len(o.Message) == 0 || len(o.Message) > 4000 {
+		return NewAppError("Reminder.IsValid", "Invalid message", "id="+o.Id)
+	}
+
+	if o.RemindAt == 0 {
+		return NewAppError("Reminder.IsValid", "Remind at must be a valid time", "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *Reminder) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	o.CreateAt = GetMillis()
+}
+
+// ParseReminder understands the text after /remind, such as
+// "me in 2 hours to review the PR" or "@alice to call bob tomorrow at 9am".
+// It returns who to remind, what to say and when.
+func ParseReminder(text string, now time.Time) (string, string, time.Time, bool) {
+	words := strings.Fields(text)
+	if len(words) < 3 {
+		return "", "", time.Time{}, false
+	}
+
+	who := words[0]
+	rest := words[1:]
+
+	var message []string
+	when, n := parseReminderWhen(rest, now)
+	if n > 0 {
+		message = rest[n:]
+	} else {
+		for i := 1; i < len(rest); i++ {
+			if when, n = parseReminderWhen(rest[i:], now); n > 0 && i+n == len(rest) {
+				message = rest[:i]
+				break
+			}
+		}
+	}
+
+	if len(message) > 0 && (strings.ToLower(message[0]) == "to" || strings.ToLower(message[0]) == "that") {
+		message = message[1:]
+	}
+
+	if len(message) == 0 {
+		return "", "", time.Time{}, false
+	}
+
+	return who, strings.Join(message, " "), when, true
+}
+
+var reminderUnits = map[string]time.Duration{
+	"min":     time.Minute,
+	"mins":    time.Minute,
+	"minute":  time.Minute,
+	"minutes": time.Minute,
+	"hr":      time.Hour,
+	"hrs":     time.Hour,
+	"hour":    time.Hour,
+	"hours":   time.Hour,
+	"day":     24 * time.Hour,
+	"days":    24 * time.Hour,
+	"week":    7 * 24 * time.Hour,
+	"weeks":   7 * 24 * time.Hour,
+}
+
+var reminderWeekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// parseReminderWhen reads a time from the start of words and returns it with
+// how many words it used, or 0 if there isn't one.
+func parseReminderWhen(words []string, now time.Time) (time.Time, int) {
+	if len(words) == 0 {
+		return time.Time{}, 0
+	}
+
+	first := strings.ToLower(words[0])
+
+	switch first {
+	case "in":
+		if len(words) < 3 {
+			return time.Time{}, 0
+		}
+
+		amount, err := strconv.Atoi(words[1])
+		if words[1] == "a" || words[1] == "an" {
+			amount, err = 1, nil
+		}
+
+		unit, ok := reminderUnits[strings.ToLower(words[2])]
+		if err != nil || amount <= 0 || !ok {
+			return time.Time{}, 0
+		}
+
+		return now.Add(time.Duration(amount) * unit), 3
+
+	case "today", "tomorrow":
+		day := now
+		if first == "tomorrow" {
+			day = now.AddDate(0, 0, 1)
+		}
+
+		if hour, minute, n := parseReminderAt(words[1:]); n > 0 {
+			return reminderDate(day, hour, minute), 1 + n
+		} else if first == "tomorrow" {
+			return reminderDate(day, REMINDER_DEFAULT_HOUR, 0), 1
+		}
+
+		return time.Time{}, 0
+
+	case "at":
+		hour, minute, n := parseReminderAt(words)
+		if n == 0 {
+			return time.Time{}, 0
+		}
+
+		when := reminderDate(now, hour, minute)
+		if n < len(words) && strings.ToLower(words[n]) == "tomorrow" {
+			return when.AddDate(0, 0, 1), n + 1
+		} else if n < len(words) && strings.ToLower(words[n]) == "today" {
+			return when, n + 1
+		} else if !when.After(now) {
+			when = when.AddDate(0, 0, 1)
+		}
+
+		return when, n
+
+	case "on":
+		if when, n := parseReminderWhen(words[1:], now); n > 0 {
+			if _, ok := reminderWeekdays[strings.ToLower(words[1])]; ok {
+				return when, n + 1
+			}
+		}
+
+		return time.Time{}, 0
+	}
+
+	if weekday, ok := reminderWeekdays[first]; ok {
+		days := int(weekday - now.Weekday())
+		if days <= 0 {
+			days += 7
+		}
+
+		day := now.AddDate(0, 0, days)
+		if hour, minute, n := parseReminderAt(words[1:]); n > 0 {
+			return reminderDate(day, hour, minute), 1 + n
+		}
+
+		return reminderDate(day, REMINDER_DEFAULT_HOUR, 0), 1
+	}
+
+	return time.Time{}, 0
+}
+
+// parseReminderAt reads "at 9am", "at 9:30 pm", "at 17:00" or "at noon".
+func parseReminderAt(words []string) (int, int, int) {
+	if len(words) < 2 || strings.ToLower(words[0]) != "at" {
+		return 0, 0, 0
+	}
+
+	clock := strings.ToLower(words[1])
+	n := 2
+
+	switch clock {
+	case "noon":
+		return 12, 0, n
+	case "midnight":
+		return 0, 0, n
+	}
+
+	suffix := ""
+	if strings.HasSuffix(clock, "am") || strings.HasSuffix(clock, "pm") {
+		suffix = clock[len(clock)-2:]
+		clock = clock[:len(clock)-2]
+	} else if len(words) > 2 && (strings.ToLower(words[2]) == "am" || strings.ToLower(words[2]) == "pm") {
+		suffix = strings.ToLower(words[2])
+		n++
+	}
+
+	parts := strings.SplitN(clock, ":", 2)
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, 0
+	}
+
+	minute := 0
+	if len(parts) == 2 {
+		if minute, err = strconv.Atoi(parts[1]); err != nil || len(parts[1]) != 2 || minute > 59 {
+			return 0, 0, 0
+		}
+	}
+
+	if len(suffix) > 0 {
+		if hour < 1 || hour > 12 {
+			return 0, 0, 0
+		}
+
+		hour = hour % 12
+		if suffix == "pm" {
+			hour += 12
+		}
+	} else if hour > 23 || hour < 0 {
+		return 0, 0, 0
+	}
+
+	return hour, minute, n
+}
+
+func reminderDate(day time.Time, hour int, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+}
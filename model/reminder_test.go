@@ -0,0 +1,91 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReminderJson(t *testing.T) {
+	o := Reminder{Id: NewId(), Message: NewId(), RemindAt: GetMillis(), TeamUrl: "http://localhost"}
+	json := o.ToJson()
+	ro := ReminderFromJson(strings.NewReader(json))
+
+	if o.Id != ro.Id || o.Message != ro.Message || o.RemindAt != ro.RemindAt {
+		t.Fatal("Ids do not match")
+	}
+
+	if ro.TeamUrl != "" {
+		t.Fatal("should not expose the team url")
+	}
+}
+
+func TestReminderIsValid(t *testing.T) {
+	o := Reminder{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PreSave()
+	o.CreatorId = NewId()
+	o.UserId = NewId()
+	o.TeamId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Message = "hello"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.RemindAt = GetMillis()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseReminder(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2015, time.July, 15, 14, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		text    string
+		who     string
+		message string
+		when    time.Time
+	}{
+		{"me in 2 hours to review the PR", "me", "review the PR", now.Add(2 * time.Hour)},
+		{"me in an hour check the oven", "me", "check the oven", now.Add(time.Hour)},
+		{"me to review the PR in 10 minutes", "me", "review the PR", now.Add(10 * time.Minute)},
+		{"@alice tomorrow at 9am to send the report", "@alice", "send the report", time.Date(2015, time.July, 16, 9, 0, 0, 0, time.UTC)},
+		{"@alice tomorrow call bob", "@alice", "call bob", time.Date(2015, time.July, 16, 9, 0, 0, 0, time.UTC)},
+		{"me at 5:15 pm to go home", "me", "go home", time.Date(2015, time.July, 15, 17, 15, 0, 0, time.UTC)},
+		{"me at 9am stand up", "me", "stand up", time.Date(2015, time.July, 16, 9, 0, 0, 0, time.UTC)},
+		{"me at noon today lunch", "me", "lunch", time.Date(2015, time.July, 15, 12, 0, 0, 0, time.UTC)},
+		{"me to deploy on friday at 17:00", "me", "deploy", time.Date(2015, time.July, 17, 17, 0, 0, 0, time.UTC)},
+		{"me wednesday retro", "me", "retro", time.Date(2015, time.July, 22, 9, 0, 0, 0, time.UTC)},
+		{"me to call at home at 8pm", "me", "call at home", time.Date(2015, time.July, 15, 20, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		who, message, when, ok := ParseReminder(c.text, now)
+		if !ok {
+			t.Fatal("should have parsed " + c.text)
+		}
+
+		if who != c.who || message != c.message || !when.Equal(c.when) {
+			t.Fatal("wrong reminder for "+c.text, who, message, when)
+		}
+	}
+
+	for _, text := range []string{"me", "me in 2 hours", "me to do something", "me in 2 fortnights to relax", "me at 13pm to nap", "me at 9:5 to stretch"} {
+		if _, _, _, ok := ParseReminder(text, now); ok {
+			t.Fatal("should not have parsed " + text)
+		}
+	}
+}
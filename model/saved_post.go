@@ -0,0 +1,59 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SavedPost is a post a user flagged to come back to later.
+type SavedPost struct {
+	UserId   string `json:"user_id"`
+	PostId   string `json:"post_id"`
+	CreateAt int64  `json:"create_at"`
+}
+
+func (o *SavedPost) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func SavedPostFromJson(data io.Reader) *SavedPost {
+	decoder := json.NewDecoder(data)
+	var o SavedPost
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func (o *SavedPost) IsValid() *AppError {
+
+	if len(o.UserId) != 26 {
+		return NewAppError("SavedPost.IsValid", "Invalid user id", "")
+	}
+
+	if len(o.PostId) != 26 {
+		return NewAppError("SavedPost.IsValid", "Invalid post id", "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("SavedPost.IsValid", "Create at must be a valid time", "")
+	}
+
+	return nil
+}
+
+func (o *SavedPost) PreSave() {
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSavedPostJson(t *testing.T) {
+	o := SavedPost{UserId: NewId(), PostId: NewId(), CreateAt: GetMillis()}
+	json := o.ToJson()
+	ro := SavedPostFromJson(strings.NewReader(json))
+
+	if o.UserId != ro.UserId || o.PostId != ro.PostId || o.CreateAt != ro.CreateAt {
+		t.Fatal("saved posts do not match")
+	}
+}
+
+func TestSavedPostIsValid(t *testing.T) {
+	o := SavedPost{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PostId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PreSave()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	SCHEDULED_POST_PENDING = "pending"
+	SCHEDULED_POST_SENDING = "sending"
+	SCHEDULED_POST_SENT    = "sent"
+	SCHEDULED_POST_FAILED  = "failed"
+
+	// Set on the post that gets created so a retry can tell it was already sent
+	POST_PROP_SCHEDULED_POST_ID = "scheduled_post_id"
+)
+
+// ScheduledPost is a message waiting to be posted at ScheduledAt.
+type ScheduledPost struct {
+	Id          string `json:"id"`
+	CreateAt    int64  `json:"create_at"`
+	UpdateAt    int64  `json:"update_at"`
+	UserId      string `json:"user_id"`
+	TeamId      string `json:"team_id"`
+	ChannelId   string `json:"channel_id"`
+	RootId      string `json:"root_id"`
+	Message     string `json:"message"`
+	ScheduledAt int64  `json:"scheduled_at"`
+	State       string `json:"state"`
+	ClaimedAt   int64  `json:"-"`
+	PostId      string `json:"post_id"`
+	Error       string `json:"error"`
+	TeamUrl     string `json:"-"`
+
+	// Sent with a create or update to confirm an @channel or @all, the same
+	// as POST_PROP_CONFIRM_CHANNEL_MENTION on a post, never saved
+	ConfirmChannelMention bool `json:"confirm_channel_mention,omitempty" db:"-"`
+}
+
+func (o *ScheduledPost) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ScheduledPostFromJson(data io.Reader) *ScheduledPost {
+	decoder := json.NewDecoder(data)
+	var o ScheduledPost
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func ScheduledPostsToJson(o []*ScheduledPost) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func ScheduledPostsFromJson(data io.Reader) []*ScheduledPost {
+	decoder := json.NewDecoder(data)
+	var o []*ScheduledPost
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
+
+func (o *ScheduledPost) IsValid() *AppError {
+
+	if len(o.Id) != 26 {
+		return NewAppError("ScheduledPost.IsValid", "Invalid Id", "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("ScheduledPost.IsValid", "Create at must be a valid time", "id="+o.Id)
+	}
+
+	if o.UpdateAt == 0 {
+		return NewAppError("ScheduledPost.IsValid", "Update at must be a valid time", "id="+o.Id)
+	}
+
+	if len(o.UserId) != 26 {
+		return NewAppError("ScheduledPost.IsValid", "Invalid user id", "id="+o.Id)
+	}
+
+	if len(o.TeamId) != 26 {
+		return NewAppError("ScheduledPost.IsValid", "Invalid team id", "id="+o.Id)
+	}
+
+	if len(o.ChannelId) != 26 {
+		return NewAppError("ScheduledPost.IsValid", "Invalid channel id", "id="+o.Id)
+	}
+
+	if !(len(o.RootId) == 26 || len(o.RootId) == 0) {
+		return NewAppError("ScheduledPost.IsValid", "Invalid root id", "id="+o.Id)
+	}
+
+	if len(o.Message) == 0 || len(o.Message) > 4000 {
+		return NewAppError("ScheduledPost.IsValid", "Invalid message", "id="+o.Id)
+	}
+
+	if o.ScheduledAt == 0 {
+		return NewAppError("ScheduledPost.IsValid", "Scheduled at must be a valid time", "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *ScheduledPost) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	o.CreateAt = GetMillis()
+	o.UpdateAt = o.CreateAt
+
+	o.State = SCHEDULED_POST_PENDING
+	o.ClaimedAt = 0
+	o.PostId = ""
+	o.Error = ""
+}
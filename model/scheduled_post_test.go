@@ -0,0 +1,73 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScheduledPostJson(t *testing.T) {
+	o := ScheduledPost{Id: NewId(), Message: NewId(), ScheduledAt: GetMillis(), TeamUrl: "http://localhost"}
+	json := o.ToJson()
+	ro := ScheduledPostFromJson(strings.NewReader(json))
+
+	if o.Id != ro.Id || o.Message != ro.Message || o.ScheduledAt != ro.ScheduledAt {
+		t.Fatal("Ids do not match")
+	}
+
+	if ro.TeamUrl != "" {
+		t.Fatal("should not expose the team url")
+	}
+
+	list := ScheduledPostsFromJson(strings.NewReader(ScheduledPostsToJson([]*ScheduledPost{&o})))
+	if len(list) != 1 || list[0].Id != o.Id {
+		t.Fatal("lists do not match")
+	}
+}
+
+func TestScheduledPostIsValid(t *testing.T) {
+	o := ScheduledPost{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PreSave()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	o.TeamId = NewId()
+	o.ChannelId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Message = "hello"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ScheduledAt = GetMillis()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.RootId = "123"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.RootId = ""
+	o.Message = strings.Repeat("0", 4001)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	if o.State != SCHEDULED_POST_PENDING {
+		t.Fatal("should start out pending")
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Status is the presence of a user as tracked from their live connections.
+// Manual holds a status the user picked themselves which wins over the
+// automatic one until ManualUntil passes, or forever when ManualUntil is 0.
+type Status struct {
+	UserId         string `json:"user_id"`
+	Status         string `json:"status"`
+	Manual         string `json:"manual"`
+	ManualUntil    int64  `json:"manual_until"`
+	LastActivityAt int64  `json:"last_activity_at"`
+}
+
+func (o *Status) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func StatusFromJson(data io.Reader) *Status {
+	decoder := json.NewDecoder(data)
+	var o Status
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func IsManualStatusValid(status string) bool {
+	return status == "" || status == USER_ONLINE || status == USER_AWAY || status == USER_DND
+}
+
+// Derive works out what the status should be at time now given whether the
+// user has any live connections.
+func (o *Status) Derive(connected bool, now int64) string {
+	if !connected {
+		return USER_OFFLINE
+	}
+
+	if len(o.Manual) > 0 && (o.ManualUntil == 0 || now < o.ManualUntil) {
+		return o.Manual
+	}
+
+	if now-o.LastActivityAt > USER_AWAY_TIMEOUT {
+		return USER_AWAY
+	}
+
+	return USER_ONLINE
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatusJson(t *testing.T) {
+	o := Status{UserId: NewId(), Status: USER_DND, Manual: USER_DND, ManualUntil: 1234, LastActivityAt: 5678}
+	json := o.ToJson()
+	ro := StatusFromJson(strings.NewReader(json))
+
+	if o != *ro {
+		t.Fatal("statuses do not match")
+	}
+}
+
+func TestStatusDerive(t *testing.T) {
+	now := GetMillis()
+	o := Status{UserId: NewId(), LastActivityAt: now}
+
+	if o.Derive(false, now) != USER_OFFLINE {
+		t.Fatal("should be offline without connections")
+	}
+
+	if o.Derive(true, now) != USER_ONLINE {
+		t.Fatal("should be online")
+	}
+
+	if o.Derive(true, now+USER_AWAY_TIMEOUT+1) != USER_AWAY {
+		t.Fatal("should be away after being idle")
+	}
+
+	o.Manual = USER_DND
+	if o.Derive(true, now) != USER_DND {
+		t.Fatal("manual status should win")
+	}
+
+	if o.Derive(false, now) != USER_OFFLINE {
+		t.Fatal("should be offline without connections even with a manual status")
+	}
+
+	o.ManualUntil = now + 1000
+	if o.Derive(true, now) != USER_DND {
+		t.Fatal("manual status should win until it expires")
+	}
+
+	if o.Derive(true, now+1001) != USER_ONLINE {
+		t.Fatal("manual status should have expired")
+	}
+}
+
+func TestIsManualStatusValid(t *testing.T) {
+	if !IsManualStatusValid(USER_DND) || !IsManualStatusValid(USER_AWAY) || !IsManualStatusValid("") {
+		t.Fatal("should be valid")
+	}
+
+	if IsManualStatusValid(USER_OFFLINE) || IsManualStatusValid("junk") {
+		t.Fatal("should be invalid")
+	}
+}
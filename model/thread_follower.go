@@ -0,0 +1,60 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ThreadFollower records whether a user gets notified about replies to a
+// thread. Users who explicitly unfollow keep a row with Following set to
+// false so that replying again doesn't silently re-follow them.
+type ThreadFollower struct {
+	RootId    string `json:"root_id"`
+	UserId    string `json:"user_id"`
+	Following bool   `json:"following"`
+	UpdateAt  int64  `json:"update_at"`
+}
+
+func (o *ThreadFollower) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ThreadFollowerFromJson(data io.Reader) *ThreadFollower {
+	decoder := json.NewDecoder(data)
+	var o ThreadFollower
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func (o *ThreadFollower) IsValid() *AppError {
+
+	if len(o.RootId) != 26 {
+		return NewAppError("ThreadFollower.IsValid", "Invalid root id", "")
+	}
+
+	if len(o.UserId) != 26 {
+		return NewAppError("ThreadFollower.IsValid", "Invalid user id", "")
+	}
+
+	if o.UpdateAt == 0 {
+		return NewAppError("ThreadFollower.IsValid", "Update at must be a valid time", "")
+	}
+
+	return nil
+}
+
+func (o *ThreadFollower) PreSave() {
+	o.UpdateAt = GetMillis()
+}
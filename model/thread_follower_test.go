@@ -0,0 +1,42 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThreadFollowerJson(t *testing.T) {
+	o := ThreadFollower{RootId: NewId(), UserId: NewId(), Following: true, UpdateAt: GetMillis()}
+	json := o.ToJson()
+	ro := ThreadFollowerFromJson(strings.NewReader(json))
+
+	if o.RootId != ro.RootId || o.UserId != ro.UserId || o.Following != ro.Following {
+		t.Fatal("followers do not match")
+	}
+}
+
+func TestThreadFollowerIsValid(t *testing.T) {
+	o := ThreadFollower{}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.RootId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.UserId = NewId()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PreSave()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
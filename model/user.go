@@ -20,6 +20,7 @@ const (
 	USER_OFFLINE         = "offline"
 	USER_AWAY            = "away"
 	USER_ONLINE          = "online"
+	USER_DND             = "dnd"
 	USER_NOTIFY_ALL      = "all"
 	USER_NOTIFY_MENTION  = "mention"
 	USER_NOTIFY_NONE     = "none"
@@ -284,6 +285,7 @@ func IsUsernameValid(username string) bool {
 		BOT_USERNAME,
 		"all",
 		"channel",
+		"here",
 	}
 
 	for _, restrictedUsername := range restrictedUsernames {
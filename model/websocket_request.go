@@ -0,0 +1,86 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	WEBSOCKET_CREATE_POST        = "create_post"
+	WEBSOCKET_TYPING             = "typing"
+	WEBSOCKET_UPDATE_LAST_VIEWED = "update_last_viewed"
+	WEBSOCKET_GET_STATUSES       = "get_statuses"
+
+	STATUS_OK   = "OK"
+	STATUS_FAIL = "FAIL"
+)
+
+// WebSocketRequest is a command sent by a client over the websocket. Seq is
+// chosen by the client and echoed back as SeqReply on the response.
+type WebSocketRequest struct {
+	Seq    int64             `json:"seq"`
+	Action string            `json:"action"`
+	Data   map[string]string `json:"data"`
+}
+
+type WebSocketResponse struct {
+	Status   string            `json:"status"`
+	SeqReply int64             `json:"seq_reply"`
+	Data     map[string]string `json:"data"`
+	Error    *AppError         `json:"error,omitempty"`
+}
+
+func (o *WebSocketRequest) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func WebSocketRequestFromJson(data io.Reader) *WebSocketRequest {
+	decoder := json.NewDecoder(data)
+	var o WebSocketRequest
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func NewWebSocketResponse(seq int64, data map[string]string, err *AppError) *WebSocketResponse {
+	if data == nil {
+		data = make(map[string]string)
+	}
+
+	if err != nil {
+		return &WebSocketResponse{Status: STATUS_FAIL, SeqReply: seq, Data: data, Error: err}
+	}
+
+	return &WebSocketResponse{Status: STATUS_OK, SeqReply: seq, Data: data}
+}
+
+func (o *WebSocketResponse) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func WebSocketResponseFromJson(data io.Reader) *WebSocketResponse {
+	decoder := json.NewDecoder(data)
+	var o WebSocketResponse
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWebSocketRequestJson(t *testing.T) {
+	r := &WebSocketRequest{Seq: 1, Action: WEBSOCKET_UPDATE_LAST_VIEWED, Data: map[string]string{"channel_id": NewId()}}
+	json := r.ToJson()
+	result := WebSocketRequestFromJson(strings.NewReader(json))
+
+	if r.Seq != result.Seq || r.Action != result.Action {
+		t.Fatal("requests do not match")
+	}
+
+	if r.Data["channel_id"] != result.Data["channel_id"] {
+		t.Fatal("Ids do not match")
+	}
+}
+
+func TestWebSocketResponseJson(t *testing.T) {
+	r := NewWebSocketResponse(5, nil, nil)
+	result := WebSocketResponseFromJson(strings.NewReader(r.ToJson()))
+
+	if result.Status != STATUS_OK || result.SeqReply != 5 || result.Error != nil {
+		t.Fatal("bad ok response")
+	}
+
+	r = NewWebSocketResponse(6, nil, NewAppError("test", "failed", ""))
+	result = WebSocketResponseFromJson(strings.NewReader(r.ToJson()))
+
+	if result.Status != STATUS_FAIL || result.SeqReply != 6 || result.Error == nil || result.Error.Message != "failed" {
+		t.Fatal("bad fail response")
+	}
+}
@@ -5,41 +5,57 @@ package store
 
 import (
 	l4g "code.google.com/p/log4go"
+	"crypto/tls"
+	"fmt"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 	"gopkg.in/redis.v2"
+	"net"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+const (
+	CLUSTER_CHANNEL = "cluster"
+
+	REDIS_DIAL_TIMEOUT         = 5 * time.Second
+	REDIS_SENTINEL_CHECK_EVERY = 5 * time.Second
+)
+
 var client *redis.Client
+var clientLock sync.Mutex
+var masterAddr string
 
 func RedisClient() *redis.Client {
+	clientLock.Lock()
+	defer clientLock.Unlock()
 
 	if client == nil {
 
-		addr := utils.Cfg.RedisSettings.DataSource
-
-		client = redis.NewTCPClient(&redis.Options{
-			Addr:     addr,
-			Password: "",
-			DB:       0,
-			PoolSize: utils.Cfg.RedisSettings.MaxOpenConns,
-		})
+		addr, err := redisMasterAddr()
+		if err != nil {
+			// Fall back to the first address so there is a client to retry
+			// with, the sentinel watcher switches over once it finds a master
+			l4g.Critical("Failed to find the redis master err:%v", err)
+			addr = utils.Cfg.RedisSettings.DataSource
+		}
 
-		l4g.Info("Pinging redis at '%v'", addr)
-		pong, err := client.Ping().Result()
+		client = newRedisClient(addr)
+		masterAddr = addr
 
-		if err != nil {
-			l4g.Critical("Failed to open redis connection to '%v' err:%v", addr, err)
-			time.Sleep(time.Second)
-			panic("Failed to open redis connection " + err.Error())
+		if len(utils.Cfg.RedisSettings.SentinelMasterName) > 0 {
+			go watchRedisMaster(client)
 		}
 
-		if pong != "PONG" {
-			l4g.Critical("Failed to ping redis connection to '%v' err:%v", addr, err)
-			time.Sleep(time.Second)
-			panic("Failed to open ping connection " + err.Error())
+		// Redis being down is survivable, the pool connects again on demand
+		// and the hubs resubscribe once it is back
+		l4g.Info("Pinging redis at '%v'", addr)
+		if pong, err := client.Ping().Result(); err != nil {
+			l4g.Critical("Failed to open redis connection to '%v' err:%v", addr, err)
+		} else if pong != "PONG" {
+			l4g.Critical("Failed to ping redis connection to '%v' reply:%v", addr, pong)
 		}
 	}
 
@@ -49,23 +65,161 @@ func RedisClient() *redis.Client {
 func RedisClose() {
 	l4g.Info("Closing redis")
 
+	clientLock.Lock()
+	defer clientLock.Unlock()
+
 	if client != nil {
 		client.Close()
 		client = nil
 	}
 }
 
+func newRedisClient(addr string) *redis.Client {
+	settings := utils.Cfg.RedisSettings
+
+	return redis.NewClient(&redis.Options{
+		Addr:        addr,
+		Dialer:      redisDialer(addr),
+		Password:    settings.Password,
+		DB:          int64(settings.DB),
+		PoolSize:    settings.MaxOpenConns,
+		DialTimeout: REDIS_DIAL_TIMEOUT,
+	})
+}
+
+func redisDialer(addr string) func() (net.Conn, error) {
+	return func() (net.Conn, error) {
+		if utils.Cfg.RedisSettings.UseTLS {
+			return tls.DialWithDialer(&net.Dialer{Timeout: REDIS_DIAL_TIMEOUT}, "tcp", addr, &tls.Config{})
+		}
+
+		return net.DialTimeout("tcp", addr, REDIS_DIAL_TIMEOUT)
+	}
+}
+
+// redisMasterAddr returns the address to connect to, asking the sentinels
+// for the current master when they are configured.
+func redisMasterAddr() (string, error) {
+	settings := utils.Cfg.RedisSettings
+
+	if len(settings.SentinelMasterName) == 0 {
+		return settings.DataSource, nil
+	}
+
+	for _, sentinelAddr := range settings.SentinelAddresses {
+		sentinel := redis.NewClient(&redis.Options{
+			Addr:        sentinelAddr,
+			Dialer:      redisDialer(sentinelAddr),
+			PoolSize:    1,
+			DialTimeout: REDIS_DIAL_TIMEOUT,
+		})
+
+		cmd := redis.NewStringSliceCmd("SENTINEL", "get-master-addr-by-name", settings.SentinelMasterName)
+		sentinel.Process(cmd)
+		sentinel.Close()
+
+		if cmd.Err() != nil {
+			l4g.Warn("Failed to get the redis master from sentinel '%v' err:%v", sentinelAddr, cmd.Err())
+		} else if reply := cmd.Val(); len(reply) == 2 {
+			return net.JoinHostPort(reply[0], reply[1]), nil
+		}
+	}
+
+	return "", fmt.Errorf("no sentinel knows the redis master '%v'", settings.SentinelMasterName)
+}
+
+// watchRedisMaster replaces the client when the sentinels fail the master
+// over. Closing the old client breaks every subscription made through it, so
+// the team hubs resubscribe against the new master.
+func watchRedisMaster(watched *redis.Client) {
+	ticker := time.NewTicker(REDIS_SENTINEL_CHECK_EVERY)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		addr, err := redisMasterAddr()
+
+		clientLock.Lock()
+
+		if client != watched {
+			clientLock.Unlock()
+			return
+		}
+
+		if err != nil || addr == masterAddr {
+			clientLock.Unlock()
+			continue
+		}
+
+		l4g.Warn("Redis master moved from '%v' to '%v'", masterAddr, addr)
+
+		client = newRedisClient(addr)
+		masterAddr = addr
+		watched.Close()
+		watched = client
+
+		clientLock.Unlock()
+	}
+}
+
+func Publish(message *model.Message) *model.AppError {
+	c := RedisClient()
+	result := c.Publish(message.TeamId, message.ToJson())
+	if result.Err() != nil {
+		return model.NewAppError("Publish", "Failed to publish message", "err="+result.Err().Error()+", payload="+message.ToJson())
+	}
+
+	return nil
+}
+
+// Subscribe opens a new pubsub connection for channel and waits for redis
+// to confirm the subscription so a dead connection shows up right away.
+func Subscribe(channel string, timeout time.Duration) (*redis.PubSub, *model.AppError) {
+	pubsub := RedisClient().PubSub()
+
+	if err := pubsub.Subscribe(channel); err != nil {
+		pubsub.Close()
+		return nil, model.NewAppError("Subscribe", "Failed to subscribe", "channel="+channel+", err="+err.Error())
+	}
+
+	if reply, err := pubsub.ReceiveTimeout(timeout); err != nil {
+		pubsub.Close()
+		return nil, model.NewAppError("Subscribe", "Failed to subscribe", "channel="+channel+", err="+err.Error())
+	} else if _, ok := reply.(*redis.Subscription); !ok {
+		pubsub.Close()
+		return nil, model.NewAppError("Subscribe", "Failed to subscribe", "channel="+channel+", unexpected reply")
+	}
+
+	return pubsub, nil
+}
+
 func PublishAndForget(message *model.Message) {
 
 	go func() {
-		c := RedisClient()
-		result := c.Publish(message.TeamId, message.ToJson())
-		if result.Err() != nil {
-			l4g.Error("Failed to publish message err=%v, payload=%v", result.Err(), message.ToJson())
+		if err := Publish(message); err != nil {
+			l4g.Error("Failed to publish message err=%v", err)
 		}
 	}()
 }
 
+// PublishCluster sends message to every app server, including this one.
+func PublishCluster(message *model.ClusterMessage) *model.AppError {
+	if result := RedisClient().Publish(CLUSTER_CHANNEL, message.ToJson()); result.Err() != nil {
+		return model.NewAppError("PublishCluster", "Failed to publish cluster message", "err="+result.Err().Error()+", event="+message.Event)
+	}
+
+	return nil
+}
+
+func GetClusterMessageFromPayload(m interface{}) *model.ClusterMessage {
+	if msg, found := m.(*redis.Message); found {
+		return model.ClusterMessageFromJson(strings.NewReader(msg.Payload))
+	} else {
+		return nil
+	}
+}
+
 func GetMessageFromPayload(m interface{}) *model.Message {
 	if msg, found := m.(*redis.Message); found {
 		return model.MessageFromJson(strings.NewReader(msg.Payload))
@@ -73,3 +227,222 @@ func GetMessageFromPayload(m interface{}) *model.Message {
 		return nil
 	}
 }
+
+// Every process counts its own connections under its own node id so a node
+// that dies doesn't leave its users online, see ReapStatusNodes.
+var statusNodeId = model.NewId()
+
+const (
+	STATUS_NODES_KEY          = "status_nodes"
+	STATUS_UPDATE_MAX_RETRIES = 10
+)
+
+func statusKey(teamId string) string {
+	return "statuses:" + teamId
+}
+
+func statusNodeKey(nodeId string) string {
+	return "status_node:" + nodeId
+}
+
+func statusNodeConnectionsKey(nodeId string) string {
+	return "status_node_conns:" + nodeId
+}
+
+func statusConnectionsField(teamId string, userId string) string {
+	return teamId + ":" + userId
+}
+
+func GetStatus(teamId string, userId string) (*model.Status, *model.AppError) {
+	return getStatus(RedisClient(), teamId, userId)
+}
+
+func getStatus(c *redis.Client, teamId string, userId string) (*model.Status, *model.AppError) {
+	result := c.HGet(statusKey(teamId), userId)
+	if result.Err() == redis.Nil {
+		return &model.Status{UserId: userId, Status: model.USER_OFFLINE}, nil
+	} else if result.Err() != nil {
+		return nil, model.NewAppError("GetStatus", "We couldn't get the status", "user_id="+userId+", err="+result.Err().Error())
+	}
+
+	if status := model.StatusFromJson(strings.NewReader(result.Val())); status != nil {
+		return status, nil
+	}
+
+	return nil, model.NewAppError("GetStatus", "We couldn't decode the status", "user_id="+userId)
+}
+
+func GetStatuses(teamId string) (map[string]*model.Status, *model.AppError) {
+	result := RedisClient().HGetAllMap(statusKey(teamId))
+	if result.Err() != nil {
+		return nil, model.NewAppError("GetStatuses", "We couldn't get the statuses", "team_id="+teamId+", err="+result.Err().Error())
+	}
+
+	statuses := make(map[string]*model.Status)
+	for userId, data := range result.Val() {
+		if status := model.StatusFromJson(strings.NewReader(data)); status != nil {
+			statuses[userId] = status
+		}
+	}
+
+	return statuses, nil
+}
+
+func SaveStatus(teamId string, status *model.Status) *model.AppError {
+	if result := RedisClient().HSet(statusKey(teamId), status.UserId, status.ToJson()); result.Err() != nil {
+		return model.NewAppError("SaveStatus", "We couldn't save the status", "user_id="+status.UserId+", err="+result.Err().Error())
+	}
+
+	return nil
+}
+
+// UpdateStatus applies change to the user's status and saves it, starting
+// over if another node saves a status for the team in between. It returns
+// the status from before the change and the one saved.
+func UpdateStatus(teamId string, userId string, change func(status *model.Status)) (*model.Status, *model.Status, *model.AppError) {
+	tx := RedisClient().Multi()
+	defer tx.Close()
+
+	key := statusKey(teamId)
+	for i := 0; i < STATUS_UPDATE_MAX_RETRIES; i++ {
+		if err := tx.Watch(key).Err(); err != nil {
+			return nil, nil, model.NewAppError("UpdateStatus", "We couldn't update the status", "user_id="+userId+", err="+err.Error())
+		}
+
+		before, err := getStatus(tx.Client, teamId, userId)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		status := *before
+		change(&status)
+
+		if status == *before {
+			return before, &status, nil
+		}
+
+		if _, err := tx.Exec(func() error {
+			tx.HSet(key, userId, status.ToJson())
+			return nil
+		}); err == redis.TxFailedErr {
+			continue
+		} else if err != nil {
+			return nil, nil, model.NewAppError("UpdateStatus", "We couldn't save the status", "user_id="+userId+", err="+err.Error())
+		}
+
+		return before, &status, nil
+	}
+
+	return nil, nil, model.NewAppError("UpdateStatus", "We couldn't save the status, it kept changing", "user_id="+userId)
+}
+
+// StatusHeartbeat marks this node as alive for ttl. Connections counted by a
+// node that stops sending heartbeats no longer count towards anyone's total.
+func StatusHeartbeat(ttl time.Duration) *model.AppError {
+	if result := RedisClient().SAdd(STATUS_NODES_KEY, statusNodeId); result.Err() != nil {
+		return model.NewAppError("StatusHeartbeat", "We couldn't register the node", "node_id="+statusNodeId+", err="+result.Err().Error())
+	}
+
+	if result := RedisClient().SetEx(statusNodeKey(statusNodeId), ttl, strconv.FormatInt(model.GetMillis(), 10)); result.Err() != nil {
+		return model.NewAppError("StatusHeartbeat", "We couldn't record the heartbeat", "node_id="+statusNodeId+", err="+result.Err().Error())
+	}
+
+	return nil
+}
+
+// ReapStatusNodes forgets the connections counted by nodes that stopped
+// sending heartbeats and returns the users they had, as team id to user ids,
+// so their statuses can be worked out again. Only one node reaps each dead
+// node.
+func ReapStatusNodes() (map[string][]string, *model.AppError) {
+	result := RedisClient().SMembers(STATUS_NODES_KEY)
+	if result.Err() != nil {
+		return nil, model.NewAppError("ReapStatusNodes", "We couldn't get the nodes", "err="+result.Err().Error())
+	}
+
+	users := make(map[string][]string)
+	for _, nodeId := range result.Val() {
+		if nodeId == statusNodeId {
+			continue
+		}
+
+		if alive := RedisClient().Exists(statusNodeKey(nodeId)); alive.Err() != nil {
+			return nil, model.NewAppError("ReapStatusNodes", "We couldn't check the node", "node_id="+nodeId+", err="+alive.Err().Error())
+		} else if alive.Val() {
+			continue
+		}
+
+		// Whoever removes the node from the set does the clean up
+		if removed := RedisClient().SRem(STATUS_NODES_KEY, nodeId); removed.Err() != nil {
+			return nil, model.NewAppError("ReapStatusNodes", "We couldn't remove the node", "node_id="+nodeId+", err="+removed.Err().Error())
+		} else if removed.Val() == 0 {
+			continue
+		}
+
+		conns := RedisClient().HGetAllMap(statusNodeConnectionsKey(nodeId))
+		if conns.Err() != nil {
+			return nil, model.NewAppError("ReapStatusNodes", "We couldn't get the node's connections", "node_id="+nodeId+", err="+conns.Err().Error())
+		}
+
+		for field := range conns.Val() {
+			if ids := strings.SplitN(field, ":", 2); len(ids) == 2 {
+				users[ids[0]] = append(users[ids[0]], ids[1])
+			}
+		}
+
+		RedisClient().Del(statusNodeConnectionsKey(nodeId))
+	}
+
+	return users, nil
+}
+
+// IncrementStatusConnections adjusts the number of live connections a user has
+// on this node and returns their new total across every live node.
+func IncrementStatusConnections(teamId string, userId string, delta int64) (int64, *model.AppError) {
+	key := statusNodeConnectionsKey(statusNodeId)
+	field := statusConnectionsField(teamId, userId)
+
+	result := RedisClient().HIncrBy(key, field, delta)
+	if result.Err() != nil {
+		return 0, model.NewAppError("IncrementStatusConnections", "We couldn't update the connection count", "user_id="+userId+", err="+result.Err().Error())
+	}
+
+	if result.Val() <= 0 {
+		RedisClient().HDel(key, field)
+	}
+
+	return GetStatusConnections(teamId, userId)
+}
+
+// GetStatusConnections is the number of live connections a user has across
+// every node that is still sending heartbeats.
+func GetStatusConnections(teamId string, userId string) (int64, *model.AppError) {
+	nodes := RedisClient().SMembers(STATUS_NODES_KEY)
+	if nodes.Err() != nil {
+		return 0, model.NewAppError("GetStatusConnections", "We couldn't get the nodes", "user_id="+userId+", err="+nodes.Err().Error())
+	}
+
+	var total int64
+	for _, nodeId := range nodes.Val() {
+		if alive := RedisClient().Exists(statusNodeKey(nodeId)); alive.Err() != nil {
+			return 0, model.NewAppError("GetStatusConnections", "We couldn't check the node", "node_id="+nodeId+", err="+alive.Err().Error())
+		} else if !alive.Val() {
+			continue
+		}
+
+		result := RedisClient().HGet(statusNodeConnectionsKey(nodeId), statusConnectionsField(teamId, userId))
+		if result.Err() == redis.Nil {
+			continue
+		} else if result.Err() != nil {
+			return 0, model.NewAppError("GetStatusConnections", "We couldn't get the connection count", "user_id="+userId+", err="+result.Err().Error())
+		}
+
+		if count, err := strconv.ParseInt(result.Val(), 10, 64); err != nil {
+			return 0, model.NewAppError("GetStatusConnections", "We couldn't decode the connection count", "user_id="+userId+", err="+err.Error())
+		} else if count > 0 {
+			total += count
+		}
+	}
+
+	return total, nil
+}
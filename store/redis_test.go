@@ -8,6 +8,7 @@ import (
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 	"testing"
+	"time"
 )
 
 func TestRedis(t *testing.T) {
@@ -57,3 +58,119 @@ func TestRedis(t *testing.T) {
 
 	RedisClose()
 }
+
+func TestRedisStatus(t *testing.T) {
+	utils.LoadConfig("config.json")
+
+	teamId := model.NewId()
+	userId := model.NewId()
+
+	if status, err := GetStatus(teamId, userId); err != nil {
+		t.Fatal(err)
+	} else if status.Status != model.USER_OFFLINE || status.UserId != userId {
+		t.Fatal("unknown users should be offline")
+	}
+
+	status := &model.Status{UserId: userId, Status: model.USER_DND, Manual: model.USER_DND}
+	if err := SaveStatus(teamId, status); err != nil {
+		t.Fatal(err)
+	}
+
+	if rstatus, err := GetStatus(teamId, userId); err != nil {
+		t.Fatal(err)
+	} else if *rstatus != *status {
+		t.Fatal("statuses do not match")
+	}
+
+	if statuses, err := GetStatuses(teamId); err != nil {
+		t.Fatal(err)
+	} else if len(statuses) != 1 || statuses[userId].Status != model.USER_DND {
+		t.Fatal("should have returned the saved status")
+	}
+
+	if before, after, err := UpdateStatus(teamId, userId, func(status *model.Status) {
+		status.Manual = ""
+		status.Status = model.USER_ONLINE
+	}); err != nil {
+		t.Fatal(err)
+	} else if before.Status != model.USER_DND || after.Status != model.USER_ONLINE {
+		t.Fatal("should have returned the status before and after")
+	}
+
+	if rstatus, _ := GetStatus(teamId, userId); rstatus.Status != model.USER_ONLINE || rstatus.Manual != "" {
+		t.Fatal("should have saved the updated status")
+	}
+
+	if err := StatusHeartbeat(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := IncrementStatusConnections(teamId, userId, 2); err != nil {
+		t.Fatal(err)
+	} else if count != 2 {
+		t.Fatal("bad connection count")
+	}
+
+	if count, _ := IncrementStatusConnections(teamId, userId, -1); count != 1 {
+		t.Fatal("bad connection count")
+	}
+
+	if count, err := GetStatusConnections(teamId, userId); err != nil {
+		t.Fatal(err)
+	} else if count != 1 {
+		t.Fatal("bad connection count")
+	}
+
+	if count, _ := GetStatusConnections(teamId, model.NewId()); count != 0 {
+		t.Fatal("unknown users should have no connections")
+	}
+
+	// A node that stopped sending heartbeats doesn't count and gets reaped
+	deadNodeId := model.NewId()
+	RedisClient().SAdd(STATUS_NODES_KEY, deadNodeId)
+	RedisClient().HIncrBy(statusNodeConnectionsKey(deadNodeId), statusConnectionsField(teamId, userId), 5)
+
+	if count, _ := GetStatusConnections(teamId, userId); count != 1 {
+		t.Fatal("should have ignored the dead node's connections")
+	}
+
+	if users, err := ReapStatusNodes(); err != nil {
+		t.Fatal(err)
+	} else if len(users[teamId]) != 1 || users[teamId][0] != userId {
+		t.Fatal("should have returned the dead node's users")
+	}
+
+	if users, _ := ReapStatusNodes(); len(users[teamId]) != 0 {
+		t.Fatal("should only reap a node once")
+	}
+
+	if RedisClient().Exists(statusNodeConnectionsKey(deadNodeId)).Val() {
+		t.Fatal("should have removed the dead node's connections")
+	}
+
+	RedisClose()
+}
+
+func TestRedisMasterAddr(t *testing.T) {
+	utils.LoadConfig("config.json")
+
+	if addr, err := redisMasterAddr(); err != nil {
+		t.Fatal(err)
+	} else if addr != utils.Cfg.RedisSettings.DataSource {
+		t.Fatal("should use the data source without sentinels")
+	}
+
+	name := utils.Cfg.RedisSettings.SentinelMasterName
+	addrs := utils.Cfg.RedisSettings.SentinelAddresses
+	defer func() {
+		utils.Cfg.RedisSettings.SentinelMasterName = name
+		utils.Cfg.RedisSettings.SentinelAddresses = addrs
+	}()
+
+	utils.Cfg.RedisSettings.SentinelMasterName = "mymaster"
+	utils.Cfg.RedisSettings.SentinelAddresses = []string{}
+
+	if _, err := redisMasterAddr(); err == nil {
+		t.Fatal("should fail without any sentinel to ask")
+	}
+}
@@ -339,6 +339,34 @@ func (s SqlChannelStore) GetMember(channelId string, userId string) StoreChannel
 	return storeChannel
 }
 
+func (s SqlChannelStore) GetMemberCount(channelId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		count, err := s.GetReplica().SelectInt(
+			`SELECT
+			    COUNT(0)
+			FROM
+			    ChannelMembers, Users
+			WHERE
+			    ChannelMembers.ChannelId = ?
+			        AND Users.Id = ChannelMembers.UserId
+			        AND Users.DeleteAt = 0`, channelId)
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.GetMemberCount", "We couldn't count the channel members", "channel_id="+channelId+", "+err.Error())
+		} else {
+			result.Data = count
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlChannelStore) GetExtraMembers(channelId string, limit int) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -504,6 +532,99 @@ func (s SqlChannelStore) UpdateLastViewedAt(channelId string, userId string) Sto
 	return storeChannel
 }
 
+// MarkUnread moves the member's last viewed time back so everything posted
+// after lastViewedAt counts as unread again.
+func (s SqlChannelStore) MarkUnread(channelId string, userId string, lastViewedAt int64, mentionCount int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		_, err := s.GetMaster().Exec(
+			`UPDATE
+				ChannelMembers, Channels
+			SET
+			    ChannelMembers.MentionCount = ?,
+			    ChannelMembers.MsgCount = GREATEST(Channels.TotalMsgCount - (SELECT
+			            COUNT(*)
+			        FROM
+			            Posts
+			        WHERE
+			            Posts.ChannelId = ?
+			                AND Posts.CreateAt > ?
+			                AND Posts.DeleteAt = 0), 0),
+			    ChannelMembers.LastViewedAt = ?,
+			    ChannelMembers.LastUpdateAt = ?
+			WHERE
+			    Channels.Id = ChannelMembers.ChannelId
+			        AND ChannelMembers.UserId = ?
+			        AND ChannelMembers.ChannelId = ?`,
+			mentionCount, channelId, lastViewedAt, lastViewedAt, model.GetMillis(), userId, channelId)
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.MarkUnread", "We couldn't mark the channel unread", "channel_id="+channelId+", user_id="+userId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+const channelUnreadQuery = `SELECT
+			    Channels.Id AS ChannelId,
+			    GREATEST(Channels.TotalMsgCount - ChannelMembers.MsgCount, 0) AS MsgCount,
+			    ChannelMembers.MentionCount AS MentionCount,
+			    ChannelMembers.LastViewedAt AS LastViewedAt
+			FROM
+			    Channels,
+			    ChannelMembers
+			WHERE
+			    Channels.Id = ChannelMembers.ChannelId
+			        AND ChannelMembers.UserId = ?
+			        AND Channels.DeleteAt = 0`
+
+func (s SqlChannelStore) GetUnread(channelId string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		// Read from the master since this usually follows a write to the counts
+		var unread model.ChannelUnread
+		if err := s.GetMaster().SelectOne(&unread, channelUnreadQuery+" AND Channels.Id = ?", userId, channelId); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.GetUnread", "We couldn't get the unread counts for the channel", "channel_id="+channelId+", user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = &unread
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlChannelStore) GetUnreads(teamId string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var unreads []*model.ChannelUnread
+		if _, err := s.GetReplica().Select(&unreads, channelUnreadQuery+" AND Channels.TeamId = ?", userId, teamId); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.GetUnreads", "We couldn't get the unread counts", "team_id="+teamId+", user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = unreads
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlChannelStore) IncrementMentionCount(channelId string, userId string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -6,6 +6,7 @@ package store
 import (
 	"github.com/mattermost/platform/model"
 	"testing"
+	"time"
 )
 
 func TestChannelStoreSave(t *testing.T) {
@@ -454,6 +455,64 @@ func TestChannelStoreUpdateLastViewedAt(t *testing.T) {
 	}
 }
 
+func TestChannelStoreMarkUnread(t *testing.T) {
+	Setup()
+
+	o1 := model.Channel{}
+	o1.TeamId = model.NewId()
+	o1.DisplayName = "Channel1"
+	o1.Name = "a" + model.NewId() + "b"
+	o1.Type = model.CHANNEL_OPEN
+	o1.TotalMsgCount = 3
+	<-store.Channel().Save(&o1)
+
+	m1 := model.ChannelMember{}
+	m1.ChannelId = o1.Id
+	m1.UserId = model.NewId()
+	m1.NotifyLevel = model.CHANNEL_NOTIFY_ALL
+	<-store.Channel().SaveMember(&m1)
+
+	p1 := &model.Post{ChannelId: o1.Id, UserId: model.NewId(), Message: "a" + model.NewId() + "b"}
+	p1 = (<-store.Post().Save(p1)).Data.(*model.Post)
+	time.Sleep(2 * time.Millisecond)
+
+	p2 := &model.Post{ChannelId: o1.Id, UserId: model.NewId(), Message: "a" + model.NewId() + "b"}
+	p2 = (<-store.Post().Save(p2)).Data.(*model.Post)
+	time.Sleep(2 * time.Millisecond)
+
+	p3 := &model.Post{ChannelId: o1.Id, UserId: model.NewId(), Message: "a" + model.NewId() + "b"}
+	p3 = (<-store.Post().Save(p3)).Data.(*model.Post)
+
+	if err := (<-store.Channel().MarkUnread(o1.Id, m1.UserId, p2.CreateAt-1, 1)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if r := <-store.Channel().GetUnread(o1.Id, m1.UserId); r.Err != nil {
+		t.Fatal(r.Err)
+	} else {
+		unread := r.Data.(*model.ChannelUnread)
+		if unread.MsgCount != 2 || unread.MentionCount != 1 || unread.LastViewedAt != p2.CreateAt-1 {
+			t.Fatal("wrong unread counts", unread.MsgCount, unread.MentionCount)
+		}
+	}
+
+	if r := <-store.Channel().GetUnreads(o1.TeamId, m1.UserId); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if unreads := r.Data.([]*model.ChannelUnread); len(unreads) != 1 || unreads[0].ChannelId != o1.Id {
+		t.Fatal("should have found the channel")
+	}
+
+	if r := <-store.Post().GetPostsSince(o1.Id, p2.CreateAt-1, 100); r.Err != nil {
+		t.Fatal(r.Err)
+	} else if list := r.Data.(*model.PostList); len(list.Order) != 2 || list.Order[0] != p2.Id || list.Order[1] != p3.Id {
+		t.Fatal("should have returned the posts oldest first")
+	}
+
+	if r := <-store.Channel().GetUnread(o1.Id, "missing id"); r.Err == nil {
+		t.Fatal("should have errored for a missing member")
+	}
+}
+
 func TestChannelStoreIncrementMentionCount(t *testing.T) {
 	Setup()
 
@@ -0,0 +1,126 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlDraftStore struct {
+	*SqlStore
+}
+
+func NewSqlDraftStore(sqlStore *SqlStore) DraftStore {
+	s := &SqlDraftStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Draft{}, "Drafts").SetKeys(false, "UserId", "ChannelId", "RootId")
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("ChannelId").SetMaxSize(26)
+		table.ColMap("RootId").SetMaxSize(26)
+		table.ColMap("Message").SetMaxSize(4000)
+		table.ColMap("Filenames").SetMaxSize(4000)
+	}
+
+	return s
+}
+
+func (s SqlDraftStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlDraftStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_channel_id", "Drafts", "ChannelId")
+}
+
+// Upsert saves the draft, replacing the user's existing draft for the same
+// channel and thread but keeping when it was first started.
+func (s SqlDraftStore) Upsert(draft *model.Draft) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		draft.PreSave()
+		if result.Err = draft.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Exec(
+			`INSERT INTO Drafts (UserId, ChannelId, RootId, Message, Filenames, CreateAt, UpdateAt) VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE Message = VALUES(Message), Filenames = VALUES(Filenames), UpdateAt = VALUES(UpdateAt)`,
+			draft.UserId, draft.ChannelId, draft.RootId, draft.Message, model.ArrayToJson(draft.Filenames), draft.CreateAt, draft.UpdateAt); err != nil {
+			result.Err = model.NewAppError("SqlDraftStore.Upsert", "We couldn't save the draft", "user_id="+draft.UserId+", channel_id="+draft.ChannelId+", "+err.Error())
+		} else {
+			var saved model.Draft
+			if err := s.GetMaster().SelectOne(&saved, "SELECT * FROM Drafts WHERE UserId = ? AND ChannelId = ? AND RootId = ?", draft.UserId, draft.ChannelId, draft.RootId); err != nil {
+				result.Err = model.NewAppError("SqlDraftStore.Upsert", "We couldn't get the saved draft", "user_id="+draft.UserId+", channel_id="+draft.ChannelId+", "+err.Error())
+			} else {
+				result.Data = &saved
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Delete removes the draft, Data is the number of drafts that were removed
+// so callers can tell whether there was anything to delete.
+func (s SqlDraftStore) Delete(userId string, channelId string, rootId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if res, err := s.GetMaster().Exec("DELETE FROM Drafts WHERE UserId = ? AND ChannelId = ? AND RootId = ?", userId, channelId, rootId); err != nil {
+			result.Err = model.NewAppError("SqlDraftStore.Delete", "We couldn't delete the draft", "user_id="+userId+", channel_id="+channelId+", "+err.Error())
+		} else {
+			rows, _ := res.RowsAffected()
+			result.Data = rows
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetForUser returns the user's drafts on the team, most recently edited
+// first. Drafts in channels the user has since left are left out.
+func (s SqlDraftStore) GetForUser(teamId string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var drafts []*model.Draft
+		if _, err := s.GetReplica().Select(&drafts,
+			`SELECT
+			    Drafts.*
+			FROM
+			    Drafts, Channels, ChannelMembers
+			WHERE
+			    Drafts.UserId = ?
+			        AND Channels.Id = Drafts.ChannelId
+			        AND Channels.TeamId = ?
+			        AND Channels.DeleteAt = 0
+			        AND ChannelMembers.ChannelId = Channels.Id
+			        AND ChannelMembers.UserId = Drafts.UserId
+			ORDER BY Drafts.UpdateAt DESC`, userId, teamId); err != nil {
+			result.Err = model.NewAppError("SqlDraftStore.GetForUser", "We couldn't get the drafts", "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = drafts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+	"testing"
+	"time"
+)
+
+func TestDraftStore(t *testing.T) {
+	Setup()
+
+	c1 := model.Channel{}
+	c1.TeamId = model.NewId()
+	c1.DisplayName = "Channel1"
+	c1.Name = "a" + model.NewId() + "b"
+	c1.Type = model.CHANNEL_OPEN
+	<-store.Channel().Save(&c1)
+
+	c2 := model.Channel{}
+	c2.TeamId = c1.TeamId
+	c2.DisplayName = "Channel2"
+	c2.Name = "a" + model.NewId() + "b"
+	c2.Type = model.CHANNEL_OPEN
+	<-store.Channel().Save(&c2)
+
+	m1 := model.ChannelMember{}
+	m1.ChannelId = c1.Id
+	m1.UserId = model.NewId()
+	m1.NotifyLevel = model.CHANNEL_NOTIFY_ALL
+	<-store.Channel().SaveMember(&m1)
+
+	d1 := &model.Draft{UserId: m1.UserId, ChannelId: c1.Id, Message: "first"}
+	if result := <-store.Draft().Upsert(d1); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		d1 = result.Data.(*model.Draft)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	if result := <-store.Draft().Upsert(&model.Draft{UserId: m1.UserId, ChannelId: c1.Id, Message: "second", Filenames: []string{"/a.png"}}); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if d := result.Data.(*model.Draft); d.Message != "second" || len(d.Filenames) != 1 || d.CreateAt != d1.CreateAt || d.UpdateAt == d1.UpdateAt {
+		t.Fatal("should have replaced the draft but kept its create at")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	rootId := model.NewId()
+	<-store.Draft().Upsert(&model.Draft{UserId: m1.UserId, ChannelId: c1.Id, RootId: rootId, Message: "reply"})
+
+	// c2 is a channel the user isn't a member of
+	<-store.Draft().Upsert(&model.Draft{UserId: m1.UserId, ChannelId: c2.Id, Message: "elsewhere"})
+
+	if result := <-store.Draft().GetForUser(c1.TeamId, m1.UserId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if drafts := result.Data.([]*model.Draft); len(drafts) != 2 || drafts[0].RootId != rootId {
+		t.Fatal("should have returned the channel and thread drafts, newest first")
+	}
+
+	if result := <-store.Draft().Delete(m1.UserId, c1.Id, ""); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(int64) != 1 {
+		t.Fatal("should have deleted the draft")
+	}
+
+	if result := <-store.Draft().Delete(m1.UserId, c1.Id, ""); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(int64) != 0 {
+		t.Fatal("there was nothing left to delete")
+	}
+
+	if result := <-store.Draft().GetForUser(c1.TeamId, m1.UserId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if drafts := result.Data.([]*model.Draft); len(drafts) != 1 || drafts[0].RootId != rootId {
+		t.Fatal("only the thread draft should be left")
+	}
+
+	if err := (<-store.Draft().Upsert(&model.Draft{UserId: m1.UserId, ChannelId: "junk"})).Err; err == nil {
+		t.Fatal("should have failed validation")
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+	"strings"
+)
+
+type SqlGroupStore struct {
+	*SqlStore
+}
+
+// The tables are UserGroups and UserGroupMembers since GROUPS is a reserved
+// word in newer versions of MySQL.
+func NewSqlGroupStore(sqlStore *SqlStore) GroupStore {
+	s := &SqlGroupStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Group{}, "UserGroups").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("TeamId").SetMaxSize(26)
+		table.ColMap("Name").SetMaxSize(64)
+		table.SetUniqueTogether("Name", "TeamId")
+		table.ColMap("DisplayName").SetMaxSize(64)
+		table.ColMap("Description").SetMaxSize(1024)
+
+		tablem := db.AddTableWithName(model.GroupMember{}, "UserGroupMembers").SetKeys(false, "GroupId", "UserId")
+		tablem.ColMap("GroupId").SetMaxSize(26)
+		tablem.ColMap("UserId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlGroupStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlGroupStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_team_id", "UserGroups", "TeamId")
+	s.CreateIndexIfNotExists("idx_user_id", "UserGroupMembers", "UserId")
+}
+
+func (s SqlGroupStore) Save(group *model.Group) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(group.Id) > 0 {
+			result.Err = model.NewAppError("SqlGroupStore.Save", "Must call update for exisiting group", "id="+group.Id)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		group.PreSave()
+		if result.Err = group.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(group); err != nil {
+			if strings.Contains(err.Error(), "Duplicate entry") && strings.Contains(err.Error(), "for key 'Name'") {
+				result.Err = model.NewAppError("SqlGroupStore.Save", "A group with that name already exists", "id="+group.Id+", "+err.Error())
+			} else {
+				result.Err = model.NewAppError("SqlGroupStore.Save", "We couldn't save the group", "id="+group.Id+", "+err.Error())
+			}
+		} else {
+			result.Data = group
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlGroupStore) Update(group *model.Group) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		group.PreUpdate()
+		if result.Err = group.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := s.GetMaster().Update(group); err != nil {
+			if strings.Contains(err.Error(), "Duplicate entry") && strings.Contains(err.Error(), "for key 'Name'") {
+				result.Err = model.NewAppError("SqlGroupStore.Update", "A group with that name already exists", "id="+group.Id+", "+err.Error())
+			} else {
+				result.Err = model.NewAppError("SqlGroupStore.Update", "We encounted an error updating the group", "id="+group.Id+", "+err.Error())
+			}
+		} else if count != 1 {
+			result.Err = model.NewAppError("SqlGroupStore.Update", "We couldn't update the group", "id="+group.Id)
+		} else {
+			result.Data = group
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlGroupStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if obj, err := s.GetReplica().Get(model.Group{}, id); err != nil {
+			result.Err = model.NewAppError("SqlGroupStore.Get", "We encounted an error finding the group", "id="+id+", "+err.Error())
+		} else if obj == nil {
+			result.Err = model.NewAppError("SqlGroupStore.Get", "We couldn't find the existing group", "id="+id)
+		} else {
+			result.Data = obj.(*model.Group)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlGroupStore) GetForTeam(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var groups []*model.Group
+		if _, err := s.GetReplica().Select(&groups, "SELECT * FROM UserGroups WHERE TeamId = ? ORDER BY Name", teamId); err != nil {
+			result.Err = model.NewAppError("SqlGroupStore.GetForTeam", "We couldn't get the groups", "team_id="+teamId+", "+err.Error())
+		} else {
+			result.Data = groups
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlGroupStore) GetByName(teamId string, name string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		group := model.Group{}
+
+		if err := s.GetReplica().SelectOne(&group, "SELECT * FROM UserGroups WHERE TeamId = ? AND Name = ?", teamId, name); err != nil {
+			result.Err = model.NewAppError("SqlGroupStore.GetByName", "We couldn't find the existing group", "team_id="+teamId+", name="+name+", "+err.Error())
+		} else {
+			result.Data = &group
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Delete removes the group along with its members.
+func (s SqlGroupStore) Delete(id string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM UserGroupMembers WHERE GroupId = ?", id); err != nil {
+			result.Err = model.NewAppError("SqlGroupStore.Delete", "We couldn't delete the group members", "id="+id+", "+err.Error())
+		} else if _, err := s.GetMaster().Exec("DELETE FROM UserGroups WHERE Id = ?", id); err != nil {
+			result.Err = model.NewAppError("SqlGroupStore.Delete", "We couldn't delete the group", "id="+id+", "+err.Error())
+		} else {
+			result.Data = id
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlGroupStore) SaveMember(member *model.GroupMember) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		member.PreSave()
+		if result.Err = member.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(member); err != nil {
+			if strings.Contains(err.Error(), "Duplicate entry") {
+				result.Err = model.NewAppError("SqlGroupStore.SaveMember", "The user is already a member of the group", "group_id="+member.GroupId+", user_id="+member.UserId+", "+err.Error())
+			} else {
+				result.Err = model.NewAppError("SqlGroupStore.SaveMember", "We couldn't add the user to the group", "group_id="+member.GroupId+", user_id="+member.UserId+", "+err.Error())
+			}
+		} else {
+			result.Data = member
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlGroupStore) RemoveMember(groupId string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM UserGroupMembers WHERE GroupId = ? AND UserId = ?", groupId, userId); err != nil {
+			result.Err = model.NewAppError("SqlGroupStore.RemoveMember", "We couldn't remove the user from the group", "group_id="+groupId+", user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = userId
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetMembers returns the ids of the users in the group.
+func (s SqlGroupStore) GetMembers(groupId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var userIds []string
+		if _, err := s.GetReplica().Select(&userIds, "SELECT UserId FROM UserGroupMembers WHERE GroupId = ?", groupId); err != nil {
+			result.Err = model.NewAppError("SqlGroupStore.GetMembers", "We couldn't get the group members", "group_id="+groupId+", "+err.Error())
+		} else {
+			result.Data = userIds
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetMentionMap returns the members of each of the team's groups keyed by
+// group name, the shape notifications want for matching @group mentions.
+func (s SqlGroupStore) GetMentionMap(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var rows []struct {
+			Name   string
+			UserId string
+		}
+		if _, err := s.GetReplica().Select(&rows,
+			`SELECT
+			    UserGroups.Name, UserGroupMembers.UserId
+			FROM
+			    UserGroups, UserGroupMembers
+			WHERE
+			    UserGroups.TeamId = ?
+			        AND UserGroupMembers.GroupId = UserGroups.Id`, teamId); err != nil {
+			result.Err = model.NewAppError("SqlGroupStore.GetMentionMap", "We couldn't get the group members", "team_id="+teamId+", "+err.Error())
+		} else {
+			mentionMap := make(map[string][]string)
+			for _, row := range rows {
+				mentionMap[row.Name] = append(mentionMap[row.Name], row.UserId)
+			}
+
+			result.Data = mentionMap
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
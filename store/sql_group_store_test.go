@@ -0,0 +1,109 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+	"testing"
+)
+
+func TestGroupStore(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+
+	g1 := &model.Group{TeamId: teamId, Name: "backend", DisplayName: "Backend"}
+	if result := <-store.Group().Save(g1); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if err := (<-store.Group().Save(&model.Group{TeamId: teamId, Name: "backend"})).Err; err == nil {
+		t.Fatal("should not allow two groups with the same name on a team")
+	}
+
+	if err := (<-store.Group().Save(&model.Group{TeamId: model.NewId(), Name: "backend"})).Err; err != nil {
+		t.Fatal("another team can use the same name", err)
+	}
+
+	g2 := &model.Group{TeamId: teamId, Name: "oncall", DisplayName: "On Call"}
+	<-store.Group().Save(g2)
+
+	g2.Name = "backend"
+	if err := (<-store.Group().Update(g2)).Err; err == nil {
+		t.Fatal("should not allow renaming onto another group")
+	}
+
+	g2.Name = "on-call"
+	if err := (<-store.Group().Update(g2)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-store.Group().Get(g2.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.Group).Name != "on-call" {
+		t.Fatal("should have been renamed")
+	}
+
+	if result := <-store.Group().GetForTeam(teamId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if groups := result.Data.([]*model.Group); len(groups) != 2 || groups[0].Id != g1.Id {
+		t.Fatal("should have returned the team's groups sorted by name")
+	}
+
+	if result := <-store.Group().GetByName(teamId, "on-call"); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.Group).Id != g2.Id {
+		t.Fatal("should have found the group by name")
+	}
+
+	if err := (<-store.Group().GetByName(model.NewId(), "on-call")).Err; err == nil {
+		t.Fatal("should not find another team's group")
+	}
+
+	userId1 := model.NewId()
+	userId2 := model.NewId()
+
+	if err := (<-store.Group().SaveMember(&model.GroupMember{GroupId: g1.Id, UserId: userId1})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (<-store.Group().SaveMember(&model.GroupMember{GroupId: g1.Id, UserId: userId1})).Err; err == nil {
+		t.Fatal("should not add the same member twice")
+	}
+
+	<-store.Group().SaveMember(&model.GroupMember{GroupId: g1.Id, UserId: userId2})
+	<-store.Group().SaveMember(&model.GroupMember{GroupId: g2.Id, UserId: userId2})
+
+	if result := <-store.Group().GetMembers(g1.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]string)) != 2 {
+		t.Fatal("should have had two members")
+	}
+
+	if result := <-store.Group().GetMentionMap(teamId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if mentionMap := result.Data.(map[string][]string); len(mentionMap["backend"]) != 2 || len(mentionMap["on-call"]) != 1 {
+		t.Fatal("wrong mention map")
+	}
+
+	<-store.Group().RemoveMember(g1.Id, userId1)
+
+	if result := <-store.Group().GetMembers(g1.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if userIds := result.Data.([]string); len(userIds) != 1 || userIds[0] != userId2 {
+		t.Fatal("should have removed the member")
+	}
+
+	if err := (<-store.Group().Delete(g1.Id)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (<-store.Group().Get(g1.Id)).Err; err == nil {
+		t.Fatal("should have deleted the group")
+	}
+
+	if result := <-store.Group().GetMembers(g1.Id); len(result.Data.([]string)) != 0 {
+		t.Fatal("should have deleted the members")
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	l4g "code.google.com/p/log4go"
+	"github.com/mattermost/platform/model"
+)
+
+const (
+	POST_MENTION_BACKFILL_PAGE_SIZE = 1000
+)
+
+type SqlPostMentionStore struct {
+	*SqlStore
+}
+
+func NewSqlPostMentionStore(sqlStore *SqlStore) PostMentionStore {
+	s := &SqlPostMentionStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.PostMention{}, "PostMentions").SetKeys(false, "PostId", "UserId")
+		table.ColMap("PostId").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("ChannelId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlPostMentionStore) UpgradeSchemaIfNeeded() {
+	// The index only fills up as posts are made, so the first time a server
+	// with history starts with it the existing posts are indexed as well
+	if count, err := s.GetMaster().SelectInt("SELECT COUNT(*) FROM PostMentions"); err != nil || count > 0 {
+		return
+	}
+
+	if count, err := s.GetMaster().SelectInt("SELECT COUNT(*) FROM Posts"); err == nil && count > 0 {
+		go s.backfillMentions()
+	}
+}
+
+// backfillMentions indexes the mentions in posts made before the index
+// existed. It parses them the way notifications do against the channel's
+// current members, leaving out @here since nobody knows who was online.
+func (s SqlPostMentionStore) backfillMentions() {
+	l4g.Info("Backfilling the mentions index")
+
+	var channels []*model.Channel
+	if _, err := s.GetMaster().Select(&channels, "SELECT * FROM Channels WHERE Type != ? AND DeleteAt = 0", model.CHANNEL_DIRECT); err != nil {
+		l4g.Error("Failed to backfill the mentions index err=%v", err)
+		return
+	}
+
+	for _, channel := range channels {
+		if err := s.backfillChannelMentions(channel); err != nil {
+			l4g.Error("Failed to backfill the mentions index channel_id=%v err=%v", channel.Id, err)
+		}
+	}
+
+	l4g.Info("Finished backfilling the mentions index")
+}
+
+func (s SqlPostMentionStore) backfillChannelMentions(channel *model.Channel) error {
+	var members []*model.User
+	if _, err := s.GetMaster().Select(&members,
+		`SELECT
+		    Users.*
+		FROM
+		    Users, ChannelMembers
+		WHERE
+		    ChannelMembers.ChannelId = ?
+		        AND Users.Id = ChannelMembers.UserId`, channel.Id); err != nil {
+		return err
+	}
+
+	var groups []struct {
+		Name   string
+		UserId string
+	}
+	if _, err := s.GetMaster().Select(&groups,
+		`SELECT
+		    UserGroups.Name, UserGroupMembers.UserId
+		FROM
+		    UserGroups, UserGroupMembers, ChannelMembers
+		WHERE
+		    UserGroups.TeamId = ?
+		        AND UserGroupMembers.GroupId = UserGroups.Id
+		        AND ChannelMembers.ChannelId = ?
+		        AND ChannelMembers.UserId = UserGroupMembers.UserId`, channel.TeamId, channel.Id); err != nil {
+		return err
+	}
+
+	parser := model.NewMentionParser()
+	for _, member := range members {
+		parser.AddUser(member)
+	}
+
+	groupMembers := make(map[string][]string)
+	for _, group := range groups {
+		groupMembers[group.Name] = append(groupMembers[group.Name], group.UserId)
+	}
+	for name, userIds := range groupMembers {
+		parser.AddGroup(name, userIds)
+	}
+
+	for offset := 0; ; offset += POST_MENTION_BACKFILL_PAGE_SIZE {
+		var posts []*model.Post
+		if _, err := s.GetMaster().Select(&posts, "SELECT * FROM Posts WHERE ChannelId = ? AND DeleteAt = 0 ORDER BY CreateAt LIMIT ? OFFSET ?",
+			channel.Id, POST_MENTION_BACKFILL_PAGE_SIZE, offset); err != nil {
+			return err
+		}
+
+		for _, post := range posts {
+			if post.IsSystemMessage() {
+				continue
+			}
+
+			results := parser.Parse(post.Message)
+			var mentionIds []string
+			for _, member := range members {
+				if member.Id != post.UserId && results.IsMentioned(member, false) {
+					mentionIds = append(mentionIds, member.Id)
+				}
+			}
+
+			if len(mentionIds) > 0 {
+				if result := <-s.Save(post, mentionIds); result.Err != nil {
+					return result.Err
+				}
+			}
+		}
+
+		if len(posts) < POST_MENTION_BACKFILL_PAGE_SIZE {
+			return nil
+		}
+	}
+}
+
+func (s SqlPostMentionStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_user_id_create_at", "PostMentions", "UserId, CreateAt")
+}
+
+// Save replaces who the post mentioned with userIds. Every write only applies
+// while the post still has post.Message and isn't deleted, so when an edit
+// races the first save whichever runs last can't bring back stale mentions.
+// The message is compared rather than UpdateAt since replies and pins bump
+// UpdateAt without changing who the post mentions.
+func (s SqlPostMentionStore) Save(post *model.Post, userIds []string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		for _, userId := range userIds {
+			mention := &model.PostMention{PostId: post.Id, UserId: userId, ChannelId: post.ChannelId, CreateAt: post.CreateAt}
+			if result.Err = mention.IsValid(); result.Err != nil {
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+		}
+
+		if _, err := s.GetMaster().Exec(
+			`DELETE FROM PostMentions
+			WHERE
+			    PostId = ?
+			        AND EXISTS (SELECT 1 FROM Posts WHERE Id = ? AND Message = ? AND DeleteAt = 0)`, post.Id, post.Id, post.Message); err != nil {
+			result.Err = model.NewAppError("SqlPostMentionStore.Save", "We couldn't save the mention", "post_id="+post.Id+", "+err.Error())
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		for _, userId := range userIds {
+			if _, err := s.GetMaster().Exec(
+				`INSERT IGNORE INTO PostMentions (PostId, UserId, ChannelId, CreateAt)
+				SELECT ?, ?, ?, ? FROM Posts WHERE Id = ? AND Message = ? AND DeleteAt = 0`,
+				post.Id, userId, post.ChannelId, post.CreateAt, post.Id, post.Message); err != nil {
+				result.Err = model.NewAppError("SqlPostMentionStore.Save", "We couldn't save the mention", "post_id="+post.Id+", user_id="+userId+", "+err.Error())
+				break
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteForPost forgets who the post mentioned along with the mentions in its
+// replies, the way deleting a post deletes its replies.
+func (s SqlPostMentionStore) DeleteForPost(postId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec(
+			`DELETE PostMentions FROM PostMentions, Posts
+			WHERE
+			    Posts.Id = PostMentions.PostId
+			        AND (Posts.Id = ? OR Posts.ParentId = ? OR Posts.RootId = ?)`, postId, postId, postId); err != nil {
+			result.Err = model.NewAppError("SqlPostMentionStore.DeleteForPost", "We couldn't remove the mentions", "post_id="+postId+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetMentions returns the posts that mentioned the user, newest first, from
+// the channels on the team they're still a member of.
+func (s SqlPostMentionStore) GetMentions(teamId string, userId string, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if limit > 1000 {
+			result.Err = model.NewAppError("SqlPostMentionStore.GetMentions", "Limit exceeded for paging", "user_id="+userId)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts,
+			`SELECT
+			    Posts.*
+			FROM
+			    PostMentions, Posts, Channels, ChannelMembers
+			WHERE
+			    PostMentions.UserId = ?
+			        AND Posts.Id = PostMentions.PostId
+			        AND Posts.DeleteAt = 0
+			        AND Channels.Id = PostMentions.ChannelId
+			        AND Channels.TeamId = ?
+			        AND Channels.DeleteAt = 0
+			        AND ChannelMembers.ChannelId = Channels.Id
+			        AND ChannelMembers.UserId = PostMentions.UserId
+			ORDER BY PostMentions.CreateAt DESC
+			LIMIT ? OFFSET ?`, userId, teamId, limit, offset); err != nil {
+			result.Err = model.NewAppError("SqlPostMentionStore.GetMentions", "We couldn't get the mentions", "user_id="+userId+", "+err.Error())
+		} else {
+			list := &model.PostList{Order: make([]string, 0, len(posts))}
+
+			for _, p := range posts {
+				list.AddPost(p)
+				list.AddOrder(p.Id)
+			}
+
+			if err := s.addReactionSummaries(list); err != nil {
+				result.Err = err
+			}
+
+			list.MakeNonNil()
+
+			result.Data = list
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+	"testing"
+	"time"
+)
+
+func TestPostMentionStore(t *testing.T) {
+	Setup()
+
+	c1 := model.Channel{}
+	c1.TeamId = model.NewId()
+	c1.DisplayName = "Channel1"
+	c1.Name = "a" + model.NewId() + "b"
+	c1.Type = model.CHANNEL_OPEN
+	<-store.Channel().Save(&c1)
+
+	c2 := model.Channel{}
+	c2.TeamId = c1.TeamId
+	c2.DisplayName = "Channel2"
+	c2.Name = "a" + model.NewId() + "b"
+	c2.Type = model.CHANNEL_OPEN
+	<-store.Channel().Save(&c2)
+
+	m1 := model.ChannelMember{}
+	m1.ChannelId = c1.Id
+	m1.UserId = model.NewId()
+	m1.NotifyLevel = model.CHANNEL_NOTIFY_ALL
+	<-store.Channel().SaveMember(&m1)
+
+	p1 := &model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "a" + model.NewId() + "b"}
+	p1 = (<-store.Post().Save(p1)).Data.(*model.Post)
+
+	time.Sleep(10 * time.Millisecond)
+
+	p2 := &model.Post{ChannelId: c1.Id, UserId: model.NewId(), Message: "a" + model.NewId() + "b"}
+	p2 = (<-store.Post().Save(p2)).Data.(*model.Post)
+
+	p3 := &model.Post{ChannelId: c2.Id, UserId: model.NewId(), Message: "a" + model.NewId() + "b"}
+	p3 = (<-store.Post().Save(p3)).Data.(*model.Post)
+
+	if err := (<-store.PostMention().Save(p1, []string{m1.UserId})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving the same mention again is fine
+	if err := (<-store.PostMention().Save(p1, []string{m1.UserId})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (<-store.PostMention().Save(p2, []string{m1.UserId, model.NewId()})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (<-store.PostMention().Save(p2, []string{"junk"})).Err; err == nil {
+		t.Fatal("should have failed on a bad user id")
+	}
+
+	<-store.PostMention().Save(p3, []string{m1.UserId})
+
+	// p3 is in a channel the user isn't a member of
+	if result := <-store.PostMention().GetMentions(c1.TeamId, m1.UserId, 0, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if list := result.Data.(*model.PostList); len(list.Order) != 2 || list.Order[0] != p2.Id || list.Order[1] != p1.Id {
+		t.Fatal("should return the mentions the user can read, newest first")
+	}
+
+	if list := (<-store.PostMention().GetMentions(c1.TeamId, m1.UserId, 1, 1)).Data.(*model.PostList); len(list.Order) != 1 || list.Order[0] != p1.Id {
+		t.Fatal("should have paged the mentions")
+	}
+
+	if err := (<-store.PostMention().GetMentions(c1.TeamId, m1.UserId, 0, 1001)).Err; err == nil {
+		t.Fatal("should have failed on the paging limit")
+	}
+
+	<-store.Post().Delete(p2.Id, model.GetMillis())
+
+	if list := (<-store.PostMention().GetMentions(c1.TeamId, m1.UserId, 0, 10)).Data.(*model.PostList); len(list.Order) != 1 || list.Order[0] != p1.Id {
+		t.Fatal("should have left out the deleted post")
+	}
+
+	p4 := &model.Post{ChannelId: c1.Id, UserId: model.NewId(), RootId: p1.Id, ParentId: p1.Id, Message: "a" + model.NewId() + "b"}
+	p4 = (<-store.Post().Save(p4)).Data.(*model.Post)
+	<-store.PostMention().Save(p4, []string{m1.UserId})
+
+	// A save for a message the post no longer has is ignored
+	stale := *p1
+	stale.Message = "a" + model.NewId() + "b"
+	if err := (<-store.PostMention().Save(&stale, []string{})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if list := (<-store.PostMention().GetMentions(c1.TeamId, m1.UserId, 0, 10)).Data.(*model.PostList); len(list.Order) != 2 {
+		t.Fatal("should have ignored the stale save")
+	}
+
+	// Saving again replaces the post's own mentions
+	if err := (<-store.PostMention().Save(p1, []string{})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if list := (<-store.PostMention().GetMentions(c1.TeamId, m1.UserId, 0, 10)).Data.(*model.PostList); len(list.Order) != 1 || list.Order[0] != p4.Id {
+		t.Fatal("should have only removed the post's own mentions")
+	}
+
+	<-store.PostMention().Save(p1, []string{m1.UserId})
+
+	if err := (<-store.PostMention().DeleteForPost(p1.Id)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if list := (<-store.PostMention().GetMentions(c1.TeamId, m1.UserId, 0, 10)).Data.(*model.PostList); len(list.Order) != 0 {
+		t.Fatal("should have removed the mentions in the replies too")
+	}
+
+	// Leaving the channel hides its mentions
+	<-store.PostMention().Save(p1, []string{m1.UserId})
+	<-store.Channel().RemoveMember(c1.Id, m1.UserId)
+
+	if list := (<-store.PostMention().GetMentions(c1.TeamId, m1.UserId, 0, 10)).Data.(*model.PostList); len(list.Order) != 0 {
+		t.Fatal("should have left out channels the user left")
+	}
+}
+
+func TestPostMentionBackfill(t *testing.T) {
+	Setup()
+
+	c1 := model.Channel{}
+	c1.TeamId = model.NewId()
+	c1.DisplayName = "Channel1"
+	c1.Name = "a" + model.NewId() + "b"
+	c1.Type = model.CHANNEL_OPEN
+	<-store.Channel().Save(&c1)
+
+	u1 := model.User{}
+	u1.TeamId = c1.TeamId
+	u1.Email = model.NewId()
+	u1.FullName = model.NewId()
+	<-store.User().Save(&u1)
+
+	u2 := model.User{}
+	u2.TeamId = c1.TeamId
+	u2.Email = model.NewId()
+	u2.FullName = model.NewId()
+	<-store.User().Save(&u2)
+
+	<-store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: u1.Id, NotifyLevel: model.CHANNEL_NOTIFY_ALL})
+	<-store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: u2.Id, NotifyLevel: model.CHANNEL_NOTIFY_ALL})
+
+	p1 := &model.Post{ChannelId: c1.Id, UserId: u1.Id, Message: "hey @" + u2.Username}
+	p1 = (<-store.Post().Save(p1)).Data.(*model.Post)
+
+	p2 := &model.Post{ChannelId: c1.Id, UserId: u2.Id, Message: "talking to myself @" + u2.Username}
+	p2 = (<-store.Post().Save(p2)).Data.(*model.Post)
+
+	if err := store.PostMention().(*SqlPostMentionStore).backfillChannelMentions(&c1); err != nil {
+		t.Fatal(err)
+	}
+
+	if list := (<-store.PostMention().GetMentions(c1.TeamId, u2.Id, 0, 10)).Data.(*model.PostList); len(list.Order) != 1 || list.Order[0] != p1.Id {
+		t.Fatal("should have indexed the mention in the existing post")
+	}
+}
@@ -4,6 +4,7 @@
 package store
 
 import (
+	l4g "code.google.com/p/log4go"
 	"fmt"
 	"github.com/mattermost/platform/model"
 	"strconv"
@@ -29,12 +30,19 @@ func NewSqlPostStore(sqlStore *SqlStore) PostStore {
 		table.ColMap("Hashtags").SetMaxSize(1000)
 		table.ColMap("Props").SetMaxSize(4000)
 		table.ColMap("Filenames").SetMaxSize(4000)
+		table.ColMap("AttachmentText").SetMaxSize(4000)
 	}
 
 	return s
 }
 
 func (s SqlPostStore) UpgradeSchemaIfNeeded() {
+	s.CreateColumnIfNotExists("Posts", "IsPinned", "Filenames", "tinyint(1)", "0")
+	s.CreateColumnIfNotExists("Posts", "ReplyCount", "IsPinned", "bigint(20)", "0")
+	if s.CreateColumnIfNotExists("Posts", "LastReplyAt", "ReplyCount", "bigint(20)", "0") {
+		s.backfillThreadMetadata()
+	}
+	s.CreateColumnIfNotExists("Posts", "AttachmentText", "LastReplyAt", "varchar(4000)", "")
 }
 
 func (s SqlPostStore) CreateIndexesIfNotExists() {
@@ -42,9 +50,11 @@ func (s SqlPostStore) CreateIndexesIfNotExists() {
 	s.CreateIndexIfNotExists("idx_create_at", "Posts", "CreateAt")
 	s.CreateIndexIfNotExists("idx_channel_id", "Posts", "ChannelId")
 	s.CreateIndexIfNotExists("idx_root_id", "Posts", "RootId")
+	s.CreateIndexIfNotExists("idx_is_pinned", "Posts", "IsPinned")
 
 	s.CreateFullTextIndexIfNotExists("idx_message_txt", "Posts", "Message")
 	s.CreateFullTextIndexIfNotExists("idx_hashtags_txt", "Posts", "Hashtags")
+	s.CreateFullTextIndexIfNotExists("idx_message_attachment_txt", "Posts", "Message, AttachmentText")
 }
 
 func (s SqlPostStore) Save(post *model.Post) StoreChannel {
@@ -75,7 +85,7 @@ func (s SqlPostStore) Save(post *model.Post) StoreChannel {
 			s.GetMaster().Exec("UPDATE Channels SET LastPostAt = ?, TotalMsgCount = TotalMsgCount + 1  WHERE Id = ?", time, post.ChannelId)
 
 			if len(post.RootId) > 0 {
-				s.GetMaster().Exec("UPDATE Posts SET UpdateAt = ? WHERE Id = ?", time, post.RootId)
+				s.GetMaster().Exec("UPDATE Posts SET UpdateAt = ?, ReplyCount = ReplyCount + 1, LastReplyAt = ? WHERE Id = ?", time, post.CreateAt, post.RootId)
 			}
 
 			result.Data = post
@@ -123,6 +133,12 @@ func (s SqlPostStore) Update(oldPost *model.Post, newMessage string, newHashtags
 			// mark the old post as deleted
 			s.GetMaster().Insert(oldPost)
 
+			// The edit wrote back the thread metadata read before it, so catch
+			// up on any replies that landed in between
+			if len(editPost.RootId) == 0 {
+				s.updateThreadMetadata(editPost.Id)
+			}
+
 			result.Data = &editPost
 		}
 
@@ -173,6 +189,10 @@ func (s SqlPostStore) Get(id string) StoreChannel {
 			}
 		}
 
+		if result.Err == nil {
+			result.Err = s.addReactionSummaries(pl)
+		}
+
 		result.Data = pl
 
 		storeChannel <- result
@@ -214,9 +234,13 @@ func (s SqlPostStore) Delete(postId string, time int64) StoreChannel {
 	go func() {
 		result := StoreResult{}
 
+		rootId, _ := s.GetReplica().SelectStr("SELECT RootId FROM Posts WHERE Id = ?", postId)
+
 		_, err := s.GetMaster().Exec("Update Posts SET DeleteAt = ?, UpdateAt = ? WHERE Id = ? OR ParentId = ? OR RootId = ?", time, time, postId, postId, postId)
 		if err != nil {
 			result.Err = model.NewAppError("SqlPostStore.Delete", "We couldn't delete the post", "id="+postId+", err="+err.Error())
+		} else if len(rootId) > 0 {
+			s.updateThreadMetadata(rootId)
 		}
 
 		storeChannel <- result
@@ -275,6 +299,10 @@ func (s SqlPostStore) GetPosts(channelId string, offset int, limit int) StoreCha
 				list.AddPost(p)
 			}
 
+			if err := s.addReactionSummaries(list); err != nil {
+				result.Err = err
+			}
+
 			list.MakeNonNil()
 
 			result.Data = list
@@ -358,7 +386,8 @@ func (s SqlPostStore) Search(teamId string, userId string, terms string, isHasht
 		result := StoreResult{}
 		termMap := map[string]bool{}
 
-		searchType := "Message"
+		// Text search covers the plain text of any attachments as well
+		searchType := "Message, AttachmentText"
 		if isHashtagSearch {
 			searchType = "Hashtags"
 			for _,term := range strings.Split(terms, " ") {
@@ -414,13 +443,235 @@ func (s SqlPostStore) Search(teamId string, userId string, terms string, isHasht
 				list.AddOrder(p.Id)
 			}
 
+			if err := s.addReactionSummaries(list); err != nil {
+				result.Err = err
+			}
+
+			list.MakeNonNil()
+
+			result.Data = list
+		}
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlPostStore) UpdatePinned(postId string, isPinned bool) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		// Bumping UpdateAt changes the channel's etag so clients see the pin
+		if _, err := s.GetMaster().Exec("UPDATE Posts SET IsPinned = ?, UpdateAt = ? WHERE Id = ? AND DeleteAt = 0", isPinned, model.GetMillis(), postId); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.UpdatePinned", "We couldn't pin or unpin the post", "id="+postId+", "+err.Error())
+		} else {
+			result.Data = postId
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlPostStore) UpdateProps(postId string, props model.StringMap) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("UPDATE Posts SET Props = ?, UpdateAt = ? WHERE Id = ? AND DeleteAt = 0", model.MapToJson(props), model.GetMillis(), postId); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.UpdateProps", "We couldn't update the post's props", "id="+postId+", "+err.Error())
+		} else {
+			result.Data = postId
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetPostsSince is every post made in the channel after since, oldest first.
+func (s SqlPostStore) GetPostsSince(channelId string, since int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if limit > 1000 {
+			result.Err = model.NewAppError("SqlPostStore.GetPostsSince", "Limit exceeded for paging", "channelId="+channelId)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts, "SELECT * FROM Posts WHERE ChannelId = ? AND CreateAt > ? AND DeleteAt = 0 ORDER BY CreateAt LIMIT ?", channelId, since, limit); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetPostsSince", "We couldn't get the posts for the channel", "channelId="+channelId+", "+err.Error())
+		} else {
+			list := &model.PostList{Order: make([]string, 0, len(posts))}
+
+			for _, p := range posts {
+				list.AddPost(p)
+				list.AddOrder(p.Id)
+			}
+
+			list.MakeNonNil()
+
+			result.Data = list
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlPostStore) GetPinnedPosts(channelId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts, "SELECT * FROM Posts WHERE ChannelId = ? AND IsPinned = 1 AND DeleteAt = 0 ORDER BY CreateAt DESC", channelId); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetPinnedPosts", "We couldn't get the pinned posts", "channelId="+channelId+", "+err.Error())
+		} else {
+			list := &model.PostList{Order: make([]string, 0, len(posts))}
+
+			for _, p := range posts {
+				list.AddPost(p)
+				list.AddOrder(p.Id)
+			}
+
+			if err := s.addReactionSummaries(list); err != nil {
+				result.Err = err
+			}
+
+			list.MakeNonNil()
+
+			result.Data = list
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlPostStore) GetPinnedPostCount(channelId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if count, err := s.GetReplica().SelectInt("SELECT COUNT(*) FROM Posts WHERE ChannelId = ? AND IsPinned = 1 AND DeleteAt = 0", channelId); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetPinnedPostCount", "We couldn't count the pinned posts", "channelId="+channelId+", "+err.Error())
+		} else {
+			result.Data = count
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetThread returns a page of replies to the root post, oldest first. The
+// root post is always included in Posts but only the replies are in Order.
+func (s SqlPostStore) GetThread(rootId string, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if limit > 1000 {
+			result.Err = model.NewAppError("SqlPostStore.GetThread", "Limit exceeded for paging", "root_id="+rootId)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		var root model.Post
+		if err := s.GetReplica().SelectOne(&root, "SELECT * FROM Posts WHERE Id = ? AND RootId = '' AND DeleteAt = 0", rootId); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetThread", "We couldn't get the thread", "root_id="+rootId+", "+err.Error())
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts, "SELECT * FROM Posts WHERE RootId = ? AND DeleteAt = 0 ORDER BY CreateAt LIMIT ?, ?", rootId, offset, limit); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetThread", "We couldn't get the thread", "root_id="+rootId+", "+err.Error())
+		} else {
+			list := &model.PostList{Order: make([]string, 0, len(posts))}
+			list.AddPost(&root)
+
+			for _, p := range posts {
+				list.AddPost(p)
+				list.AddOrder(p.Id)
+			}
+
+			if err := s.addReactionSummaries(list); err != nil {
+				result.Err = err
+			}
+
 			list.MakeNonNil()
 
 			result.Data = list
 		}
+
 		storeChannel <- result
 		close(storeChannel)
 	}()
 
 	return storeChannel
 }
+
+type threadMetadata struct {
+	ReplyCount  int64
+	LastReplyAt int64
+}
+
+// updateThreadMetadata recounts the replies to a root post after replies
+// have gone away.
+func (s SqlPostStore) updateThreadMetadata(rootId string) {
+	var meta threadMetadata
+	if err := s.GetMaster().SelectOne(&meta, "SELECT COUNT(*) AS ReplyCount, COALESCE(MAX(CreateAt), 0) AS LastReplyAt FROM Posts WHERE RootId = ? AND DeleteAt = 0", rootId); err != nil {
+		l4g.Error("Failed to count the replies root_id=%v err=%v", rootId, err)
+		return
+	}
+
+	if _, err := s.GetMaster().Exec("UPDATE Posts SET ReplyCount = ?, LastReplyAt = ? WHERE Id = ?", meta.ReplyCount, meta.LastReplyAt, rootId); err != nil {
+		l4g.Error("Failed to update the thread root_id=%v err=%v", rootId, err)
+	}
+}
+
+// backfillThreadMetadata fills in the reply counts for threads that existed
+// before they were tracked.
+func (s SqlPostStore) backfillThreadMetadata() {
+	if _, err := s.GetMaster().Exec(
+		`UPDATE Posts
+		        INNER JOIN
+		    (SELECT
+		        RootId, COUNT(*) AS ReplyCount, MAX(CreateAt) AS LastReplyAt
+		    FROM
+		        Posts
+		    WHERE
+		        RootId != '' AND DeleteAt = 0
+		    GROUP BY RootId) q1 ON q1.RootId = Posts.Id
+		SET
+		    Posts.ReplyCount = q1.ReplyCount,
+		    Posts.LastReplyAt = q1.LastReplyAt`); err != nil {
+		l4g.Error("Failed to backfill the thread reply counts err=%v", err)
+	}
+}
@@ -380,6 +380,10 @@ func TestPostStoreGetPostsWtihDetails(t *testing.T) {
 	if r1.Posts[o1.Id].Message != o1.Message {
 		t.Fatal("Missing parent")
 	}
+
+	if r1.Posts[o1.Id].ReplyCount != 3 || r1.Posts[o1.Id].LastReplyAt != o3.CreateAt {
+		t.Fatal("parent should have the thread metadata")
+	}
 }
 
 func TestPostStoreSearch(t *testing.T) {
@@ -478,4 +482,87 @@ func TestPostStoreSearch(t *testing.T) {
 	if len(r8.Order) != 0 {
 		t.Fatal("returned wrong serach result")
 	}
+
+	o6 := &model.Post{}
+	o6.ChannelId = c1.Id
+	o6.UserId = model.NewId()
+	o6.SetAttachments([]*model.PostAttachment{{Title: "Deploy finished", Fields: []*model.PostAttachmentField{{Title: "Environment", Value: "staging"}}}})
+	o6 = (<-store.Post().Save(o6)).Data.(*model.Post)
+
+	r9 := (<-store.Post().Search(teamId, userId, "staging", false)).Data.(*model.PostList)
+	if len(r9.Order) != 1 || r9.Order[0] != o6.Id {
+		t.Fatal("should have found the post by its attachment text")
+	}
+}
+
+func TestPostStorePinned(t *testing.T) {
+	Setup()
+
+	channelId := model.NewId()
+
+	o1 := (<-store.Post().Save(&model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "a" + model.NewId() + "b", IsPinned: true})).Data.(*model.Post)
+	o2 := (<-store.Post().Save(&model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "a" + model.NewId() + "b"})).Data.(*model.Post)
+
+	if o1.IsPinned {
+		t.Fatal("new posts should never start out pinned")
+	}
+
+	<-store.Post().UpdatePinned(o1.Id, true)
+	<-store.Post().UpdatePinned(o2.Id, true)
+
+	if count := (<-store.Post().GetPinnedPostCount(channelId)).Data.(int64); count != 2 {
+		t.Fatal("should have two pinned posts")
+	}
+
+	<-store.Post().UpdatePinned(o2.Id, false)
+
+	list := (<-store.Post().GetPinnedPosts(channelId)).Data.(*model.PostList)
+	if len(list.Order) != 1 || list.Order[0] != o1.Id || !list.Posts[o1.Id].IsPinned {
+		t.Fatal("should only have o1 pinned")
+	}
+
+	<-store.Post().Delete(o1.Id, model.GetMillis())
+
+	if count := (<-store.Post().GetPinnedPostCount(channelId)).Data.(int64); count != 0 {
+		t.Fatal("deleted posts should not count as pinned")
+	}
+}
+
+func TestPostStoreThread(t *testing.T) {
+	Setup()
+
+	root := (<-store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "a" + model.NewId() + "b"})).Data.(*model.Post)
+
+	replies := make([]*model.Post, 0, 3)
+	for i := 0; i < 3; i++ {
+		reply := &model.Post{ChannelId: root.ChannelId, UserId: model.NewId(), Message: "a" + model.NewId() + "b", RootId: root.Id}
+		replies = append(replies, (<-store.Post().Save(reply)).Data.(*model.Post))
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	list := (<-store.Post().GetThread(root.Id, 0, 2)).Data.(*model.PostList)
+	if len(list.Order) != 2 || list.Order[0] != replies[0].Id || list.Order[1] != replies[1].Id {
+		t.Fatal("should have the first page of replies oldest first")
+	}
+
+	if list.Posts[root.Id] == nil || list.Posts[root.Id].ReplyCount != 3 || list.Posts[root.Id].LastReplyAt != replies[2].CreateAt {
+		t.Fatal("should include the root post with its metadata")
+	}
+
+	if list = (<-store.Post().GetThread(root.Id, 2, 2)).Data.(*model.PostList); len(list.Order) != 1 || list.Order[0] != replies[2].Id {
+		t.Fatal("should have the last reply on the second page")
+	}
+
+	if err := (<-store.Post().GetThread(replies[0].Id, 0, 2)).Err; err == nil {
+		t.Fatal("replies aren't the root of a thread")
+	}
+
+	// Editing the root keeps the count, deleting a reply brings it down
+	<-store.Post().Update(list.Posts[root.Id], "a"+model.NewId()+"b", "")
+	<-store.Post().Delete(replies[2].Id, model.GetMillis())
+
+	root = (<-store.Post().Get(root.Id)).Data.(*model.PostList).Posts[root.Id]
+	if root.ReplyCount != 2 || root.LastReplyAt != replies[1].CreateAt {
+		t.Fatal("should have recounted the replies")
+	}
 }
@@ -0,0 +1,130 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+	"strings"
+)
+
+type SqlReactionStore struct {
+	*SqlStore
+}
+
+func NewSqlReactionStore(sqlStore *SqlStore) ReactionStore {
+	s := &SqlReactionStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Reaction{}, "Reactions").SetKeys(false, "PostId", "UserId", "EmojiName")
+		table.ColMap("PostId").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("EmojiName").SetMaxSize(64)
+	}
+
+	return s
+}
+
+func (s SqlReactionStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlReactionStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_user_id", "Reactions", "UserId")
+}
+
+func (s SqlReactionStore) Save(reaction *model.Reaction) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		reaction.PreSave()
+		if result.Err = reaction.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		// Reacting twice with the same emoji is not an error, nothing changes
+		if err := s.GetMaster().Insert(reaction); err != nil && !strings.Contains(err.Error(), "Duplicate entry") {
+			result.Err = model.NewAppError("SqlReactionStore.Save", "We couldn't save the reaction", "post_id="+reaction.PostId+", user_id="+reaction.UserId+", "+err.Error())
+		} else {
+			s.touchPost(reaction.PostId)
+			result.Data = reaction
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlReactionStore) Delete(reaction *model.Reaction) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM Reactions WHERE PostId = ? AND UserId = ? AND EmojiName = ?", reaction.PostId, reaction.UserId, reaction.EmojiName); err != nil {
+			result.Err = model.NewAppError("SqlReactionStore.Delete", "We couldn't delete the reaction", "post_id="+reaction.PostId+", user_id="+reaction.UserId+", "+err.Error())
+		} else {
+			s.touchPost(reaction.PostId)
+			result.Data = reaction
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlReactionStore) GetForPost(postId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var reactions []*model.Reaction
+		if _, err := s.GetReplica().Select(&reactions, "SELECT * FROM Reactions WHERE PostId = ? ORDER BY CreateAt", postId); err != nil {
+			result.Err = model.NewAppError("SqlReactionStore.GetForPost", "We couldn't get the reactions", "post_id="+postId+", "+err.Error())
+		} else {
+			result.Data = reactions
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// touchPost bumps the post's UpdateAt so the post list etags change with its
+// reactions.
+func (s SqlReactionStore) touchPost(postId string) {
+	s.GetMaster().Exec("UPDATE Posts SET UpdateAt = ? WHERE Id = ?", model.GetMillis(), postId)
+}
+
+// addReactionSummaries fills in list.Reactions for every post in the list.
+func (ss *SqlStore) addReactionSummaries(list *model.PostList) *model.AppError {
+	if len(list.Posts) == 0 {
+		list.Reactions = make(map[string][]*model.ReactionSummary)
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(list.Posts))
+	args := make([]interface{}, 0, len(list.Posts))
+	for id := range list.Posts {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+
+	var reactions []*model.Reaction
+	if _, err := ss.GetReplica().Select(&reactions, "SELECT * FROM Reactions WHERE PostId IN ("+strings.Join(placeholders, ", ")+") ORDER BY CreateAt", args...); err != nil {
+		return model.NewAppError("SqlStore.addReactionSummaries", "We couldn't get the reactions", err.Error())
+	}
+
+	list.Reactions = model.SummarizeReactions(reactions)
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+	"testing"
+)
+
+func TestReactionStoreSaveDelete(t *testing.T) {
+	Setup()
+
+	o1 := &model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "a" + model.NewId() + "b"}
+	o1 = (<-store.Post().Save(o1)).Data.(*model.Post)
+
+	r1 := &model.Reaction{PostId: o1.Id, UserId: model.NewId(), EmojiName: "smile"}
+	if err := (<-store.Reaction().Save(r1)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving the same reaction again is fine
+	if err := (<-store.Reaction().Save(&model.Reaction{PostId: o1.Id, UserId: r1.UserId, EmojiName: "smile"})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	r2 := &model.Reaction{PostId: o1.Id, UserId: model.NewId(), EmojiName: "smile"}
+	<-store.Reaction().Save(r2)
+
+	if err := (<-store.Reaction().Save(&model.Reaction{PostId: o1.Id, UserId: r1.UserId, EmojiName: "bad name"})).Err; err == nil {
+		t.Fatal("should have failed on an invalid emoji name")
+	}
+
+	if result := <-store.Reaction().GetForPost(o1.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if reactions := result.Data.([]*model.Reaction); len(reactions) != 2 {
+		t.Fatal("should have two reactions")
+	}
+
+	pl := (<-store.Post().Get(o1.Id)).Data.(*model.PostList)
+	if summaries := pl.Reactions[o1.Id]; len(summaries) != 1 || summaries[0].Count != 2 {
+		t.Fatal("post list should have summarized the reactions")
+	}
+
+	<-store.Reaction().Delete(r1)
+
+	if reactions := (<-store.Reaction().GetForPost(o1.Id)).Data.([]*model.Reaction); len(reactions) != 1 || reactions[0].UserId != r2.UserId {
+		t.Fatal("should have deleted the reaction")
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlReminderStore struct {
+	*SqlStore
+}
+
+func NewSqlReminderStore(sqlStore *SqlStore) ReminderStore {
+	s := &SqlReminderStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Reminder{}, "Reminders").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("CreatorId").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("TeamId").SetMaxSize(26)
+		table.ColMap("Message").SetMaxSize(4000)
+		table.ColMap("TeamUrl").SetMaxSize(1000)
+	}
+
+	return s
+}
+
+func (s SqlReminderStore) UpgradeSchemaIfNeeded() {
+	s.CreateColumnIfNotExists("Reminders", "ClaimedAt", "TeamUrl", "bigint(20)", "0")
+	s.CreateColumnIfNotExists("Reminders", "Attempts", "ClaimedAt", "int(11)", "0")
+}
+
+func (s SqlReminderStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_user_id", "Reminders", "UserId")
+	s.CreateIndexIfNotExists("idx_creator_id", "Reminders", "CreatorId")
+	s.CreateIndexIfNotExists("idx_remind_at", "Reminders", "RemindAt")
+}
+
+func (s SqlReminderStore) Save(reminder *model.Reminder) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(reminder.Id) > 0 {
+			result.Err = model.NewAppError("SqlReminderStore.Save", "You cannot update an existing reminder", "id="+reminder.Id)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		reminder.PreSave()
+		if result.Err = reminder.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(reminder); err != nil {
+			result.Err = model.NewAppError("SqlReminderStore.Save", "We couldn't save the reminder", "id="+reminder.Id+", "+err.Error())
+		} else {
+			result.Data = reminder
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetForUser returns the reminders on the team that are for the user or that
+// the user set for someone else, soonest first.
+func (s SqlReminderStore) GetForUser(teamId string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var reminders []*model.Reminder
+		if _, err := s.GetReplica().Select(&reminders, "SELECT * FROM Reminders WHERE TeamId = ? AND (UserId = ? OR CreatorId = ?) ORDER BY RemindAt",
+			teamId, userId, userId); err != nil {
+			result.Err = model.NewAppError("SqlReminderStore.GetForUser", "We couldn't get the reminders", "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = reminders
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Delete removes a reminder the user set or that is for them.
+func (s SqlReminderStore) Delete(id string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("DELETE FROM Reminders WHERE Id = ? AND (UserId = ? OR CreatorId = ?)", id, userId, userId); err != nil {
+			result.Err = model.NewAppError("SqlReminderStore.Delete", "We couldn't delete the reminder", "id="+id+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows != 1 {
+			result.Err = model.NewAppError("SqlReminderStore.Delete", "We couldn't find the reminder", "id="+id)
+		} else {
+			result.Data = id
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetDue returns the reminders that are due and that nobody has claimed
+// since staleBefore, a server that claimed one and then died leaves it for
+// another to pick up.
+func (s SqlReminderStore) GetDue(now int64, staleBefore int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var reminders []*model.Reminder
+		if _, err := s.GetMaster().Select(&reminders, "SELECT * FROM Reminders WHERE RemindAt <= ? AND ClaimedAt < ? ORDER BY RemindAt LIMIT ?", now, staleBefore, limit); err != nil {
+			result.Err = model.NewAppError("SqlReminderStore.GetDue", "We couldn't get the reminders", err.Error())
+		} else {
+			result.Data = reminders
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Claim marks the reminder as being sent by this server. Only one caller can
+// win for the ClaimedAt the reminder was read with, Data is false if someone
+// else got there first.
+func (s SqlReminderStore) Claim(reminder *model.Reminder, now int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("UPDATE Reminders SET ClaimedAt = ? WHERE Id = ? AND ClaimedAt = ?", now, reminder.Id, reminder.ClaimedAt); err != nil {
+			result.Err = model.NewAppError("SqlReminderStore.Claim", "We couldn't claim the reminder", "id="+reminder.Id+", "+err.Error())
+		} else {
+			rows, _ := sqlResult.RowsAffected()
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Finish removes a reminder once it has been sent.
+func (s SqlReminderStore) Finish(id string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM Reminders WHERE Id = ?", id); err != nil {
+			result.Err = model.NewAppError("SqlReminderStore.Finish", "We couldn't remove the sent reminder", "id="+id+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Release gives up the claim on a reminder that couldn't be sent so it's
+// tried again at retryAt, counting the failed attempt.
+func (s SqlReminderStore) Release(id string, retryAt int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("UPDATE Reminders SET ClaimedAt = 0, RemindAt = ?, Attempts = Attempts + 1 WHERE Id = ?", retryAt, id); err != nil {
+			result.Err = model.NewAppError("SqlReminderStore.Release", "We couldn't release the reminder", "id="+id+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+	"testing"
+)
+
+func TestReminderStore(t *testing.T) {
+	Setup()
+
+	r1 := &model.Reminder{CreatorId: model.NewId(), UserId: model.NewId(), TeamId: model.NewId(), Message: "a" + model.NewId() + "b", RemindAt: model.GetMillis() - 1000}
+	if err := (<-store.Reminder().Save(r1)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	r2 := &model.Reminder{CreatorId: r1.UserId, UserId: r1.UserId, TeamId: r1.TeamId, Message: "a" + model.NewId() + "b", RemindAt: model.GetMillis() + 60000}
+	<-store.Reminder().Save(r2)
+
+	if list := (<-store.Reminder().GetForUser(r1.TeamId, r1.UserId)).Data.([]*model.Reminder); len(list) != 2 || list[0].Id != r1.Id {
+		t.Fatal("should have both reminders, soonest first")
+	}
+
+	if list := (<-store.Reminder().GetForUser(r1.TeamId, r1.CreatorId)).Data.([]*model.Reminder); len(list) != 1 {
+		t.Fatal("the creator should see the reminder they set")
+	}
+
+	getDue := func() *model.Reminder {
+		now := model.GetMillis()
+		for _, reminder := range (<-store.Reminder().GetDue(now, now-1000, 100)).Data.([]*model.Reminder) {
+			if reminder.Id == r2.Id {
+				t.Fatal("r2 isn't due yet")
+			} else if reminder.Id == r1.Id {
+				return reminder
+			}
+		}
+
+		return nil
+	}
+
+	due := getDue()
+	if due == nil {
+		t.Fatal("r1 should be due")
+	}
+
+	if claimed := (<-store.Reminder().Claim(due, model.GetMillis())).Data.(bool); !claimed {
+		t.Fatal("should have claimed the reminder")
+	}
+
+	if claimed := (<-store.Reminder().Claim(due, model.GetMillis())).Data.(bool); claimed {
+		t.Fatal("should not claim the reminder twice")
+	}
+
+	if getDue() != nil {
+		t.Fatal("a claimed reminder shouldn't be due")
+	}
+
+	// Releasing it after a failed send makes it due again
+	if err := (<-store.Reminder().Release(r1.Id, model.GetMillis()-1)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if due = getDue(); due == nil || due.ClaimedAt != 0 || due.Attempts != 1 {
+		t.Fatal("should be due again with the attempt counted")
+	}
+
+	if err := (<-store.Reminder().Finish(r1.Id)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if getDue() != nil {
+		t.Fatal("a sent reminder shouldn't be due")
+	}
+
+	if err := (<-store.Reminder().Delete(r2.Id, model.NewId())).Err; err == nil {
+		t.Fatal("should not delete someone else's reminder")
+	}
+
+	if err := (<-store.Reminder().Delete(r2.Id, r2.UserId)).Err; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+	"strings"
+)
+
+type SqlSavedPostStore struct {
+	*SqlStore
+}
+
+func NewSqlSavedPostStore(sqlStore *SqlStore) SavedPostStore {
+	s := &SqlSavedPostStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.SavedPost{}, "SavedPosts").SetKeys(false, "UserId", "PostId")
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("PostId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlSavedPostStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlSavedPostStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_post_id", "SavedPosts", "PostId")
+}
+
+func (s SqlSavedPostStore) Save(saved *model.SavedPost) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		saved.PreSave()
+		if result.Err = saved.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		// Saving a post twice leaves it where it already was in the list
+		if err := s.GetMaster().Insert(saved); err != nil && !strings.Contains(err.Error(), "Duplicate entry") {
+			result.Err = model.NewAppError("SqlSavedPostStore.Save", "We couldn't save the post", "user_id="+saved.UserId+", post_id="+saved.PostId+", "+err.Error())
+		} else {
+			result.Data = saved
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlSavedPostStore) Delete(userId string, postId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM SavedPosts WHERE UserId = ? AND PostId = ?", userId, postId); err != nil {
+			result.Err = model.NewAppError("SqlSavedPostStore.Delete", "We couldn't unsave the post", "user_id="+userId+", post_id="+postId+", "+err.Error())
+		} else {
+			result.Data = postId
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetSavedPosts returns the user's saved posts, most recently saved first.
+// Posts that were deleted, or that are in channels the user can no longer
+// read, are left out the same way CheckPermissionsTo would refuse them.
+func (s SqlSavedPostStore) GetSavedPosts(teamId string, userId string, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if limit > 1000 {
+			result.Err = model.NewAppError("SqlSavedPostStore.GetSavedPosts", "Limit exceeded for paging", "user_id="+userId)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts,
+			`SELECT
+			    Posts.*
+			FROM
+			    SavedPosts, Posts, Channels, ChannelMembers
+			WHERE
+			    SavedPosts.UserId = ?
+			        AND Posts.Id = SavedPosts.PostId
+			        AND Posts.DeleteAt = 0
+			        AND Channels.Id = Posts.ChannelId
+			        AND Channels.TeamId = ?
+			        AND Channels.DeleteAt = 0
+			        AND ChannelMembers.ChannelId = Channels.Id
+			        AND ChannelMembers.UserId = SavedPosts.UserId
+			ORDER BY SavedPosts.CreateAt DESC
+			LIMIT ? OFFSET ?`, userId, teamId, limit, offset); err != nil {
+			result.Err = model.NewAppError("SqlSavedPostStore.GetSavedPosts", "We couldn't get the saved posts", "user_id="+userId+", "+err.Error())
+		} else {
+			list := &model.PostList{Order: make([]string, 0, len(posts))}
+
+			for _, p := range posts {
+				list.AddPost(p)
+				list.AddOrder(p.Id)
+			}
+
+			if err := s.addReactionSummaries(list); err != nil {
+				result.Err = err
+			}
+
+			list.MakeNonNil()
+
+			result.Data = list
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+	"testing"
+)
+
+func TestSavedPostStore(t *testing.T) {
+	Setup()
+
+	c1 := model.Channel{}
+	c1.TeamId = model.NewId()
+	c1.DisplayName = "Channel1"
+	c1.Name = "a" + model.NewId() + "b"
+	c1.Type = model.CHANNEL_OPEN
+	<-store.Channel().Save(&c1)
+
+	c2 := model.Channel{}
+	c2.TeamId = c1.TeamId
+	c2.DisplayName = "Channel2"
+	c2.Name = "a" + model.NewId() + "b"
+	c2.Type = model.CHANNEL_OPEN
+	<-store.Channel().Save(&c2)
+
+	m1 := model.ChannelMember{}
+	m1.ChannelId = c1.Id
+	m1.UserId = model.NewId()
+	m1.NotifyLevel = model.CHANNEL_NOTIFY_ALL
+	<-store.Channel().SaveMember(&m1)
+
+	p1 := &model.Post{ChannelId: c1.Id, UserId: m1.UserId, Message: "a" + model.NewId() + "b"}
+	p1 = (<-store.Post().Save(p1)).Data.(*model.Post)
+
+	p2 := &model.Post{ChannelId: c2.Id, UserId: m1.UserId, Message: "a" + model.NewId() + "b"}
+	p2 = (<-store.Post().Save(p2)).Data.(*model.Post)
+
+	if err := (<-store.SavedPost().Save(&model.SavedPost{UserId: m1.UserId, PostId: p1.Id})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving the same post again is fine
+	if err := (<-store.SavedPost().Save(&model.SavedPost{UserId: m1.UserId, PostId: p1.Id})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	<-store.SavedPost().Save(&model.SavedPost{UserId: m1.UserId, PostId: p2.Id})
+
+	// p2 is in a channel the user isn't a member of
+	if result := <-store.SavedPost().GetSavedPosts(c1.TeamId, m1.UserId, 0, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if list := result.Data.(*model.PostList); len(list.Order) != 1 || list.Order[0] != p1.Id {
+		t.Fatal("should only return saved posts the user can read")
+	}
+
+	if err := (<-store.SavedPost().GetSavedPosts(c1.TeamId, m1.UserId, 0, 1001)).Err; err == nil {
+		t.Fatal("should have failed on the paging limit")
+	}
+
+	<-store.SavedPost().Delete(m1.UserId, p1.Id)
+
+	if list := (<-store.SavedPost().GetSavedPosts(c1.TeamId, m1.UserId, 0, 10)).Data.(*model.PostList); len(list.Order) != 0 {
+		t.Fatal("should have unsaved the post")
+	}
+}
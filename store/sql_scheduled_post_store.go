@@ -0,0 +1,269 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlScheduledPostStore struct {
+	*SqlStore
+}
+
+func NewSqlScheduledPostStore(sqlStore *SqlStore) ScheduledPostStore {
+	s := &SqlScheduledPostStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.ScheduledPost{}, "ScheduledPosts").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("TeamId").SetMaxSize(26)
+		table.ColMap("ChannelId").SetMaxSize(26)
+		table.ColMap("RootId").SetMaxSize(26)
+		table.ColMap("Message").SetMaxSize(4000)
+		table.ColMap("State").SetMaxSize(16)
+		table.ColMap("PostId").SetMaxSize(26)
+		table.ColMap("Error").SetMaxSize(1000)
+		table.ColMap("TeamUrl").SetMaxSize(1000)
+	}
+
+	return s
+}
+
+func (s SqlScheduledPostStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlScheduledPostStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_user_id", "ScheduledPosts", "UserId")
+	s.CreateIndexIfNotExists("idx_scheduled_at", "ScheduledPosts", "ScheduledAt")
+}
+
+func (s SqlScheduledPostStore) Save(scheduled *model.ScheduledPost) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(scheduled.Id) > 0 {
+			result.Err = model.NewAppError("SqlScheduledPostStore.Save", "You cannot update an existing scheduled post", "id="+scheduled.Id)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		scheduled.PreSave()
+		if result.Err = scheduled.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(scheduled); err != nil {
+			result.Err = model.NewAppError("SqlScheduledPostStore.Save", "We couldn't save the scheduled post", "id="+scheduled.Id+", "+err.Error())
+		} else {
+			result.Data = scheduled
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Update changes the message and time of a scheduled post as long as it
+// hasn't started sending yet.
+func (s SqlScheduledPostStore) Update(scheduled *model.ScheduledPost) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		scheduled.UpdateAt = model.GetMillis()
+		if result.Err = scheduled.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if sqlResult, err := s.GetMaster().Exec("UPDATE ScheduledPosts SET Message = ?, ScheduledAt = ?, UpdateAt = ? WHERE Id = ? AND UserId = ? AND State = ?",
+			scheduled.Message, scheduled.ScheduledAt, scheduled.UpdateAt, scheduled.Id, scheduled.UserId, model.SCHEDULED_POST_PENDING); err != nil {
+			result.Err = model.NewAppError("SqlScheduledPostStore.Update", "We couldn't update the scheduled post", "id="+scheduled.Id+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows != 1 {
+			result.Err = model.NewAppError("SqlScheduledPostStore.Update", "The scheduled post has already been sent or cancelled", "id="+scheduled.Id)
+		} else {
+			result.Data = scheduled
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlScheduledPostStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var scheduled model.ScheduledPost
+		if err := s.GetMaster().SelectOne(&scheduled, "SELECT * FROM ScheduledPosts WHERE Id = ?", id); err != nil {
+			result.Err = model.NewAppError("SqlScheduledPostStore.Get", "We couldn't find the scheduled post", "id="+id+", "+err.Error())
+		} else {
+			result.Data = &scheduled
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetForUser returns the user's scheduled posts on the team that haven't
+// been sent, soonest first.
+func (s SqlScheduledPostStore) GetForUser(teamId string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var scheduled []*model.ScheduledPost
+		if _, err := s.GetReplica().Select(&scheduled, "SELECT * FROM ScheduledPosts WHERE UserId = ? AND TeamId = ? AND State != ? ORDER BY ScheduledAt",
+			userId, teamId, model.SCHEDULED_POST_SENT); err != nil {
+			result.Err = model.NewAppError("SqlScheduledPostStore.GetForUser", "We couldn't get the scheduled posts", "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = scheduled
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Delete cancels a scheduled post that hasn't started sending, or clears
+// one that failed.
+func (s SqlScheduledPostStore) Delete(id string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("DELETE FROM ScheduledPosts WHERE Id = ? AND UserId = ? AND State IN (?, ?)",
+			id, userId, model.SCHEDULED_POST_PENDING, model.SCHEDULED_POST_FAILED); err != nil {
+			result.Err = model.NewAppError("SqlScheduledPostStore.Delete", "We couldn't cancel the scheduled post", "id="+id+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows != 1 {
+			result.Err = model.NewAppError("SqlScheduledPostStore.Delete", "The scheduled post has already been sent or cancelled", "id="+id)
+		} else {
+			result.Data = id
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetDue returns the pending posts whose time has come along with any whose
+// claim is older than staleBefore because the server sending them went away.
+func (s SqlScheduledPostStore) GetDue(now int64, staleBefore int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var scheduled []*model.ScheduledPost
+		if _, err := s.GetMaster().Select(&scheduled,
+			`SELECT * FROM ScheduledPosts
+			WHERE
+			    (State = ? AND ScheduledAt <= ?)
+			        OR (State = ? AND ClaimedAt < ?)
+			ORDER BY ScheduledAt
+			LIMIT ?`,
+			model.SCHEDULED_POST_PENDING, now, model.SCHEDULED_POST_SENDING, staleBefore, limit); err != nil {
+			result.Err = model.NewAppError("SqlScheduledPostStore.GetDue", "We couldn't get the scheduled posts", err.Error())
+		} else {
+			result.Data = scheduled
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Claim marks the post as being sent by this server. Only one caller can win
+// the claim, Data is false for everyone else.
+func (s SqlScheduledPostStore) Claim(scheduled *model.ScheduledPost, now int64) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("UPDATE ScheduledPosts SET State = ?, ClaimedAt = ? WHERE Id = ? AND State = ? AND ClaimedAt = ?",
+			model.SCHEDULED_POST_SENDING, now, scheduled.Id, scheduled.State, scheduled.ClaimedAt); err != nil {
+			result.Err = model.NewAppError("SqlScheduledPostStore.Claim", "We couldn't claim the scheduled post", "id="+scheduled.Id+", "+err.Error())
+		} else {
+			rows, _ := sqlResult.RowsAffected()
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Finish records how sending went, an empty postId means it failed.
+func (s SqlScheduledPostStore) Finish(id string, postId string, errorMessage string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		state := model.SCHEDULED_POST_SENT
+		if len(postId) == 0 {
+			state = model.SCHEDULED_POST_FAILED
+		}
+
+		if _, err := s.GetMaster().Exec("UPDATE ScheduledPosts SET State = ?, PostId = ?, Error = ?, UpdateAt = ? WHERE Id = ?",
+			state, postId, errorMessage, model.GetMillis(), id); err != nil {
+			result.Err = model.NewAppError("SqlScheduledPostStore.Finish", "We couldn't update the scheduled post", "id="+id+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetSentPostId looks for a post that an earlier attempt already created
+// for the scheduled post, Data is empty if there isn't one.
+func (s SqlScheduledPostStore) GetSentPostId(scheduled *model.ScheduledPost) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if postId, err := s.GetMaster().SelectNullStr("SELECT Id FROM Posts WHERE ChannelId = ? AND UserId = ? AND CreateAt >= ? AND Props LIKE ? LIMIT 1",
+			scheduled.ChannelId, scheduled.UserId, scheduled.ClaimedAt, "%\""+model.POST_PROP_SCHEDULED_POST_ID+"\":\""+scheduled.Id+"\"%"); err != nil {
+			result.Err = model.NewAppError("SqlScheduledPostStore.GetSentPostId", "We couldn't check for the sent post", "id="+scheduled.Id+", "+err.Error())
+		} else {
+			result.Data = postId.String
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
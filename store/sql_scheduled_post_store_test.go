@@ -0,0 +1,92 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+	"testing"
+)
+
+func TestScheduledPostStore(t *testing.T) {
+	Setup()
+
+	s1 := &model.ScheduledPost{UserId: model.NewId(), TeamId: model.NewId(), ChannelId: model.NewId(), Message: "a" + model.NewId() + "b", ScheduledAt: model.GetMillis() + 60000}
+	if err := (<-store.ScheduledPost().Save(s1)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	s2 := &model.ScheduledPost{UserId: s1.UserId, TeamId: s1.TeamId, ChannelId: s1.ChannelId, Message: "a" + model.NewId() + "b", ScheduledAt: model.GetMillis() + 30000}
+	<-store.ScheduledPost().Save(s2)
+
+	if list := (<-store.ScheduledPost().GetForUser(s1.TeamId, s1.UserId)).Data.([]*model.ScheduledPost); len(list) != 2 || list[0].Id != s2.Id {
+		t.Fatal("should have both scheduled posts, soonest first")
+	}
+
+	s1.Message = "a" + model.NewId() + "b"
+	s1.ScheduledAt = model.GetMillis() - 1000
+	if err := (<-store.ScheduledPost().Update(s1)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	due := (<-store.ScheduledPost().GetDue(model.GetMillis(), 0, 100)).Data.([]*model.ScheduledPost)
+	var rs1 *model.ScheduledPost
+	for _, scheduled := range due {
+		if scheduled.Id == s1.Id {
+			rs1 = scheduled
+		} else if scheduled.Id == s2.Id {
+			t.Fatal("s2 isn't due yet")
+		}
+	}
+
+	if rs1 == nil || rs1.Message != s1.Message {
+		t.Fatal("s1 should be due")
+	}
+
+	// Only the first claim wins
+	if claimed := (<-store.ScheduledPost().Claim(rs1, model.GetMillis())).Data.(bool); !claimed {
+		t.Fatal("should have claimed the post")
+	}
+
+	if claimed := (<-store.ScheduledPost().Claim(rs1, model.GetMillis())).Data.(bool); claimed {
+		t.Fatal("should not claim the post twice")
+	}
+
+	if err := (<-store.ScheduledPost().Update(s1)).Err; err == nil {
+		t.Fatal("should not be able to edit once sending")
+	}
+
+	if err := (<-store.ScheduledPost().Delete(s1.Id, s1.UserId)).Err; err == nil {
+		t.Fatal("should not be able to cancel once sending")
+	}
+
+	rs1 = (<-store.ScheduledPost().Get(s1.Id)).Data.(*model.ScheduledPost)
+	if postId := (<-store.ScheduledPost().GetSentPostId(rs1)).Data.(string); postId != "" {
+		t.Fatal("should not have found a post")
+	}
+
+	post := &model.Post{ChannelId: s1.ChannelId, UserId: s1.UserId, Message: s1.Message, Props: model.StringMap{model.POST_PROP_SCHEDULED_POST_ID: s1.Id}}
+	post = (<-store.Post().Save(post)).Data.(*model.Post)
+
+	if postId := (<-store.ScheduledPost().GetSentPostId(rs1)).Data.(string); postId != post.Id {
+		t.Fatal("should have found the sent post")
+	}
+
+	<-store.ScheduledPost().Finish(s1.Id, post.Id, "")
+
+	if rs1 = (<-store.ScheduledPost().Get(s1.Id)).Data.(*model.ScheduledPost); rs1.State != model.SCHEDULED_POST_SENT || rs1.PostId != post.Id {
+		t.Fatal("should have been sent")
+	}
+
+	if list := (<-store.ScheduledPost().GetForUser(s1.TeamId, s1.UserId)).Data.([]*model.ScheduledPost); len(list) != 1 || list[0].Id != s2.Id {
+		t.Fatal("sent posts should not be listed")
+	}
+
+	if err := (<-store.ScheduledPost().Delete(s2.Id, model.NewId())).Err; err == nil {
+		t.Fatal("should not cancel someone else's scheduled post")
+	}
+
+	if err := (<-store.ScheduledPost().Delete(s2.Id, s2.UserId)).Err; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -132,3 +132,24 @@ func TestSessionStoreUpdateLastActivityAt(t *testing.T) {
 	}
 
 }
+
+func TestSessionStoreUpdateRoles(t *testing.T) {
+	Setup()
+
+	s1 := model.Session{}
+	s1.UserId = model.NewId()
+	s1.TeamId = model.NewId()
+	<-store.Session().Save(&s1)
+
+	if err := (<-store.Session().UpdateRoles(s1.UserId, model.ROLE_ADMIN)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if r1 := <-store.Session().Get(s1.Id); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else {
+		if r1.Data.(*model.Session).Roles != model.ROLE_ADMIN {
+			t.Fatal("Roles not updated correctly")
+		}
+	}
+}
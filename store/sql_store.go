@@ -28,14 +28,22 @@ import (
 )
 
 type SqlStore struct {
-	master   *gorp.DbMap
-	replicas []*gorp.DbMap
-	team     TeamStore
-	channel  ChannelStore
-	post     PostStore
-	user     UserStore
-	audit    AuditStore
-	session  SessionStore
+	master        *gorp.DbMap
+	replicas      []*gorp.DbMap
+	team          TeamStore
+	channel       ChannelStore
+	post          PostStore
+	user          UserStore
+	audit         AuditStore
+	session       SessionStore
+	reaction      ReactionStore
+	savedPost     SavedPostStore
+	thread        ThreadStore
+	scheduledPost ScheduledPostStore
+	reminder      ReminderStore
+	draft         DraftStore
+	group         GroupStore
+	postMention   PostMentionStore
 }
 
 func NewSqlStore() Store {
@@ -59,6 +67,14 @@ func NewSqlStore() Store {
 	sqlStore.user = NewSqlUserStore(sqlStore)
 	sqlStore.audit = NewSqlAuditStore(sqlStore)
 	sqlStore.session = NewSqlSessionStore(sqlStore)
+	sqlStore.reaction = NewSqlReactionStore(sqlStore)
+	sqlStore.savedPost = NewSqlSavedPostStore(sqlStore)
+	sqlStore.thread = NewSqlThreadStore(sqlStore)
+	sqlStore.scheduledPost = NewSqlScheduledPostStore(sqlStore)
+	sqlStore.reminder = NewSqlReminderStore(sqlStore)
+	sqlStore.draft = NewSqlDraftStore(sqlStore)
+	sqlStore.group = NewSqlGroupStore(sqlStore)
+	sqlStore.postMention = NewSqlPostMentionStore(sqlStore)
 
 	sqlStore.master.CreateTablesIfNotExists()
 
@@ -68,6 +84,14 @@ func NewSqlStore() Store {
 	sqlStore.user.(*SqlUserStore).CreateIndexesIfNotExists()
 	sqlStore.audit.(*SqlAuditStore).CreateIndexesIfNotExists()
 	sqlStore.session.(*SqlSessionStore).CreateIndexesIfNotExists()
+	sqlStore.reaction.(*SqlReactionStore).CreateIndexesIfNotExists()
+	sqlStore.savedPost.(*SqlSavedPostStore).CreateIndexesIfNotExists()
+	sqlStore.thread.(*SqlThreadStore).CreateIndexesIfNotExists()
+	sqlStore.scheduledPost.(*SqlScheduledPostStore).CreateIndexesIfNotExists()
+	sqlStore.reminder.(*SqlReminderStore).CreateIndexesIfNotExists()
+	sqlStore.draft.(*SqlDraftStore).CreateIndexesIfNotExists()
+	sqlStore.group.(*SqlGroupStore).CreateIndexesIfNotExists()
+	sqlStore.postMention.(*SqlPostMentionStore).CreateIndexesIfNotExists()
 
 	sqlStore.team.(*SqlTeamStore).UpgradeSchemaIfNeeded()
 	sqlStore.channel.(*SqlChannelStore).UpgradeSchemaIfNeeded()
@@ -75,6 +99,14 @@ func NewSqlStore() Store {
 	sqlStore.user.(*SqlUserStore).UpgradeSchemaIfNeeded()
 	sqlStore.audit.(*SqlAuditStore).UpgradeSchemaIfNeeded()
 	sqlStore.session.(*SqlSessionStore).UpgradeSchemaIfNeeded()
+	sqlStore.reaction.(*SqlReactionStore).UpgradeSchemaIfNeeded()
+	sqlStore.savedPost.(*SqlSavedPostStore).UpgradeSchemaIfNeeded()
+	sqlStore.thread.(*SqlThreadStore).UpgradeSchemaIfNeeded()
+	sqlStore.scheduledPost.(*SqlScheduledPostStore).UpgradeSchemaIfNeeded()
+	sqlStore.reminder.(*SqlReminderStore).UpgradeSchemaIfNeeded()
+	sqlStore.draft.(*SqlDraftStore).UpgradeSchemaIfNeeded()
+	sqlStore.group.(*SqlGroupStore).UpgradeSchemaIfNeeded()
+	sqlStore.postMention.(*SqlPostMentionStore).UpgradeSchemaIfNeeded()
 
 	return sqlStore
 }
@@ -264,6 +296,38 @@ func (ss SqlStore) Audit() AuditStore {
 	return ss.audit
 }
 
+func (ss SqlStore) Reaction() ReactionStore {
+	return ss.reaction
+}
+
+func (ss SqlStore) SavedPost() SavedPostStore {
+	return ss.savedPost
+}
+
+func (ss SqlStore) Thread() ThreadStore {
+	return ss.thread
+}
+
+func (ss SqlStore) ScheduledPost() ScheduledPostStore {
+	return ss.scheduledPost
+}
+
+func (ss SqlStore) Reminder() ReminderStore {
+	return ss.reminder
+}
+
+func (ss SqlStore) Draft() DraftStore {
+	return ss.draft
+}
+
+func (ss SqlStore) Group() GroupStore {
+	return ss.group
+}
+
+func (ss SqlStore) PostMention() PostMentionStore {
+	return ss.postMention
+}
+
 type mattermConverter struct{}
 
 func (me mattermConverter) ToDb(val interface{}) (interface{}, error) {
@@ -0,0 +1,105 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlThreadStore struct {
+	*SqlStore
+}
+
+func NewSqlThreadStore(sqlStore *SqlStore) ThreadStore {
+	s := &SqlThreadStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.ThreadFollower{}, "ThreadFollowers").SetKeys(false, "RootId", "UserId")
+		table.ColMap("RootId").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlThreadStore) UpgradeSchemaIfNeeded() {
+}
+
+func (s SqlThreadStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_user_id", "ThreadFollowers", "UserId")
+}
+
+// SaveFollower records an explicit follow or unfollow, replacing whatever
+// the user had before.
+func (s SqlThreadStore) SaveFollower(follower *model.ThreadFollower) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		follower.PreSave()
+		if result.Err = follower.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Exec(
+			`INSERT INTO ThreadFollowers (RootId, UserId, Following, UpdateAt) VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE Following = VALUES(Following), UpdateAt = VALUES(UpdateAt)`,
+			follower.RootId, follower.UserId, follower.Following, follower.UpdateAt); err != nil {
+			result.Err = model.NewAppError("SqlThreadStore.SaveFollower", "We couldn't update the thread follower", "root_id="+follower.RootId+", user_id="+follower.UserId+", "+err.Error())
+		} else {
+			result.Data = follower
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// AutoFollow makes the users follow the thread unless they've already made a
+// choice about it.
+func (s SqlThreadStore) AutoFollow(rootId string, userIds []string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		time := model.GetMillis()
+		for _, userId := range userIds {
+			if _, err := s.GetMaster().Exec("INSERT IGNORE INTO ThreadFollowers (RootId, UserId, Following, UpdateAt) VALUES (?, ?, 1, ?)", rootId, userId, time); err != nil {
+				result.Err = model.NewAppError("SqlThreadStore.AutoFollow", "We couldn't follow the thread", "root_id="+rootId+", user_id="+userId+", "+err.Error())
+				break
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlThreadStore) GetFollowers(rootId string) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		var userIds []string
+		if _, err := s.GetReplica().Select(&userIds, "SELECT UserId FROM ThreadFollowers WHERE RootId = ? AND Following = 1", rootId); err != nil {
+			result.Err = model.NewAppError("SqlThreadStore.GetFollowers", "We couldn't get the thread followers", "root_id="+rootId+", "+err.Error())
+		} else {
+			result.Data = userIds
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
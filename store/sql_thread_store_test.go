@@ -0,0 +1,51 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+	"testing"
+)
+
+func TestThreadStoreFollowers(t *testing.T) {
+	Setup()
+
+	rootId := model.NewId()
+	userId1 := model.NewId()
+	userId2 := model.NewId()
+
+	if err := (<-store.Thread().AutoFollow(rootId, []string{userId1, userId2})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	// Following twice is fine
+	if err := (<-store.Thread().AutoFollow(rootId, []string{userId1})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	if followers := (<-store.Thread().GetFollowers(rootId)).Data.([]string); len(followers) != 2 {
+		t.Fatal("should have two followers")
+	}
+
+	if err := (<-store.Thread().SaveFollower(&model.ThreadFollower{RootId: rootId, UserId: userId1, Following: false})).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	// An explicit unfollow sticks
+	<-store.Thread().AutoFollow(rootId, []string{userId1})
+
+	if followers := (<-store.Thread().GetFollowers(rootId)).Data.([]string); len(followers) != 1 || followers[0] != userId2 {
+		t.Fatal("should only have userId2 following")
+	}
+
+	<-store.Thread().SaveFollower(&model.ThreadFollower{RootId: rootId, UserId: userId1, Following: true})
+
+	if followers := (<-store.Thread().GetFollowers(rootId)).Data.([]string); len(followers) != 2 {
+		t.Fatal("should be following again")
+	}
+
+	if err := (<-store.Thread().SaveFollower(&model.ThreadFollower{RootId: rootId, Following: true})).Err; err == nil {
+		t.Fatal("should have failed on a missing user")
+	}
+}
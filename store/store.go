@@ -21,6 +21,14 @@ type Store interface {
 	User() UserStore
 	Audit() AuditStore
 	Session() SessionStore
+	Reaction() ReactionStore
+	SavedPost() SavedPostStore
+	Thread() ThreadStore
+	ScheduledPost() ScheduledPostStore
+	Reminder() ReminderStore
+	Draft() DraftStore
+	Group() GroupStore
+	PostMention() PostMentionStore
 	Close()
 }
 
@@ -45,12 +53,16 @@ type ChannelStore interface {
 	SaveMember(member *model.ChannelMember) StoreChannel
 	GetMembers(channelId string) StoreChannel
 	GetMember(channelId string, userId string) StoreChannel
+	GetMemberCount(channelId string) StoreChannel
 	RemoveMember(channelId string, userId string) StoreChannel
 	GetExtraMembers(channelId string, limit int) StoreChannel
 	CheckPermissionsTo(teamId string, channelId string, userId string) StoreChannel
 	CheckOpenChannelPermissions(teamId string, channelId string) StoreChannel
 	CheckPermissionsToByName(teamId string, channelName string, userId string) StoreChannel
 	UpdateLastViewedAt(channelId string, userId string) StoreChannel
+	MarkUnread(channelId string, userId string, lastViewedAt int64, mentionCount int64) StoreChannel
+	GetUnread(channelId string, userId string) StoreChannel
+	GetUnreads(teamId string, userId string) StoreChannel
 	IncrementMentionCount(channelId string, userId string) StoreChannel
 	UpdateNotifyLevel(channelId string, userId string, notifyLevel string) StoreChannel
 }
@@ -63,6 +75,12 @@ type PostStore interface {
 	GetPosts(channelId string, offset int, limit int) StoreChannel
 	GetEtag(channelId string) StoreChannel
 	Search(teamId string, userId string, terms string, isHashtagSearch bool) StoreChannel
+	UpdatePinned(postId string, isPinned bool) StoreChannel
+	UpdateProps(postId string, props model.StringMap) StoreChannel
+	GetPostsSince(channelId string, since int64, limit int) StoreChannel
+	GetPinnedPosts(channelId string) StoreChannel
+	GetPinnedPostCount(channelId string) StoreChannel
+	GetThread(rootId string, offset int, limit int) StoreChannel
 }
 
 type UserStore interface {
@@ -86,6 +104,72 @@ type SessionStore interface {
 	GetSessions(userId string) StoreChannel
 	Remove(sessionIdOrAlt string) StoreChannel
 	UpdateLastActivityAt(sessionId string, time int64) StoreChannel
+	UpdateRoles(userId string, roles string) StoreChannel
+}
+
+type ReactionStore interface {
+	Save(reaction *model.Reaction) StoreChannel
+	Delete(reaction *model.Reaction) StoreChannel
+	GetForPost(postId string) StoreChannel
+}
+
+type SavedPostStore interface {
+	Save(saved *model.SavedPost) StoreChannel
+	Delete(userId string, postId string) StoreChannel
+	GetSavedPosts(teamId string, userId string, offset int, limit int) StoreChannel
+}
+
+type ThreadStore interface {
+	SaveFollower(follower *model.ThreadFollower) StoreChannel
+	AutoFollow(rootId string, userIds []string) StoreChannel
+	GetFollowers(rootId string) StoreChannel
+}
+
+type ScheduledPostStore interface {
+	Save(scheduled *model.ScheduledPost) StoreChannel
+	Update(scheduled *model.ScheduledPost) StoreChannel
+	Get(id string) StoreChannel
+	GetForUser(teamId string, userId string) StoreChannel
+	Delete(id string, userId string) StoreChannel
+	GetDue(now int64, staleBefore int64, limit int) StoreChannel
+	Claim(scheduled *model.ScheduledPost, now int64) StoreChannel
+	Finish(id string, postId string, errorMessage string) StoreChannel
+	GetSentPostId(scheduled *model.ScheduledPost) StoreChannel
+}
+
+type ReminderStore interface {
+	Save(reminder *model.Reminder) StoreChannel
+	GetForUser(teamId string, userId string) StoreChannel
+	Delete(id string, userId string) StoreChannel
+	GetDue(now int64, staleBefore int64, limit int) StoreChannel
+	Claim(reminder *model.Reminder, now int64) StoreChannel
+	Finish(id string) StoreChannel
+	Release(id string, retryAt int64) StoreChannel
+}
+
+type DraftStore interface {
+	Upsert(draft *model.Draft) StoreChannel
+	Delete(userId string, channelId string, rootId string) StoreChannel
+	GetForUser(teamId string, userId string) StoreChannel
+}
+
+type GroupStore interface {
+	Save(group *model.Group) StoreChannel
+	Update(group *model.Group) StoreChannel
+	Get(id string) StoreChannel
+	GetForTeam(teamId string) StoreChannel
+	GetByName(teamId string, name string) StoreChannel
+	Delete(id string) StoreChannel
+	SaveMember(member *model.GroupMember) StoreChannel
+	RemoveMember(groupId string, userId string) StoreChannel
+	GetMembers(groupId string) StoreChannel
+	GetMentionMap(teamId string) StoreChannel
+}
+
+type PostMentionStore interface {
+	Save(post *model.Post, userIds []string) StoreChannel
+	DeleteForPost(postId string) StoreChannel
+	GetMentions(teamId string, userId string, offset int, limit int) StoreChannel
 }
 
 type AuditStore interface {
@@ -43,8 +43,18 @@ type SqlSettings struct {
 }
 
 type RedisSettings struct {
-	DataSource   string
-	MaxOpenConns int
+	DataSource         string
+	MaxOpenConns       int
+	Password           string
+	DB                 int
+	UseTLS             bool
+	SentinelMasterName string
+	SentinelAddresses  []string
+}
+
+type WebSocketSettings struct {
+	SendBufferSize      int
+	BroadcastBufferSize int
 }
 
 type LogSettings struct {
@@ -106,18 +116,34 @@ type TeamSettings struct {
 	ReportProblemLink string
 	TourLink          string
 	DefaultThemeColor string
+
+	// @channel and @all in channels with more members than this need the
+	// poster to confirm, or to be a channel admin when LargeChannelMentionsAdminOnly
+	// is set. 0 turns the check off.
+	LargeChannelMentionSize       int
+	LargeChannelMentionsAdminOnly bool
+}
+
+type LinkPreviewSettings struct {
+	Enable         bool
+	AllowedDomains []string
+	DeniedDomains  []string
+	TimeoutSecs    int
+	MaxBodySize    int64
 }
 
 type Config struct {
-	LogSettings     LogSettings
-	ServiceSettings ServiceSettings
-	SqlSettings     SqlSettings
-	RedisSettings   RedisSettings
-	AWSSettings     AWSSettings
-	ImageSettings   ImageSettings
-	EmailSettings   EmailSettings
-	PrivacySettings PrivacySettings
-	TeamSettings    TeamSettings
+	LogSettings         LogSettings
+	ServiceSettings     ServiceSettings
+	SqlSettings         SqlSettings
+	RedisSettings       RedisSettings
+	WebSocketSettings   WebSocketSettings
+	AWSSettings         AWSSettings
+	ImageSettings       ImageSettings
+	EmailSettings       EmailSettings
+	PrivacySettings     PrivacySettings
+	TeamSettings        TeamSettings
+	LinkPreviewSettings LinkPreviewSettings
 }
 
 func (o *Config) ToJson() string {
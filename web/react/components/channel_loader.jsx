@@ -53,6 +53,15 @@ module.exports = React.createClass({
         /* End global change listeners setup */
     },
     _onSocketChange: function(msg) {
+        // The server missed messages while it was cut off from the others
+        if (msg && msg.action == "resync") {
+            AsyncClient.getPosts(true);
+            AsyncClient.getChannels(true, true);
+            AsyncClient.getChannelExtraInfo(true);
+            AsyncClient.getStatuses();
+            return;
+        }
+
         if (msg && msg.user_id) {
             UserStore.setStatus(msg.user_id, "online");
         }
@@ -14,6 +14,7 @@ var Constants = require('../utils/constants.jsx');
 
 module.exports = React.createClass({
     lastTime: 0,
+    confirmedChannelMention: false,
     handleSubmit: function(e) {
         e.preventDefault();
 
@@ -39,6 +40,11 @@ module.exports = React.createClass({
         post.parent_id = this.props.parentId;
         post.filenames = this.state.previews;
 
+        if (this.confirmedChannelMention) {
+            post.props = {confirm_channel_mention: "true"};
+            this.confirmedChannelMention = false;
+        }
+
         this.setState({ submitting: true });
 
         client.createPost(post, ChannelStore.getCurrent(),
@@ -55,6 +61,15 @@ module.exports = React.createClass({
 
             }.bind(this),
             function(err) {
+                // The channel is big enough that @channel and @all need confirming
+                if (err.status_code === 412 && confirm(err.message)) {
+                    this.confirmedChannelMention = true;
+                    this.setState({ submitting: false }, function() {
+                        this.handleSubmit(e);
+                    });
+                    return;
+                }
+
                 var state = {};
                 state.server_error = err.message;
 
@@ -19,6 +19,8 @@ var ActionTypes = Constants.ActionTypes;
 
 module.exports = React.createClass({
     lastTime: 0,
+    draftTimeout: null,
+    confirmedChannelMention: false,
     handleSubmit: function(e) {
         e.preventDefault();
 
@@ -42,6 +44,9 @@ module.exports = React.createClass({
 
         this.setState({ submitting: true });
 
+        // Creating the post deletes the server's draft, don't let a pending save bring it back
+        clearTimeout(this.draftTimeout);
+
         var user_id = UserStore.getCurrentId();
 
         if (post.message.indexOf("/") == 0) {
@@ -51,6 +56,7 @@ module.exports = React.createClass({
                 false,
                 function(data) {
                     PostStore.storeDraft(data.channel_id, user_id, null);
+                    client.deleteDraft(data.channel_id, "", function() {}, function() {});
                     this.setState({ messageText: '', submitting: false, post_error: null, previews: [], server_error: null, limit_error: null });
 
                     if (data.goto_location.length > 0) {
@@ -68,6 +74,11 @@ module.exports = React.createClass({
             post.channel_id = this.state.channel_id;
             post.filenames = this.state.previews;
 
+            if (this.confirmedChannelMention) {
+                post.props = {confirm_channel_mention: "true"};
+                this.confirmedChannelMention = false;
+            }
+
             client.createPost(post, ChannelStore.getCurrent(),
                 function(data) {
                     PostStore.storeDraft(data.channel_id, data.user_id, null);
@@ -83,6 +94,15 @@ module.exports = React.createClass({
 
                 }.bind(this),
                 function(err) {
+                    // The channel is big enough that @channel and @all need confirming
+                    if (err.status_code === 412 && confirm(err.message)) {
+                        this.confirmedChannelMention = true;
+                        this.setState({ submitting: false }, function() {
+                            this.handleSubmit(e);
+                        });
+                        return;
+                    }
+
                     var state = {}
                     state.server_error = err.message;
                     state.submitting = false;
@@ -120,6 +140,47 @@ module.exports = React.createClass({
         }
         draft['message'] = messageText;
         PostStore.storeCurrentDraft(draft);
+        this.syncDraft(this.state.channel_id, messageText, draft['previews']);
+    },
+    syncDraft: function(channel_id, messageText, previews) {
+        // Wait for a pause in typing before sending the draft to the server
+        clearTimeout(this.draftTimeout);
+        this.draftTimeout = setTimeout(function() {
+            if (messageText.trim().length === 0 && previews.length === 0) {
+                client.deleteDraft(channel_id, "", function() {}, function() {});
+            } else {
+                client.upsertDraft({channel_id: channel_id, root_id: "", message: messageText, filenames: previews}, function() {}, function() {});
+            }
+        }, 1000);
+    },
+    storeServerDraft: function(draft) {
+        var user_id = UserStore.getCurrentId();
+        var local = PostStore.getDraft(draft.channel_id, user_id) || {uploadsInProgress: 0};
+        local['message'] = draft.message;
+        local['previews'] = draft.filenames;
+        PostStore.storeDraft(draft.channel_id, user_id, local);
+
+        if (draft.channel_id === this.state.channel_id) {
+            this.setState({messageText: draft.message, initialText: draft.message, previews: draft.filenames});
+        }
+    },
+    _onSocketChange: function(msg) {
+        if (!msg) {
+            return;
+        }
+
+        if (msg.action == "draft_updated") {
+            var draft = JSON.parse(msg.props.draft);
+            if (!draft.root_id) {
+                this.storeServerDraft(draft);
+            }
+        } else if (msg.action == "draft_deleted" && !msg.props.root_id) {
+            PostStore.storeDraft(msg.props.channel_id, UserStore.getCurrentId(), null);
+
+            if (msg.props.channel_id === this.state.channel_id) {
+                this.setState({messageText: '', initialText: '', previews: []});
+            }
+        }
     },
     resizePostHolder: function() {
         var height = $(window).height() - $(this.refs.topDiv.getDOMNode()).height() - $('#error_bar').outerHeight() - 50;
@@ -174,10 +235,24 @@ module.exports = React.createClass({
     },
     componentDidMount: function() {
         ChannelStore.addChangeListener(this._onChange);
+        SocketStore.addChangeListener(this._onSocketChange);
         this.resizePostHolder();
+
+        client.getDrafts(
+            function(drafts) {
+                for (var i = 0; i < drafts.length; i++) {
+                    if (!drafts[i].root_id) {
+                        this.storeServerDraft(drafts[i]);
+                    }
+                }
+            }.bind(this),
+            function() {}
+        );
     },
     componentWillUnmount: function() {
         ChannelStore.removeChangeListener(this._onChange);
+        SocketStore.removeChangeListener(this._onSocketChange);
+        clearTimeout(this.draftTimeout);
     },
     _onChange: function() {
         var channel_id = ChannelStore.getCurrentId();
@@ -0,0 +1,17 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+module.exports = React.createClass({
+    render: function() {
+        var preview = this.props.preview;
+
+        return (
+            <div className="link-preview">
+                { preview.site_name ? <div className="link-preview__site">{preview.site_name}</div> : "" }
+                { preview.title ? <div className="link-preview__title"><a href={preview.url} target="_blank">{preview.title}</a></div> : "" }
+                { preview.description ? <div className="link-preview__description">{preview.description}</div> : "" }
+                { preview.image_url ? <a href={preview.url} target="_blank"><img className="link-preview__image" src={preview.image_url} /></a> : "" }
+            </div>
+        );
+    }
+});
@@ -46,12 +46,10 @@ module.exports = React.createClass({
         }
 
         var commentCount = 0;
-        var commentRootId = parentPost ? post.root_id : post.id;
+        var rootPost = parentPost ? posts[post.root_id] : post;
         var rootUser = "";
-        for (var postId in posts) {
-            if (posts[postId].root_id == commentRootId) {
-                commentCount += 1;
-            }
+        if (rootPost && rootPost.reply_count) {
+            commentCount = rootPost.reply_count;
         }
 
         var error = this.state.error ? <div className='form-group has-error'><label className='control-label'>{ this.state.error }</label></div> : null;
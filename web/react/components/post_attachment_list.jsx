@@ -0,0 +1,74 @@
+// Copyright (c) 2015 Spinpunch, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+var utils = require('../utils/utils.jsx');
+
+var attachmentColors = {
+    good: "#36a64f",
+    warning: "#daa038",
+    danger: "#d00000"
+};
+
+module.exports = React.createClass({
+    render: function() {
+        var attachments = utils.getPostAttachments(this.props.post);
+        if (attachments.length === 0) {
+            return null;
+        }
+
+        var items = attachments.map(function(attachment, i) {
+            var color = attachmentColors[attachment.color] || attachment.color || "#ddd";
+
+            var author = "";
+            if (attachment.author_name) {
+                var authorName = attachment.author_link ? <a href={attachment.author_link} target="_blank">{attachment.author_name}</a> : attachment.author_name;
+                author = (
+                    <div className="attachment__author">
+                        { attachment.author_icon ? <img className="attachment__author-icon" src={attachment.author_icon} /> : "" }
+                        { authorName }
+                    </div>
+                );
+            }
+
+            var title = "";
+            if (attachment.title) {
+                title = <div className="attachment__title">{ attachment.title_link ? <a href={attachment.title_link} target="_blank">{attachment.title}</a> : attachment.title }</div>;
+            }
+
+            var fields = "";
+            if (attachment.fields && attachment.fields.length > 0) {
+                fields = (
+                    <div className="attachment__fields">
+                        { attachment.fields.map(function(field, j) {
+                            return (
+                                <div key={"field_" + j} className={"attachment__field" + (field.short ? " attachment__field--short" : "")}>
+                                    <div className="attachment__field-title">{field.title}</div>
+                                    <div className="attachment__field-value">{utils.textToJsx(field.value)}</div>
+                                </div>
+                            );
+                        }) }
+                    </div>
+                );
+            }
+
+            return (
+                <div key={"attachment_" + i} className="attachment" style={{borderLeftColor: color}}>
+                    { attachment.pretext ? <div className="attachment__pretext">{utils.textToJsx(attachment.pretext)}</div> : "" }
+                    { author }
+                    { title }
+                    { attachment.text ? <div className="attachment__text">{utils.textToJsx(attachment.text)}</div> : "" }
+                    { fields }
+                    { attachment.image_url ? <img className="attachment__image" src={attachment.image_url} /> : "" }
+                    { attachment.thumb_url && !attachment.image_url ? <img className="attachment__thumb" src={attachment.thumb_url} /> : "" }
+                    { attachment.footer ? <div className="attachment__footer">{attachment.footer}</div> : "" }
+                </div>
+            );
+        });
+
+        return (
+            <div className="attachment__list">
+                { items }
+            </div>
+        );
+    }
+});
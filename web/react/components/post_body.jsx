@@ -5,6 +5,8 @@ var CreateComment = require( './create_comment.jsx' );
 var UserStore = require('../stores/user_store.jsx');
 var utils = require('../utils/utils.jsx');
 var ViewImageModal = require('./view_image.jsx');
+var PostAttachmentList = require('./post_attachment_list.jsx');
+var LinkPreview = require('./link_preview.jsx');
 var Constants = require('../utils/constants.jsx');
 
 module.exports = React.createClass({
@@ -82,6 +84,10 @@ module.exports = React.createClass({
             postClass += " post-comment";
         }
 
+        if (utils.isSystemMessage(post)) {
+            postClass += " post-system-message";
+        }
+
         var postFiles = [];
         var images = [];
         if (filenames) {
@@ -113,7 +119,10 @@ module.exports = React.createClass({
         }
 
         var embed;
-        if (postFiles.length === 0 && this.state.links) {
+        var linkPreview = utils.getLinkPreview(post);
+        if (linkPreview) {
+            embed = <LinkPreview preview={linkPreview} />;
+        } else if (postFiles.length === 0 && this.state.links) {
             embed = utils.getEmbed(this.state.links[0]);
         }
 
@@ -121,6 +130,7 @@ module.exports = React.createClass({
             <div className="post-body">
                 { comment }
                 <p key={post.Id+"_message"} className={postClass}><span>{inner}</span></p>
+                <PostAttachmentList post={post} />
                 { filenames && filenames.length > 0 ?
                     <div className="post-image__columns">
                         { postFiles }
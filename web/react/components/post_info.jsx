@@ -2,12 +2,26 @@
 // See License.txt for license information.
 
 var UserStore = require('../stores/user_store.jsx');
+var AsyncClient = require('../utils/async_client.jsx');
+var client = require('../utils/client.jsx');
 var utils = require('../utils/utils.jsx');
 
 module.exports = React.createClass({
     getInitialState: function() {
         return { };
     },
+    handleMarkUnread: function(e) {
+        e.preventDefault();
+
+        client.markChannelUnread(this.props.post.channel_id, this.props.post.id,
+            function() {
+                AsyncClient.getChannels(true);
+            },
+            function(err) {
+                AsyncClient.dispatchError(err, "markChannelUnread");
+            }
+        );
+    },
     render: function() {
         var post = this.props.post;
         var isOwner = UserStore.getCurrentId() == post.user_id;
@@ -17,6 +31,15 @@ module.exports = React.createClass({
             type = "Comment"
         }
 
+        if (post.type === "ephemeral") {
+            return (
+                <ul className="post-header post-info">
+                    <li className="post-header-col"><time className="post-profile-time">{ utils.displayDateTime(post.create_at) }</time></li>
+                    <li className="post-header-col">(Only visible to you)</li>
+                </ul>
+            );
+        }
+
         var comments = "";
         var lastCommentClass = this.props.isLastComment ? " comment-icon__container__show" : " comment-icon__container__hide";
         if (this.props.commentCount >= 1) {
@@ -28,21 +51,20 @@ module.exports = React.createClass({
                 <li className="post-header-col"><time className="post-profile-time">{ utils.displayDateTime(post.create_at) }</time></li>
                     <li className="post-header-col post-header__reply">
                         <div className="dropdown">
-                        { isOwner || (this.props.allowReply === "true" && type != "Comment") ?
                             <div>
                                 <a href="#" className="dropdown-toggle theme" type="button" data-toggle="dropdown" aria-expanded="false">
                                     [...]
                                 </a>
                                 <ul className="dropdown-menu" role="menu">
-                                    { isOwner ? <li role="presentation"><a href="#" role="menuitem" data-toggle="modal" data-target="#edit_post" data-title={type} data-message={post.message} data-postid={post.id} data-channelid={post.channel_id} data-comments={type === "Post" ? this.props.commentCount : 0}>Edit</a></li>
+                                    { isOwner && !utils.isSystemMessage(post) ? <li role="presentation"><a href="#" role="menuitem" data-toggle="modal" data-target="#edit_post" data-title={type} data-message={post.message} data-postid={post.id} data-channelid={post.channel_id} data-comments={type === "Post" ? this.props.commentCount : 0}>Edit</a></li>
                                     : "" }
                                     { isOwner ? <li role="presentation"><a href="#" role="menuitem" data-toggle="modal" data-target="#delete_post" data-title={type} data-postid={post.id} data-channelid={post.channel_id} data-comments={type === "Post" ? this.props.commentCount : 0}>Delete</a></li>
                                     : "" }
                                     { this.props.allowReply === "true" ? <li role="presentation"><a className="reply-link theme" href="#" onClick={this.props.handleCommentClick}>Reply</a></li>
                                     : "" }
+                                    <li role="presentation"><a href="#" role="menuitem" onClick={this.handleMarkUnread}>Mark Unread</a></li>
                                 </ul>
                             </div>
-                            : "" }
                         </div>
                         { comments }
                     </li>
@@ -191,10 +191,31 @@ module.exports = React.createClass({
             var post_list = PostStore.getPosts(msg.channel_id);
             if (!post_list) return;
 
-            post_list.posts[post.id] = post;
             if (post_list.order.indexOf(post.id) === -1) {
                 post_list.order.unshift(post.id);
+
+                var root = post.root_id ? post_list.posts[post.root_id] : null;
+                if (root) {
+                    root.reply_count = (root.reply_count || 0) + 1;
+                    root.last_reply_at = post.create_at;
+                }
             }
+            post_list.posts[post.id] = post;
+
+            if (this.state.channel.id === msg.channel_id) {
+                this.setState({ post_list: post_list });
+            };
+
+            PostStore.storePosts(post.channel_id, post_list);
+        } else if (msg.action == "ephemeral_post") {
+            var post = JSON.parse(msg.props.post);
+
+            var post_list = PostStore.getPosts(msg.channel_id);
+            if (!post_list) return;
+
+            // Never saved on the server so it goes away the next time posts load
+            post_list.posts[post.id] = post;
+            post_list.order.unshift(post.id);
 
             if (this.state.channel.id === msg.channel_id) {
                 this.setState({ post_list: post_list });
@@ -212,6 +233,26 @@ module.exports = React.createClass({
                 post_list.posts[post.id] = post;
                 this.setState({ post_list: post_list });
 
+                PostStore.storePosts(msg.channel_id, post_list);
+            } else {
+                AsyncClient.getPosts(true, msg.channel_id);
+            }
+        } else if (msg.action == "post_link_preview") {
+            if (this.state.channel.id == msg.channel_id) {
+                var post_list = this.state.post_list;
+                if (!(msg.props.post_id in post_list.posts)) return;
+
+                var post = post_list.posts[msg.props.post_id];
+                post.props = post.props || {};
+                if (msg.props.link_preview) {
+                    post.props.link_preview = msg.props.link_preview;
+                } else {
+                    delete post.props.link_preview;
+                }
+
+                post_list.posts[post.id] = post;
+                this.setState({ post_list: post_list });
+
                 PostStore.storePosts(msg.channel_id, post_list);
             } else {
                 AsyncClient.getPosts(true, msg.channel_id);
@@ -224,6 +265,12 @@ module.exports = React.createClass({
                 var post_list = this.state.post_list;
                 if (!(msg.props.post_id in this.state.post_list.posts)) return;
 
+                var deleted = post_list.posts[msg.props.post_id];
+                var root = deleted.root_id ? post_list.posts[deleted.root_id] : null;
+                if (root && root.reply_count > 0) {
+                    root.reply_count -= 1;
+                }
+
                 delete post_list.posts[msg.props.post_id];
                 var index = post_list.order.indexOf(msg.props.post_id);
                 if (index > -1) post_list.order.splice(index, 1);
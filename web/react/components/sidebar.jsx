@@ -228,6 +228,11 @@ var SidebarLoggedIn = React.createClass({
             if (UserStore.getCurrentId() != msg.user_id) {
 
                 var mentions = msg.props.mentions ? JSON.parse(msg.props.mentions) : [];
+
+                // Replies in a followed thread notify like a mention does
+                if (msg.props.followers) {
+                    mentions = mentions.concat(JSON.parse(msg.props.followers));
+                }
                 var channel = ChannelStore.get(msg.channel_id);
 
                 var user = UserStore.getCurrentUser();
@@ -249,7 +254,14 @@ var SidebarLoggedIn = React.createClass({
 
                 var repRegex = new RegExp("<br>", "g");
                 var post = JSON.parse(msg.props.post);
-                var msg = post.message.replace(repRegex, "\n").split("\n")[0].replace("<mention>", "").replace("</mention>", "");
+                var text = post.message;
+                if (!text) {
+                    var attachments = utils.getPostAttachments(post);
+                    if (attachments.length > 0) {
+                        text = attachments[0].fallback || attachments[0].title || attachments[0].text || "";
+                    }
+                }
+                var msg = text.replace(repRegex, "\n").split("\n")[0].replace("<mention>", "").replace("</mention>", "");
                 if (msg.length > 50) {
                     msg = msg.substring(0,49) + "...";
                 }
@@ -263,6 +275,10 @@ var SidebarLoggedIn = React.createClass({
             if (ChannelStore.getCurrentId() != msg.channel_id) {
                 AsyncClient.getChannels(true);
             }
+        } else if (msg.action == "channel_unread") {
+            AsyncClient.getChannels(true);
+        } else if (msg.action == "status_change") {
+            UserStore.setStatus(msg.user_id, msg.props.status);
         }
     },
     updateTitle: function() {
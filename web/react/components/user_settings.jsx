@@ -1044,6 +1044,27 @@ var AppearanceTab = React.createClass({
             }.bind(this)
         );
     },
+    submitLinkPreviews: function(e) {
+        e.preventDefault();
+        var user = UserStore.getCurrentUser();
+        if (!user.props) user.props = {};
+        user.props.link_previews = this.state.link_previews;
+
+        client.updateUser(user,
+            function(data) {
+                this.props.updateSection("");
+                AsyncClient.getMe();
+            }.bind(this),
+            function(err) {
+                state = this.getInitialState();
+                state.server_error = err;
+                this.setState(state);
+            }.bind(this)
+        );
+    },
+    handleLinkPreviewsRadio: function(val) {
+        this.setState({ link_previews: val });
+    },
     updateTheme: function(e) {
         var hex = utils.rgb2hex(e.target.style.backgroundColor);
         this.setState({ theme: hex.toLowerCase() });
@@ -1065,7 +1086,8 @@ var AppearanceTab = React.createClass({
         if (user.props && user.props.theme) {
             theme = user.props.theme;
         }
-        return { theme: theme.toLowerCase() };
+        var link_previews = user.props && user.props.link_previews === "false" ? "false" : "true";
+        return { theme: theme.toLowerCase(), link_previews: link_previews };
     },
     render: function() {
         var server_error = this.state.server_error ? this.state.server_error : null;
@@ -1112,6 +1134,46 @@ var AppearanceTab = React.createClass({
             }
         }
 
+        var linkPreviewsSection;
+        if (this.props.activeSection === 'link_previews') {
+            var linkPreviewsActive = ["",""];
+            if (this.state.link_previews === "false") {
+                linkPreviewsActive[1] = "active";
+            } else {
+                linkPreviewsActive[0] = "active";
+            }
+
+            var inputs = [];
+
+            inputs.push(
+                <div>
+                    <div className="btn-group" data-toggle="buttons-radio">
+                        <button className={"btn btn-default "+linkPreviewsActive[0]} onClick={function(){self.handleLinkPreviewsRadio("true")}}>On</button>
+                        <button className={"btn btn-default "+linkPreviewsActive[1]} onClick={function(){self.handleLinkPreviewsRadio("false")}}>Off</button>
+                    </div>
+                    <div><br/>Show a preview of the first link in the messages you post</div>
+                </div>
+            );
+
+            linkPreviewsSection = (
+                <SettingItemMax
+                    title="Link previews"
+                    inputs={inputs}
+                    submit={this.submitLinkPreviews}
+                    server_error={server_error}
+                    updateSection={function(e){self.props.updateSection("");e.preventDefault();}}
+                />
+            );
+        } else {
+            linkPreviewsSection = (
+                <SettingItemMin
+                    title="Link previews"
+                    describe={this.state.link_previews === "false" ? "Off" : "On"}
+                    updateSection={function(){self.props.updateSection("link_previews");}}
+                />
+            );
+        }
+
         return (
             <div>
                 <div className="modal-header">
@@ -1123,6 +1185,8 @@ var AppearanceTab = React.createClass({
                     <div className="divider-dark first"/>
                     {themeSection}
                     <div className="divider-dark"/>
+                    {linkPreviewsSection}
+                    <div className="divider-dark"/>
                 </div>
             </div>
         );
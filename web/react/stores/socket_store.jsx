@@ -13,6 +13,12 @@ var ActionTypes = Constants.ActionTypes;
 var CHANGE_EVENT = 'change';
 
 var conn;
+var stream;
+var failedConnects = 0;
+
+// After this many websockets close without ever opening we assume something
+// between us and the server breaks them and fall back to an event stream
+var MAX_FAILED_CONNECTS = 3;
 
 var SocketStore = assign({}, EventEmitter.prototype, {
   initialize: function(self) {
@@ -21,17 +27,24 @@ var SocketStore = assign({}, EventEmitter.prototype, {
     if (!self) self = this;
     self.setMaxListeners(0);
 
-    if (window["WebSocket"] && !conn) {
+    if (window["WebSocket"] && !conn && failedConnects < MAX_FAILED_CONNECTS) {
       var protocol = window.location.protocol == "https:" ? "wss://" : "ws://";
       var port = window.location.protocol == "https:" ? ":8443" : "";
       var conn_url = protocol + location.host + port + "/api/v1/websocket";
       console.log("connecting to " + conn_url);
       conn = new WebSocket(conn_url);
+      var opened = false;
+
+      conn.onopen = function() {
+        opened = true;
+        failedConnects = 0;
+      };
 
       conn.onclose = function(evt) {
         console.log("websocket closed");
         console.log(evt);
         conn = null;
+        if (!opened) failedConnects++;
         setTimeout(function(){self.initialize(self)}, 3000);
       };
 
@@ -46,6 +59,28 @@ var SocketStore = assign({}, EventEmitter.prototype, {
           msg: JSON.parse(evt.data)
         });
       };
+    } else if (window["EventSource"] && !conn && !stream) {
+      console.log("falling back to the event stream");
+      // EventSource reconnects by itself so there is no onclose to handle
+      stream = new EventSource("/api/v1/events/stream");
+
+      stream.onmessage = function(evt) {
+        AppDispatcher.handleServerAction({
+          type: ActionTypes.RECIEVED_MSG,
+          msg: JSON.parse(evt.data)
+        });
+      };
+
+      // The server closed the stream on purpose, reconnect unless it was
+      // because the session was revoked
+      stream.addEventListener("close", function(evt) {
+        console.log("event stream closed: " + evt.data);
+        stream.close();
+        stream = null;
+        if (evt.data !== "session revoked") {
+          setTimeout(function(){self.initialize(self)}, 3000);
+        }
+      });
     }
   },
   emitChange: function(msg) {
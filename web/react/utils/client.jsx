@@ -537,6 +537,64 @@ module.exports.updateLastViewedAt = function(channelId, success, error) {
     });
 };
 
+module.exports.getDrafts = function(success, error) {
+    $.ajax({
+        url: "/api/v1/drafts/",
+        dataType: 'json',
+        type: 'GET',
+        success: success,
+        error: function(xhr, status, err) {
+            e = handleError("getDrafts", xhr, status, err);
+            error(e);
+        }
+    });
+};
+
+module.exports.upsertDraft = function(draft, success, error) {
+    $.ajax({
+        url: "/api/v1/drafts/upsert",
+        dataType: 'json',
+        contentType: 'application/json',
+        type: 'POST',
+        data: JSON.stringify(draft),
+        success: success,
+        error: function(xhr, status, err) {
+            e = handleError("upsertDraft", xhr, status, err);
+            error(e);
+        }
+    });
+};
+
+module.exports.deleteDraft = function(channelId, rootId, success, error) {
+    $.ajax({
+        url: "/api/v1/drafts/delete",
+        dataType: 'json',
+        contentType: 'application/json',
+        type: 'POST',
+        data: JSON.stringify({channel_id: channelId, root_id: rootId}),
+        success: success,
+        error: function(xhr, status, err) {
+            e = handleError("deleteDraft", xhr, status, err);
+            error(e);
+        }
+    });
+};
+
+module.exports.markChannelUnread = function(channelId, postId, success, error) {
+    $.ajax({
+        url: "/api/v1/channels/" + channelId + "/mark_unread",
+        dataType: 'json',
+        contentType: 'application/json',
+        type: 'POST',
+        data: JSON.stringify({post_id: postId}),
+        success: success,
+        error: function(xhr, status, err) {
+            e = handleError("markChannelUnread", xhr, status, err);
+            error(e);
+        }
+    });
+};
+
 module.exports.getChannels = function(success, error) {
     $.ajax({
         url: "/api/v1/channels/",
@@ -584,7 +642,7 @@ module.exports.executeCommand = function(channelId, command, suggest, success, e
         dataType: 'json',
         contentType: 'application/json',
         type: 'POST',
-        data: JSON.stringify({channelId: channelId, command: command, suggest: "" + suggest}),
+        data: JSON.stringify({channelId: channelId, command: command, suggest: "" + suggest, utcOffset: "" + (-new Date().getTimezoneOffset())}),
         success: success,
         error: function(xhr, status, err) {
             e = handleError("executeCommand", xhr, status, err);
@@ -15,6 +15,34 @@ module.exports.isEmail = function(email) {
   return regex.test(email);
 };
 
+module.exports.isSystemMessage = function(post) {
+    return Boolean(post.type) && post.type.indexOf("system_") === 0;
+};
+
+module.exports.getPostAttachments = function(post) {
+    if (!post.props || !post.props.attachments) {
+        return [];
+    }
+
+    try {
+        return JSON.parse(post.props.attachments) || [];
+    } catch (e) {
+        return [];
+    }
+};
+
+module.exports.getLinkPreview = function(post) {
+    if (!post.props || !post.props.link_preview) {
+        return null;
+    }
+
+    try {
+        return JSON.parse(post.props.link_preview);
+    } catch (e) {
+        return null;
+    }
+};
+
 module.exports.cleanUpUrlable = function(input) {
 	var cleaned = input.trim().replace(/-/g, ' ').replace(/[^\w\s]/gi, '').toLowerCase().replace(/\s/g, '-');
 	cleaned = cleaned.replace(/^\-+/, '');